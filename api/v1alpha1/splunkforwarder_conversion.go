@@ -0,0 +1,201 @@
+package v1alpha1
+
+import (
+	"slices"
+
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/openshift/splunk-forwarder-operator/api/v1beta1"
+)
+
+// HeavyForwarderImageTagAnnotation preserves the v1beta1 heavyForwarder.image.tag, which has no
+// v1alpha1 field, when a v1beta1 SplunkForwarder is stored as v1alpha1.
+const HeavyForwarderImageTagAnnotation = "splunkforwarder.managed.openshift.io/heavy-forwarder-image-tag"
+
+// blank assignment to verify that SplunkForwarder implements conversion.Convertible
+var _ conversion.Convertible = &SplunkForwarder{}
+
+// ConvertTo converts this SplunkForwarder to the hub version (v1beta1).
+func (src *SplunkForwarder) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.SplunkForwarder)
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	heavyForwarderImageTag := ""
+	if tag, ok := dst.Annotations[HeavyForwarderImageTagAnnotation]; ok {
+		heavyForwarderImageTag = tag
+		delete(dst.Annotations, HeavyForwarderImageTagAnnotation)
+		if len(dst.Annotations) == 0 {
+			dst.Annotations = nil
+		}
+	}
+
+	dst.Spec = v1beta1.SplunkForwarderSpec{
+		SplunkLicenseAccepted: src.Spec.SplunkLicenseAccepted,
+		Forwarder: v1beta1.ForwarderSpec{
+			Image: v1beta1.ImageSpec{
+				Repository: src.Spec.Image,
+				Tag:        src.Spec.ImageTag,
+				Digest:     src.Spec.ImageDigest,
+			},
+			ImagePullPolicy:  src.Spec.ImagePullPolicy,
+			ImagePullSecrets: slices.Clone(src.Spec.ImagePullSecrets),
+		},
+		HeavyForwarder: v1beta1.HeavyForwarderSpec{
+			Enabled: src.Spec.UseHeavyForwarder,
+			Image: v1beta1.ImageSpec{
+				Repository: src.Spec.HeavyForwarderImage,
+				Tag:        heavyForwarderImageTag,
+				Digest:     src.Spec.HeavyForwarderDigest,
+			},
+			Replicas: src.Spec.HeavyForwarderReplicas,
+			NodeRole: src.Spec.HeavyForwarderSelector,
+		},
+		Outputs: v1beta1.OutputsSpec{
+			ClusterID:      src.Spec.ClusterID,
+			MetadataFields: (*v1beta1.SplunkMetadataFields)(src.Spec.MetadataFields.DeepCopy()),
+		},
+		Auth: v1beta1.AuthSpec{
+			CertificateIssuerRef: (*v1beta1.CertificateIssuerReference)(src.Spec.CertificateIssuerRef.DeepCopy()),
+			TrustedCABundle:      src.Spec.TrustedCABundle,
+			FIPS:                 src.Spec.FIPS,
+		},
+		EventsCollector:  (*v1beta1.SplunkEventsCollector)(src.Spec.EventsCollector.DeepCopy()),
+		Throughput:       (*v1beta1.SplunkThroughput)(src.Spec.Throughput.DeepCopy()),
+		Queues:           (*v1beta1.SplunkQueues)(src.Spec.Queues.DeepCopy()),
+		ReliableDelivery: (*v1beta1.SplunkReliableDelivery)(src.Spec.ReliableDelivery.DeepCopy()),
+	}
+	if src.Spec.SplunkInputs != nil {
+		dst.Spec.Forwarder.Inputs = make([]v1beta1.SplunkForwarderInputs, len(src.Spec.SplunkInputs))
+		for i, input := range src.Spec.SplunkInputs {
+			dst.Spec.Forwarder.Inputs[i] = v1beta1.SplunkForwarderInputs(*input.DeepCopy())
+		}
+	}
+	if src.Spec.Filters != nil {
+		dst.Spec.HeavyForwarder.Filters = make([]v1beta1.SplunkFilter, len(src.Spec.Filters))
+		for i, filter := range src.Spec.Filters {
+			dst.Spec.HeavyForwarder.Filters[i] = v1beta1.SplunkFilter(filter)
+		}
+	}
+	if src.Spec.PodLogs != nil {
+		dst.Spec.Forwarder.PodLogs = make([]v1beta1.SplunkPodLogsInput, len(src.Spec.PodLogs))
+		for i, input := range src.Spec.PodLogs {
+			dst.Spec.Forwarder.PodLogs[i] = v1beta1.SplunkPodLogsInput(*input.DeepCopy())
+		}
+	}
+	if src.Spec.ScriptedInputs != nil {
+		dst.Spec.Forwarder.ScriptedInputs = make([]v1beta1.SplunkScriptedInput, len(src.Spec.ScriptedInputs))
+		for i, input := range src.Spec.ScriptedInputs {
+			dst.Spec.Forwarder.ScriptedInputs[i] = v1beta1.SplunkScriptedInput(input)
+		}
+	}
+	if src.Spec.Apps != nil {
+		dst.Spec.Forwarder.Apps = make([]v1beta1.SplunkApp, len(src.Spec.Apps))
+		for i, app := range src.Spec.Apps {
+			dst.Spec.Forwarder.Apps[i] = v1beta1.SplunkApp(app)
+		}
+	}
+	if src.Spec.Masking != nil {
+		dst.Spec.Masking = &v1beta1.SplunkMasking{DisableBuiltinRules: src.Spec.Masking.DisableBuiltinRules}
+		if src.Spec.Masking.Rules != nil {
+			dst.Spec.Masking.Rules = make([]v1beta1.SplunkMaskingRule, len(src.Spec.Masking.Rules))
+			for i, rule := range src.Spec.Masking.Rules {
+				dst.Spec.Masking.Rules[i] = v1beta1.SplunkMaskingRule(rule)
+			}
+		}
+	}
+	if src.Spec.RolloutStrategy != nil {
+		dst.Spec.Forwarder.RolloutStrategy = &v1beta1.SplunkRolloutStrategy{
+			Canary:       (*v1beta1.SplunkCanaryRollout)(src.Spec.RolloutStrategy.Canary.DeepCopy()),
+			AutoRollback: (*v1beta1.SplunkAutoRollback)(src.Spec.RolloutStrategy.AutoRollback.DeepCopy()),
+		}
+	}
+
+	dst.Status = v1beta1.SplunkForwarderStatus(*src.Status.DeepCopy())
+
+	return nil
+}
+
+// ConvertFrom converts from the hub version (v1beta1) to this version.
+func (dst *SplunkForwarder) ConvertFrom(srcRaw conversion.Hub) error {
+	src := srcRaw.(*v1beta1.SplunkForwarder)
+
+	dst.ObjectMeta = *src.ObjectMeta.DeepCopy()
+	if tag := src.Spec.HeavyForwarder.Image.Tag; tag != "" {
+		if dst.Annotations == nil {
+			dst.Annotations = map[string]string{}
+		}
+		dst.Annotations[HeavyForwarderImageTagAnnotation] = tag
+	}
+
+	dst.Spec = SplunkForwarderSpec{
+		SplunkLicenseAccepted:  src.Spec.SplunkLicenseAccepted,
+		Image:                  src.Spec.Forwarder.Image.Repository,
+		ImageTag:               src.Spec.Forwarder.Image.Tag,
+		ImageDigest:            src.Spec.Forwarder.Image.Digest,
+		ImagePullPolicy:        src.Spec.Forwarder.ImagePullPolicy,
+		ImagePullSecrets:       slices.Clone(src.Spec.Forwarder.ImagePullSecrets),
+		ClusterID:              src.Spec.Outputs.ClusterID,
+		UseHeavyForwarder:      src.Spec.HeavyForwarder.Enabled,
+		HeavyForwarderImage:    src.Spec.HeavyForwarder.Image.Repository,
+		HeavyForwarderDigest:   src.Spec.HeavyForwarder.Image.Digest,
+		HeavyForwarderReplicas: src.Spec.HeavyForwarder.Replicas,
+		HeavyForwarderSelector: src.Spec.HeavyForwarder.NodeRole,
+		CertificateIssuerRef:   (*CertificateIssuerReference)(src.Spec.Auth.CertificateIssuerRef.DeepCopy()),
+		TrustedCABundle:        src.Spec.Auth.TrustedCABundle,
+		FIPS:                   src.Spec.Auth.FIPS,
+		MetadataFields:         (*SplunkMetadataFields)(src.Spec.Outputs.MetadataFields.DeepCopy()),
+		EventsCollector:        (*SplunkEventsCollector)(src.Spec.EventsCollector.DeepCopy()),
+		Throughput:             (*SplunkThroughput)(src.Spec.Throughput.DeepCopy()),
+		Queues:                 (*SplunkQueues)(src.Spec.Queues.DeepCopy()),
+		ReliableDelivery:       (*SplunkReliableDelivery)(src.Spec.ReliableDelivery.DeepCopy()),
+	}
+	if src.Spec.Forwarder.Inputs != nil {
+		dst.Spec.SplunkInputs = make([]SplunkForwarderInputs, len(src.Spec.Forwarder.Inputs))
+		for i, input := range src.Spec.Forwarder.Inputs {
+			dst.Spec.SplunkInputs[i] = SplunkForwarderInputs(*input.DeepCopy())
+		}
+	}
+	if src.Spec.HeavyForwarder.Filters != nil {
+		dst.Spec.Filters = make([]SplunkFilter, len(src.Spec.HeavyForwarder.Filters))
+		for i, filter := range src.Spec.HeavyForwarder.Filters {
+			dst.Spec.Filters[i] = SplunkFilter(filter)
+		}
+	}
+	if src.Spec.Forwarder.PodLogs != nil {
+		dst.Spec.PodLogs = make([]SplunkPodLogsInput, len(src.Spec.Forwarder.PodLogs))
+		for i, input := range src.Spec.Forwarder.PodLogs {
+			dst.Spec.PodLogs[i] = SplunkPodLogsInput(*input.DeepCopy())
+		}
+	}
+	if src.Spec.Forwarder.ScriptedInputs != nil {
+		dst.Spec.ScriptedInputs = make([]SplunkScriptedInput, len(src.Spec.Forwarder.ScriptedInputs))
+		for i, input := range src.Spec.Forwarder.ScriptedInputs {
+			dst.Spec.ScriptedInputs[i] = SplunkScriptedInput(input)
+		}
+	}
+	if src.Spec.Forwarder.Apps != nil {
+		dst.Spec.Apps = make([]SplunkApp, len(src.Spec.Forwarder.Apps))
+		for i, app := range src.Spec.Forwarder.Apps {
+			dst.Spec.Apps[i] = SplunkApp(app)
+		}
+	}
+	if src.Spec.Masking != nil {
+		dst.Spec.Masking = &SplunkMasking{DisableBuiltinRules: src.Spec.Masking.DisableBuiltinRules}
+		if src.Spec.Masking.Rules != nil {
+			dst.Spec.Masking.Rules = make([]SplunkMaskingRule, len(src.Spec.Masking.Rules))
+			for i, rule := range src.Spec.Masking.Rules {
+				dst.Spec.Masking.Rules[i] = SplunkMaskingRule(rule)
+			}
+		}
+	}
+	if src.Spec.Forwarder.RolloutStrategy != nil {
+		dst.Spec.RolloutStrategy = &SplunkRolloutStrategy{
+			Canary:       (*SplunkCanaryRollout)(src.Spec.Forwarder.RolloutStrategy.Canary.DeepCopy()),
+			AutoRollback: (*SplunkAutoRollback)(src.Spec.Forwarder.RolloutStrategy.AutoRollback.DeepCopy()),
+		}
+	}
+
+	dst.Status = SplunkForwarderStatus(*src.Status.DeepCopy())
+
+	return nil
+}
@@ -0,0 +1,131 @@
+package v1alpha1
+
+import (
+	"math/rand"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/api/apitesting/fuzzer"
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	metafuzzer "k8s.io/apimachinery/pkg/apis/meta/fuzzer"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/util/diff"
+	"sigs.k8s.io/randfill"
+
+	"github.com/openshift/splunk-forwarder-operator/api/v1beta1"
+)
+
+const fuzzIterations = 1000
+
+func conversionFiller(t *testing.T) *randfill.Filler {
+	scheme := runtime.NewScheme()
+	if err := AddToScheme(scheme); err != nil {
+		t.Fatalf("unable to add v1alpha1 scheme: %v", err)
+	}
+	if err := v1beta1.AddToScheme(scheme); err != nil {
+		t.Fatalf("unable to add v1beta1 scheme: %v", err)
+	}
+	funcs := fuzzer.MergeFuzzerFuncs(metafuzzer.Funcs, func(serializer.CodecFactory) []interface{} {
+		return []interface{}{
+			// The apiVersion and kind are set by the conversion webhook, not by the conversion functions
+			func(in *metav1.TypeMeta, c randfill.Continue) {
+				*in = metav1.TypeMeta{}
+			},
+		}
+	})
+	return fuzzer.FuzzerFor(funcs, rand.NewSource(rand.Int63()), serializer.NewCodecFactory(scheme))
+}
+
+func TestFuzzyConversion(t *testing.T) {
+	filler := conversionFiller(t)
+
+	t.Run("v1alpha1 to v1beta1 and back", func(t *testing.T) {
+		for i := 0; i < fuzzIterations; i++ {
+			spoke := &SplunkForwarder{}
+			filler.Fill(spoke)
+			// The annotation only holds v1beta1 data
+			delete(spoke.Annotations, HeavyForwarderImageTagAnnotation)
+
+			hub := &v1beta1.SplunkForwarder{}
+			if err := spoke.ConvertTo(hub); err != nil {
+				t.Fatalf("ConvertTo() error = %v", err)
+			}
+			got := &SplunkForwarder{}
+			if err := got.ConvertFrom(hub); err != nil {
+				t.Fatalf("ConvertFrom() error = %v", err)
+			}
+			if !apiequality.Semantic.DeepEqual(spoke, got) {
+				t.Fatalf("round trip changed the object: %s", diff.Diff(spoke, got))
+			}
+		}
+	})
+
+	t.Run("v1beta1 to v1alpha1 and back", func(t *testing.T) {
+		for i := 0; i < fuzzIterations; i++ {
+			hub := &v1beta1.SplunkForwarder{}
+			filler.Fill(hub)
+			delete(hub.Annotations, HeavyForwarderImageTagAnnotation)
+
+			spoke := &SplunkForwarder{}
+			if err := spoke.ConvertFrom(hub); err != nil {
+				t.Fatalf("ConvertFrom() error = %v", err)
+			}
+			got := &v1beta1.SplunkForwarder{}
+			if err := spoke.ConvertTo(got); err != nil {
+				t.Fatalf("ConvertTo() error = %v", err)
+			}
+			if !apiequality.Semantic.DeepEqual(hub, got) {
+				t.Fatalf("round trip changed the object: %s", diff.Diff(hub, got))
+			}
+		}
+	})
+}
+
+func TestConvertTo(t *testing.T) {
+	spoke := &SplunkForwarder{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "openshift-test"},
+		Spec: SplunkForwarderSpec{
+			SplunkLicenseAccepted:  true,
+			Image:                  "test-image",
+			ImageDigest:            "sha256:2452a3f01e840661ee1194777ed5a9185ceaaa9ec7329ed364fa2f02be22a701",
+			ClusterID:              "test",
+			SplunkInputs:           []SplunkForwarderInputs{{Path: "/var/log/test", Index: "main"}},
+			UseHeavyForwarder:      true,
+			HeavyForwarderImage:    "test-hf-image",
+			HeavyForwarderReplicas: 3,
+			HeavyForwarderSelector: "infra",
+			Filters:                []SplunkFilter{{Name: "debug", Filter: "DEBUG"}},
+			CertificateIssuerRef:   &CertificateIssuerReference{Name: "splunk-ca"},
+		},
+	}
+	want := v1beta1.SplunkForwarderSpec{
+		SplunkLicenseAccepted: true,
+		Forwarder: v1beta1.ForwarderSpec{
+			Image: v1beta1.ImageSpec{
+				Repository: "test-image",
+				Digest:     "sha256:2452a3f01e840661ee1194777ed5a9185ceaaa9ec7329ed364fa2f02be22a701",
+			},
+			Inputs: []v1beta1.SplunkForwarderInputs{{Path: "/var/log/test", Index: "main"}},
+		},
+		HeavyForwarder: v1beta1.HeavyForwarderSpec{
+			Enabled:  true,
+			Image:    v1beta1.ImageSpec{Repository: "test-hf-image"},
+			Replicas: 3,
+			NodeRole: "infra",
+			Filters:  []v1beta1.SplunkFilter{{Name: "debug", Filter: "DEBUG"}},
+		},
+		Outputs: v1beta1.OutputsSpec{ClusterID: "test"},
+		Auth: v1beta1.AuthSpec{
+			CertificateIssuerRef: &v1beta1.CertificateIssuerReference{Name: "splunk-ca"},
+		},
+	}
+
+	hub := &v1beta1.SplunkForwarder{}
+	if err := spoke.ConvertTo(hub); err != nil {
+		t.Fatalf("ConvertTo() error = %v", err)
+	}
+	if !apiequality.Semantic.DeepEqual(hub.Spec, want) {
+		t.Errorf("ConvertTo() spec: %s", diff.Diff(want, hub.Spec))
+	}
+}
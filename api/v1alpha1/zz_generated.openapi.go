@@ -91,21 +91,47 @@ func schema_openshift_splunk_forwarder_operator_api_v1alpha1_SplunkForwarderSpec
 					},
 					"imageTag": {
 						SchemaProps: spec.SchemaProps{
-							Description: "The container image tag of the Splunk Forwarder image. Is not used if ImageDigest is supplied. Optional: Defaults to latest",
+							Description: "The container image tag of the Splunk Forwarder image, resolved once to its digest. Is not used if ImageDigest is supplied. Must not be latest. Required unless ImageDigest is supplied.",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
 					"imageDigest": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Container image digest of the Splunk Forwarder image. Has precedence and is recommended over ImageTag. Optional: Defaults to latest",
+							Description: "Container image digest of the Splunk Forwarder image. Has precedence and is recommended over ImageTag. Required unless ImageTag is supplied.",
 							Type:        []string{"string"},
 							Format:      "",
 						},
 					},
+					"imagePullPolicy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Pull policy of the Splunk Forwarder image. Optional: Defaults to IfNotPresent when the image is pinned by digest, and Always otherwise",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"imagePullSecrets": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-type": "set",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "Names of the Secrets, in the namespace of the CR, used to pull the Splunk Forwarder image in addition to the pull secrets of the service account. Optional: Defaults to the pull secrets of the service account.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Type:   []string{"string"},
+										Format: "",
+									},
+								},
+							},
+						},
+					},
 					"clusterID": {
 						SchemaProps: spec.SchemaProps{
-							Description: "Unique cluster name. Optional: Looked up on the cluster if not provided, default to openshift",
+							Description: "Unique cluster name. Optional: Looked up from the infrastructure name of the cluster if not provided",
 							Type:        []string{"string"},
 							Format:      "",
 						},
@@ -185,12 +211,140 @@ func schema_openshift_splunk_forwarder_operator_api_v1alpha1_SplunkForwarderSpec
 							},
 						},
 					},
+					"certificateIssuerRef": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Reference to a cert-manager Issuer or ClusterIssuer that issues the forwarder client certificate. When set, the operator requests a cert-manager Certificate and writes the issued key pair into the splunk-auth Secret as server.pem and cacert.pem. cacert.pem holds the trusted CA bundle when the issuer does not publish its CA. Removing the reference deletes the Certificate and its issued Secret. Optional: Defaults to mTLS material being placed into splunk-auth by hand.",
+							Ref:         ref("github.com/openshift/splunk-forwarder-operator/api/v1alpha1.CertificateIssuerReference"),
+						},
+					},
+					"trustedCABundle": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Whether the forwarders verify the receivers against the trusted CA bundle of the cluster, which includes the additional CAs of the cluster-wide Proxy, on top of the CA of the splunk-auth Secret. The operator creates a ConfigMap the bundle is injected into, and rolls the forwarders when the bundle changes. Optional: Defaults to false, verifying the receivers against the splunk-auth Secret only.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"fips": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Whether the forwarders run in FIPS mode: FIPS-approved cipher suites and TLS versions only, with SPLUNK_FIPS set. Auto follows the fips setting of the cluster install-config. Configurations with non-FIPS ciphers are not rolled out while FIPS mode is on. Optional: Defaults to Auto.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"metadataFields": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Cluster metadata added as indexed fields to every input, in addition to the cluster ID. Optional: Defaults to only the cluster ID.",
+							Ref:         ref("github.com/openshift/splunk-forwarder-operator/api/v1alpha1.SplunkMetadataFields"),
+						},
+					},
+					"masking": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Masking rules that redact sensitive data, such as tokens and passwords, from the events before they leave the cluster. The rules are applied on the Heavy Forwarder when it is used, and on the Universal Forwarders otherwise. Optional: Defaults to no masking.",
+							Ref:         ref("github.com/openshift/splunk-forwarder-operator/api/v1alpha1.SplunkMasking"),
+						},
+					},
+					"podLogs": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"name",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "Container logs of the pods selected by namespace and labels. The operator monitors the log directories of the matching pods, keeps them in sync as pods come and go, and adds the namespace, pod and container to the events. Optional: Defaults to no pod logs.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/openshift/splunk-forwarder-operator/api/v1alpha1.SplunkPodLogsInput"),
+									},
+								},
+							},
+						},
+					},
+					"scriptedInputs": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"name",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "Scripts, taken from ConfigMaps, whose output is indexed on a schedule by every forwarder. Optional: Defaults to no scripted inputs.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/openshift/splunk-forwarder-operator/api/v1alpha1.SplunkScriptedInput"),
+									},
+								},
+							},
+						},
+					},
+					"apps": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"name",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "Extra Splunk apps, such as technology add-ons, mounted into every forwarder from ConfigMaps, Secrets or OCI images. The forwarders are restarted when the content of a ConfigMap or Secret changes. Optional: Defaults to no extra apps.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("github.com/openshift/splunk-forwarder-operator/api/v1alpha1.SplunkApp"),
+									},
+								},
+							},
+						},
+					},
+					"eventsCollector": {
+						SchemaProps: spec.SchemaProps{
+							Description: "A Deployment collecting the cluster Events, and optionally the objects of selected resources, as JSON and forwarding them with the authentication of the Universal Forwarders. Optional: Defaults to no events collector.",
+							Ref:         ref("github.com/openshift/splunk-forwarder-operator/api/v1alpha1.SplunkEventsCollector"),
+						},
+					},
+					"throughput": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Throughput limits of the forwarders, applied to the Universal and Heavy Forwarders. Optional: Defaults to an unlimited rate and one pipeline.",
+							Ref:         ref("github.com/openshift/splunk-forwarder-operator/api/v1alpha1.SplunkThroughput"),
+						},
+					},
+					"queues": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Queue sizes and receiver settings of the forwarders, applied to the Universal and Heavy Forwarders. Optional: Defaults to the Splunk defaults.",
+							Ref:         ref("github.com/openshift/splunk-forwarder-operator/api/v1alpha1.SplunkQueues"),
+						},
+					},
+					"reliableDelivery": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Guaranteed delivery of the events, through indexer acknowledgement and persistent queues. Optional: Defaults to best effort delivery.",
+							Ref:         ref("github.com/openshift/splunk-forwarder-operator/api/v1alpha1.SplunkReliableDelivery"),
+						},
+					},
+					"rolloutStrategy": {
+						SchemaProps: spec.SchemaProps{
+							Description: "How changes of the forwarders, such as a new image or new inputs, are rolled out to the nodes. Optional: Defaults to rolling changes out to every node, one node at a time, with automatic rollback.",
+							Ref:         ref("github.com/openshift/splunk-forwarder-operator/api/v1alpha1.SplunkRolloutStrategy"),
+						},
+					},
 				},
 				Required: []string{"image", "splunkInputs"},
 			},
 		},
 		Dependencies: []string{
-			"github.com/openshift/splunk-forwarder-operator/api/v1alpha1.SplunkFilter", "github.com/openshift/splunk-forwarder-operator/api/v1alpha1.SplunkForwarderInputs"},
+			"github.com/openshift/splunk-forwarder-operator/api/v1alpha1.CertificateIssuerReference", "github.com/openshift/splunk-forwarder-operator/api/v1alpha1.SplunkApp", "github.com/openshift/splunk-forwarder-operator/api/v1alpha1.SplunkEventsCollector", "github.com/openshift/splunk-forwarder-operator/api/v1alpha1.SplunkFilter", "github.com/openshift/splunk-forwarder-operator/api/v1alpha1.SplunkForwarderInputs", "github.com/openshift/splunk-forwarder-operator/api/v1alpha1.SplunkMasking", "github.com/openshift/splunk-forwarder-operator/api/v1alpha1.SplunkMetadataFields", "github.com/openshift/splunk-forwarder-operator/api/v1alpha1.SplunkPodLogsInput", "github.com/openshift/splunk-forwarder-operator/api/v1alpha1.SplunkQueues", "github.com/openshift/splunk-forwarder-operator/api/v1alpha1.SplunkReliableDelivery", "github.com/openshift/splunk-forwarder-operator/api/v1alpha1.SplunkRolloutStrategy", "github.com/openshift/splunk-forwarder-operator/api/v1alpha1.SplunkScriptedInput", "github.com/openshift/splunk-forwarder-operator/api/v1alpha1.SplunkThroughput"},
 	}
 }
 
@@ -200,7 +354,109 @@ func schema_openshift_splunk_forwarder_operator_api_v1alpha1_SplunkForwarderStat
 			SchemaProps: spec.SchemaProps{
 				Description: "SplunkForwarderStatus defines the observed state of SplunkForwarder",
 				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"conditions": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"type",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "Conditions describe the state of the forwarder configuration.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("k8s.io/apimachinery/pkg/apis/meta/v1.Condition"),
+									},
+								},
+							},
+						},
+					},
+					"observedGeneration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The most recent generation of the CR that was reconciled.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"desiredNumberScheduled": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Number of nodes that should be running a forwarder pod, across all forwarder DaemonSets.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"numberReady": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Number of nodes running a ready forwarder pod, across all forwarder DaemonSets.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"deliveryMode": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Delivery guarantee of the forwarders: Reliable when reliableDelivery is enabled and the forwarders send over mTLS, BestEffort otherwise. With the HEC token, acknowledgement is configured in the splunk-hec-token Secret.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"fipsMode": {
+						SchemaProps: spec.SchemaProps{
+							Description: "FIPS mode of the forwarders, as chosen by the fips field or detected from the cluster.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"resolvedImage": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The image:tag reference imageDigest was resolved from.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"imageDigest": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Digest the image tag was resolved to, which the forwarders run until the image or the tag changes.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"canaryRevision": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The rollout revision, a hash of the forwarder DaemonSets and ConfigMaps, the canary is testing.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"canaryPhase": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Phase of the canary rollout of the canary revision.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"canaryPhaseTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "When the canary rollout entered its phase.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"canaryMessage": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Details of the canary phase, such as the pods that failed the canary.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
 			},
 		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Condition", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
 	}
 }
@@ -0,0 +1,552 @@
+/*
+Copyright 2022.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SplunkForwarderSpec defines the desired state of SplunkForwarder
+// +k8s:openapi-gen=true
+type SplunkForwarderSpec struct {
+	// Adds an --accept-license flag to automatically accept the Splunk License Agreement.
+	// Must be true for the Red Hat provided Splunk Forwarder image.
+	// Optional: Defaults to false.
+	SplunkLicenseAccepted bool `json:"splunkLicenseAccepted,omitempty"`
+	// The Splunk Universal Forwarders running on every node.
+	Forwarder ForwarderSpec `json:"forwarder"`
+	// An optional Splunk Heavy Forwarder the Universal Forwarders send their events through.
+	// Optional: Defaults to sending the events directly.
+	HeavyForwarder HeavyForwarderSpec `json:"heavyForwarder,omitempty"`
+	// How the events are tagged before they are sent.
+	Outputs OutputsSpec `json:"outputs,omitempty"`
+	// Masking rules that redact sensitive data, such as tokens and passwords, from the events before
+	// they leave the cluster. The rules are applied on the Heavy Forwarder when it is used, and on the
+	// Universal Forwarders otherwise.
+	// Optional: Defaults to no masking.
+	Masking *SplunkMasking `json:"masking,omitempty"`
+	// A Deployment collecting the cluster Events, and optionally the objects of selected resources,
+	// as JSON and forwarding them with the authentication of the Universal Forwarders.
+	// Optional: Defaults to no events collector.
+	EventsCollector *SplunkEventsCollector `json:"eventsCollector,omitempty"`
+	// Throughput limits of the forwarders, applied to the Universal and Heavy Forwarders.
+	// Optional: Defaults to an unlimited rate and one pipeline.
+	Throughput *SplunkThroughput `json:"throughput,omitempty"`
+	// Queue sizes and receiver settings of the forwarders, applied to the Universal and Heavy Forwarders.
+	// Optional: Defaults to the Splunk defaults.
+	Queues *SplunkQueues `json:"queues,omitempty"`
+	// Guaranteed delivery of the events, through indexer acknowledgement and persistent queues.
+	// Optional: Defaults to best effort delivery.
+	ReliableDelivery *SplunkReliableDelivery `json:"reliableDelivery,omitempty"`
+	// How the forwarders authenticate against Splunk. The HEC token in the splunk-hec-token Secret is
+	// used when present, otherwise the mTLS material in the splunk-auth Secret.
+	Auth AuthSpec `json:"auth,omitempty"`
+}
+
+// ImageSpec is the struct that references a container image
+type ImageSpec struct {
+	// Container image path.
+	Repository string `json:"repository"`
+	// Container image tag. Is not used if Digest is supplied.
+	// Optional: Required for the forwarder image unless Digest is supplied.
+	Tag string `json:"tag,omitempty"`
+	// Container image digest. Has precedence and is recommended over Tag.
+	// Optional: Required for the forwarder image unless Tag is supplied.
+	Digest string `json:"digest,omitempty"`
+}
+
+// ForwarderSpec is the struct that configures the Splunk Universal Forwarders
+type ForwarderSpec struct {
+	// The Splunk Universal Forwarder image. Its tag is resolved once to its digest and must not be latest.
+	Image ImageSpec `json:"image"`
+	// Pull policy of the Splunk Universal Forwarder image.
+	// Optional: Defaults to IfNotPresent when the image is pinned by digest, and Always otherwise
+	// +kubebuilder:validation:Enum=Always;IfNotPresent;Never
+	ImagePullPolicy string `json:"imagePullPolicy,omitempty"`
+	// Names of the Secrets, in the namespace of the CR, used to pull the Splunk Universal Forwarder image in addition
+	// to the pull secrets of the service account.
+	// Optional: Defaults to the pull secrets of the service account.
+	// +listType=set
+	// +kubebuilder:validation:items:Pattern=`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`
+	ImagePullSecrets []string `json:"imagePullSecrets,omitempty"`
+	// +listType=atomic
+	Inputs []SplunkForwarderInputs `json:"inputs"`
+	// Container logs of the pods selected by namespace and labels. The operator monitors the log
+	// directories of the matching pods, keeps them in sync as pods come and go, and adds the
+	// namespace, pod and container to the events.
+	// Optional: Defaults to no pod logs.
+	// +listType=map
+	// +listMapKey=name
+	PodLogs []SplunkPodLogsInput `json:"podLogs,omitempty"`
+	// Scripts, taken from ConfigMaps, whose output is indexed on a schedule by every forwarder.
+	// Optional: Defaults to no scripted inputs.
+	// +listType=map
+	// +listMapKey=name
+	ScriptedInputs []SplunkScriptedInput `json:"scriptedInputs,omitempty"`
+	// Extra Splunk apps, such as technology add-ons, mounted into every forwarder from ConfigMaps, Secrets
+	// or OCI images. The forwarders are restarted when the content of a ConfigMap or Secret changes.
+	// Optional: Defaults to no extra apps.
+	// +listType=map
+	// +listMapKey=name
+	Apps []SplunkApp `json:"apps,omitempty"`
+	// How changes of the forwarders, such as a new image or new inputs, are rolled out to the nodes.
+	// Optional: Defaults to rolling changes out to every node, one node at a time, with automatic rollback.
+	RolloutStrategy *SplunkRolloutStrategy `json:"rolloutStrategy,omitempty"`
+}
+
+// HeavyForwarderSpec is the struct that configures the Splunk Heavy Forwarder
+type HeavyForwarderSpec struct {
+	// Whether the Splunk Heavy Forwarder should be deployed.
+	// Optional: Defaults to false.
+	Enabled bool `json:"enabled,omitempty"`
+	// The Splunk Heavy Forwarder image. Required when Enabled is true.
+	Image ImageSpec `json:"image,omitempty"`
+	// Number of desired Splunk Heavy Forwarder pods.
+	// Optional: Defaults to 2
+	Replicas int32 `json:"replicas,omitempty"`
+	// Node role the Splunk Heavy Forwarder pods are scheduled to, through the node selector
+	// with key: "node-role.kubernetes.io"
+	// Optional: Defaults to an empty value.
+	NodeRole string `json:"nodeRole,omitempty"`
+	// List of additional filters supplied to configure the Splunk Heavy Forwarder
+	// Optional: Defaults to no additional filters (no transforms.conf).
+	// +listType=map
+	// +listMapKey=name
+	Filters []SplunkFilter `json:"filters,omitempty"`
+}
+
+// OutputsSpec is the struct that configures how the events are tagged before they are sent
+type OutputsSpec struct {
+	// Unique cluster name.
+	// Optional: Looked up from the infrastructure name of the cluster if not provided
+	ClusterID string `json:"clusterID,omitempty"`
+	// Cluster metadata added as indexed fields to every input, in addition to the cluster ID.
+	// Optional: Defaults to only the cluster ID.
+	MetadataFields *SplunkMetadataFields `json:"metadataFields,omitempty"`
+}
+
+// AuthSpec is the struct that configures how the forwarders authenticate against Splunk
+type AuthSpec struct {
+	// Reference to a cert-manager Issuer or ClusterIssuer that issues the forwarder client
+	// certificate. When set, the operator requests a cert-manager Certificate and writes the
+	// issued key pair into the splunk-auth Secret as server.pem and cacert.pem. cacert.pem holds
+	// the trusted CA bundle when the issuer does not publish its CA. Removing the reference deletes
+	// the Certificate and its issued Secret.
+	// Optional: Defaults to mTLS material being placed into splunk-auth by hand.
+	CertificateIssuerRef *CertificateIssuerReference `json:"certificateIssuerRef,omitempty"`
+	// Whether the forwarders verify the receivers against the trusted CA bundle of the cluster, which
+	// includes the additional CAs of the cluster-wide Proxy, on top of the CA of the splunk-auth Secret.
+	// The operator creates a ConfigMap the bundle is injected into, and rolls the forwarders when the
+	// bundle changes.
+	// Optional: Defaults to false, verifying the receivers against the splunk-auth Secret only.
+	TrustedCABundle bool `json:"trustedCABundle,omitempty"`
+	// Whether the forwarders run in FIPS mode: FIPS-approved cipher suites and TLS versions only, with
+	// SPLUNK_FIPS set. Auto follows the fips setting of the cluster install-config.
+	// Configurations with non-FIPS ciphers are not rolled out while FIPS mode is on.
+	// Optional: Defaults to Auto.
+	// +kubebuilder:validation:Enum=Auto;Enabled;Disabled
+	// +kubebuilder:default=Auto
+	FIPS string `json:"fips,omitempty"`
+}
+
+// SplunkForwarderStatus defines the observed state of SplunkForwarder
+// +k8s:openapi-gen=true
+type SplunkForwarderStatus struct {
+	// Conditions describe the state of the forwarder configuration.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// The most recent generation of the CR that was reconciled.
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
+	// Number of nodes that should be running a forwarder pod, across all forwarder DaemonSets.
+	DesiredNumberScheduled int32 `json:"desiredNumberScheduled,omitempty"`
+	// Number of nodes running a ready forwarder pod, across all forwarder DaemonSets.
+	NumberReady int32 `json:"numberReady,omitempty"`
+	// Delivery guarantee of the forwarders: Reliable when reliableDelivery is enabled and the forwarders
+	// send over mTLS, BestEffort otherwise. With the HEC token, acknowledgement is configured in the
+	// splunk-hec-token Secret.
+	// +kubebuilder:validation:Enum=Reliable;BestEffort
+	DeliveryMode string `json:"deliveryMode,omitempty"`
+	// FIPS mode of the forwarders, as chosen by the fips field or detected from the cluster.
+	// +kubebuilder:validation:Enum=Enabled;Disabled
+	FIPSMode string `json:"fipsMode,omitempty"`
+	// The image:tag reference imageDigest was resolved from.
+	// +optional
+	ResolvedImage string `json:"resolvedImage,omitempty"`
+	// Digest the image tag was resolved to, which the forwarders run until the image or the tag changes.
+	// +optional
+	// +kubebuilder:validation:Pattern=`^sha256:[a-f0-9]{64}$`
+	ImageDigest string `json:"imageDigest,omitempty"`
+	// The rollout revision, a hash of the forwarder DaemonSets and ConfigMaps, the canary is testing.
+	// +optional
+	CanaryRevision string `json:"canaryRevision,omitempty"`
+	// Phase of the canary rollout of the canary revision.
+	// +optional
+	// +kubebuilder:validation:Enum=Progressing;Soaking;Promoted;Failed
+	CanaryPhase string `json:"canaryPhase,omitempty"`
+	// When the canary rollout entered its phase.
+	// +optional
+	CanaryPhaseTime *metav1.Time `json:"canaryPhaseTime,omitempty"`
+	// Details of the canary phase, such as the pods that failed the canary.
+	// +optional
+	CanaryMessage string `json:"canaryMessage,omitempty"`
+}
+
+// The version is not served by default, only where the conversion webhook is deployed: the package-operator
+// deployment serves it together with the webhook.
+
+// +kubebuilder:object:root=true
+
+// SplunkForwarder is the Schema for the splunkforwarders API
+// +k8s:openapi-gen=true
+// +kubebuilder:subresource:status
+// +kubebuilder:unservedversion
+type SplunkForwarder struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+
+	Spec   SplunkForwarderSpec   `json:"spec,omitempty"`
+	Status SplunkForwarderStatus `json:"status,omitempty"`
+}
+
+// +kubebuilder:object:root=true
+
+// SplunkForwarderList contains a list of SplunkForwarder
+type SplunkForwarderList struct {
+	metav1.TypeMeta `json:",inline"`
+	metav1.ListMeta `json:"metadata,omitempty"`
+	Items           []SplunkForwarder `json:"items"`
+}
+
+// SplunkFilter is the struct that configures Splunk Heavy Forwarder filters.
+// +kubebuilder:validation:XValidation:rule="!(has(self.action) && self.action in ['SetIndex', 'SetSourceType', 'Route']) || (has(self.value) && size(self.value) > 0)",message="value is required for the SetIndex, SetSourceType and Route actions"
+type SplunkFilter struct {
+	// Name of the filter, will be prepended with "filter_".
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9_-]+$`
+	Name string `json:"name"`
+	// Routing criteria regex for the filter to match on.
+	Filter string `json:"filter"`
+	// What to do with the events matching the filter:
+	// Drop sends them to the nullQueue, Keep drops the events of the filter's scope no Keep filter matches,
+	// SetIndex and SetSourceType rewrite the index or sourcetype to Value, Mask replaces the
+	// matched text with Value (SEDCMD), and Route sends them to the tcpout group named in Value.
+	// Optional: Defaults to "Drop"
+	// +kubebuilder:validation:Enum=Drop;Keep;SetIndex;SetSourceType;Mask;Route
+	Action string `json:"action,omitempty"`
+	// Argument of the action: the index, the sourcetype, the replacement text or the output group.
+	// Required for SetIndex, SetSourceType and Route.
+	Value string `json:"value,omitempty"`
+	// Sourcetype whose events the filter applies to.
+	// Optional: Defaults to "_json" when neither Source nor Host is set
+	SourceType string `json:"sourceType,omitempty"`
+	// Source whose events the filter applies to, as a props.conf "source::" pattern.
+	// Is not used if SourceType is supplied.
+	Source string `json:"source,omitempty"`
+	// Host whose events the filter applies to, as a props.conf "host::" pattern.
+	// Is not used if SourceType or Source is supplied.
+	Host string `json:"host,omitempty"`
+}
+
+// CertificateIssuerReference is the struct that references the cert-manager issuer for
+// the forwarder client certificate.
+type CertificateIssuerReference struct {
+	// Name of the Issuer or ClusterIssuer.
+	Name string `json:"name"`
+	// Kind of the issuer, either Issuer or ClusterIssuer.
+	// Optional: Defaults to "Issuer"
+	// +kubebuilder:validation:Enum=Issuer;ClusterIssuer
+	Kind string `json:"kind,omitempty"`
+	// API group of the issuer.
+	// Optional: Defaults to "cert-manager.io"
+	Group string `json:"group,omitempty"`
+}
+
+// SplunkMetadataFields is the struct that selects the cluster metadata added to every event
+// through _meta. The node name is always available as the host field.
+type SplunkMetadataFields struct {
+	// Adds the OpenShift version from the ClusterVersion resource as "clusterversion".
+	ClusterVersion bool `json:"clusterVersion,omitempty"`
+	// Adds the external cluster UUID from the ClusterVersion resource as "clusteruuid".
+	ClusterUUID bool `json:"clusterUUID,omitempty"`
+	// Adds the infrastructure platform (AWS, GCP, Azure, ...) from the Infrastructure resource as "platform".
+	Platform bool `json:"platform,omitempty"`
+	// Adds the cloud region from the Infrastructure resource as "region", when the platform reports one.
+	Region bool `json:"region,omitempty"`
+}
+
+// SplunkMasking is the struct that configures the redaction of sensitive data from the events.
+type SplunkMasking struct {
+	// Disables the built-in rules, which mask bearer tokens, OpenShift OAuth tokens, JWTs, private keys,
+	// password and token fields, and AWS access key IDs.
+	// Optional: Defaults to false
+	DisableBuiltinRules bool `json:"disableBuiltinRules,omitempty"`
+	// Additional masking rules, applied after the built-in rules.
+	// +listType=map
+	// +listMapKey=name
+	Rules []SplunkMaskingRule `json:"rules,omitempty"`
+}
+
+// SplunkMaskingRule is the struct that defines a regex-and-replacement masking rule, rendered as a SEDCMD.
+type SplunkMaskingRule struct {
+	// Name of the rule, will be prepended with "mask_".
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9_-]+$`
+	Name string `json:"name"`
+	// Regex matching the sensitive data.
+	Regex string `json:"regex"`
+	// Replacement of the matched text. Groups of the regex can be referenced as \1 to \9.
+	// Optional: Defaults to removing the matched text
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// SplunkPodLogsInput is the struct that defines an input collecting the container logs of the pods
+// selected by a namespace and a label selector
+type SplunkPodLogsInput struct {
+	// Name of the input.
+	Name string `json:"name"`
+	// Namespace of the pods.
+	Namespace string `json:"namespace"`
+	// Label selector of the pods.
+	// Optional: Defaults to all pods of the namespace
+	Selector *metav1.LabelSelector `json:"selector,omitempty"`
+	// Names of the containers whose logs are collected.
+	// Optional: Defaults to all containers of the pods
+	// +listType=set
+	Containers []string `json:"containers,omitempty"`
+	// Repository for data. More info: https://docs.splunk.com/Splexicon:Index
+	// Optional: Defaults to "main"
+	Index string `json:"index,omitempty"`
+	// Data structure of the event. More info: https://docs.splunk.com/Splexicon:Sourcetype
+	// Optional: Defaults to "kube:container"
+	SourceType string `json:"sourceType,omitempty"`
+}
+
+// SplunkScriptedInput is the struct that defines an input indexing the output of a script run on a schedule.
+// The script is taken from a ConfigMap and mounted into the bin directory of a dedicated app, so that only
+// scripts shipped this way can be run, never arbitrary paths of the host.
+type SplunkScriptedInput struct {
+	// Name of the input, also the file name of the script in the app.
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9_-]+$`
+	// +kubebuilder:validation:MaxLength=63
+	Name string `json:"name"`
+	// Name of the ConfigMap, in the namespace of the CR, holding the script.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`
+	ConfigMap string `json:"configMap"`
+	// Key of the script in the ConfigMap.
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9_-][A-Za-z0-9_.-]*$`
+	Key string `json:"key"`
+	// How often the script is run: a number of seconds, or a cron schedule.
+	// Optional: Defaults to "60"
+	// +kubebuilder:validation:Pattern=`^([0-9]+|[-0-9*/,]+( [-0-9*/,]+){4})$`
+	Interval string `json:"interval,omitempty"`
+	// Repository for data. More info: https://docs.splunk.com/Splexicon:Index
+	// Optional: Defaults to "main"
+	Index string `json:"index,omitempty"`
+	// Data structure of the event. More info: https://docs.splunk.com/Splexicon:Sourcetype
+	// Optional: Defaults to the name of the script
+	SourceType string `json:"sourceType,omitempty"`
+}
+
+// SplunkApp is the struct that references an extra Splunk app, such as a technology add-on, mounted into
+// the forwarders. Exactly one of ConfigMap, Secret or Image is set.
+// +kubebuilder:validation:XValidation:rule="[has(self.configMap), has(self.secret), has(self.image)].filter(x, x).size() == 1",message="exactly one of configMap, secret or image must be set"
+type SplunkApp struct {
+	// Name of the app, the directory it is mounted at under etc/apps.
+	// +kubebuilder:validation:Pattern=`^[A-Za-z0-9_-]+$`
+	// +kubebuilder:validation:MaxLength=63
+	// +kubebuilder:validation:XValidation:rule="!(self in ['osd_monitored_logs', 'osd_scripted_inputs', 'osd_kube_events', 'splunkauth'])",message="the name is reserved for an app of the operator"
+	Name string `json:"name"`
+	// Name of the ConfigMap, in the namespace of the CR, whose keys are the files of the default directory
+	// of the app, e.g. inputs.conf and props.conf.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`
+	ConfigMap string `json:"configMap,omitempty"`
+	// Name of the Secret, in the namespace of the CR, whose keys are the files of the default directory
+	// of the app.
+	// +kubebuilder:validation:Pattern=`^[a-z0-9]([-a-z0-9.]*[a-z0-9])?$`
+	Secret string `json:"secret,omitempty"`
+	// OCI image or artifact holding the whole app directory, mounted as an image volume.
+	Image string `json:"image,omitempty"`
+	// Pull policy of the image.
+	// Optional: Defaults to Always for the latest tag, and IfNotPresent otherwise
+	// +kubebuilder:validation:Enum=Always;IfNotPresent;Never
+	ImagePullPolicy string `json:"imagePullPolicy,omitempty"`
+}
+
+// SplunkThroughput is the struct that limits how fast the forwarders process events
+type SplunkThroughput struct {
+	// Maximum rate, in kilobytes per second, at which each forwarder processes events. 0 is unlimited.
+	// Optional: Defaults to 0
+	// +kubebuilder:validation:Minimum=0
+	MaxKBps int32 `json:"maxKBps,omitempty"`
+	// Number of ingestion pipelines of each forwarder, each with its own queues and receiver connections.
+	// Optional: Defaults to 1
+	// +kubebuilder:validation:Minimum=1
+	// +kubebuilder:validation:Maximum=8
+	ParallelIngestionPipelines int32 `json:"parallelIngestionPipelines,omitempty"`
+}
+
+// SplunkQueues is the struct that sizes the queues of the forwarders and configures how they send the
+// queued events to the receivers
+type SplunkQueues struct {
+	// Maximum size of the output queue of each pipeline, in bytes with a KB, MB or GB suffix.
+	// Optional: Defaults to the Splunk default, "auto"
+	// +kubebuilder:validation:Pattern=`^[0-9]+(KB|MB|GB)$`
+	MaxQueueSize string `json:"maxQueueSize,omitempty"`
+	// Maximum size of the parsing queue of each pipeline, in bytes with a KB, MB or GB suffix.
+	// Optional: Defaults to the Splunk default
+	// +kubebuilder:validation:Pattern=`^[0-9]+(KB|MB|GB)$`
+	ParsingQueueSize string `json:"parsingQueueSize,omitempty"`
+	// Whether the events are kept in the output queue until the receiver acknowledges them.
+	// Optional: Defaults to the setting of the receiver group
+	UseACK *bool `json:"useACK,omitempty"`
+	// Seconds after which the forwarders switch to another receiver of the group.
+	// Optional: Defaults to the Splunk default
+	// +kubebuilder:validation:Minimum=1
+	AutoLBFrequency int32 `json:"autoLBFrequency,omitempty"`
+	// Whether the events are compressed on the wire. The receivers must be configured alike.
+	// Optional: Defaults to the setting of the receiver group
+	Compressed *bool `json:"compressed,omitempty"`
+}
+
+// SplunkReliableDelivery is the struct that configures the forwarders so that events are not lost when the
+// receivers are unreachable or the forwarder pods restart
+type SplunkReliableDelivery struct {
+	// Whether the forwarders wait for the receivers to acknowledge the events, and queue them on the node.
+	// Optional: Defaults to false.
+	Enabled bool `json:"enabled,omitempty"`
+	// Size of the output queue holding the events until they are acknowledged, in bytes with a KB, MB or
+	// GB suffix.
+	// Optional: Defaults to "64MB"
+	// +kubebuilder:validation:Pattern=`^[0-9]+(KB|MB|GB)$`
+	MaxQueueSize string `json:"maxQueueSize,omitempty"`
+	// Size of the persistent queue of the scripted inputs, and of the receiving port of the Heavy
+	// Forwarder. The queue of the scripted inputs is kept on the node, in the forwarder state hostPath
+	// where the monitored files keep their checkpoints.
+	// Optional: Defaults to "500MB"
+	// +kubebuilder:validation:Pattern=`^[0-9]+(KB|MB|GB)$`
+	PersistentQueueSize string `json:"persistentQueueSize,omitempty"`
+}
+
+// SplunkEventsCollector is the struct that configures the Deployment collecting the cluster Events, and
+// optionally the objects of selected resources, which never reach the node log files.
+type SplunkEventsCollector struct {
+	// Whether the events collector is deployed.
+	// Optional: Defaults to false.
+	Enabled bool `json:"enabled,omitempty"`
+	// Namespaces whose Events and objects are collected.
+	// Optional: Defaults to all namespaces
+	// +listType=set
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Resources whose objects are also collected when they are added, changed or deleted.
+	// Optional: Defaults to only collecting Events
+	// +kubebuilder:validation:items:Enum=Nodes;Pods;Deployments;DaemonSets;StatefulSets;Jobs;PersistentVolumeClaims
+	// +listType=set
+	Resources []string `json:"resources,omitempty"`
+	// Repository for data. More info: https://docs.splunk.com/Splexicon:Index
+	// Optional: Defaults to the index of the first input that sets one, or "main"
+	Index string `json:"index,omitempty"`
+	// Container image of the collector, which runs the "events" subcommand of the operator.
+	// Optional: Defaults to the operator image
+	Image string `json:"image,omitempty"`
+}
+
+// SplunkRolloutStrategy is the struct that configures how changes of the forwarders are rolled out
+type SplunkRolloutStrategy struct {
+	// Rolls changes out to the canary nodes first, and to every node once the canary forwarders stayed
+	// ready for the soak duration. A failed canary is rolled back and the change is not promoted.
+	// Optional: Defaults to rolling changes out to every node.
+	Canary *SplunkCanaryRollout `json:"canary,omitempty"`
+	// Rolls a forwarder DaemonSet back to its last ready pod template when a new pod template fails.
+	// Optional: Defaults to rolling back after 10m without progress.
+	AutoRollback *SplunkAutoRollback `json:"autoRollback,omitempty"`
+}
+
+// SplunkAutoRollback is the struct that configures the automatic rollback of the forwarder DaemonSets. A
+// rollout fails when a forwarder container crash-loops or cannot be started, or when no forwarder pod is
+// updated or becomes ready for the progress deadline. The DaemonSets and their ConfigMaps are then kept on
+// their last good state, but for credential rotations, until the generation of the CR changes.
+type SplunkAutoRollback struct {
+	// Whether failed rollouts are rolled back.
+	// Optional: Defaults to true.
+	Enabled *bool `json:"enabled,omitempty"`
+	// How long a rollout may make no progress before it fails.
+	// Optional: Defaults to 10m.
+	ProgressDeadline *metav1.Duration `json:"progressDeadline,omitempty"`
+}
+
+// SplunkCanaryRollout is the struct that configures the canary rollout of the forwarders. The forwarders
+// of the canary nodes run in separate DaemonSets, named after the forwarder DaemonSets with a -canary
+// suffix, and read copies of the forwarder ConfigMaps.
+type SplunkCanaryRollout struct {
+	// Labels of the canary nodes, such as a label set on a few worker nodes.
+	// +kubebuilder:validation:MinProperties=1
+	NodeSelector map[string]string `json:"nodeSelector"`
+	// How long the canary forwarders have to stay ready, without crash-looping, before the change is
+	// promoted to every node.
+	// Optional: Defaults to 10m.
+	SoakDuration *metav1.Duration `json:"soakDuration,omitempty"`
+	// How long the canary forwarders have to become ready before the canary fails.
+	// Optional: Defaults to 10m.
+	ProgressDeadline *metav1.Duration `json:"progressDeadline,omitempty"`
+}
+
+// SplunkForwarderInputs is the struct that defines all the splunk inputs
+type SplunkForwarderInputs struct {
+	// Type of the input: "Monitor" monitors the files under Path, "Journald" reads the systemd journal
+	// of the node, for the logs of services such as kubelet, crio or sshd.
+	// Optional: Defaults to "Monitor"
+	// +kubebuilder:validation:Enum=Monitor;Journald
+	Type string `json:"type,omitempty"`
+	// Filepath for Splunk to monitor.
+	// Required for Monitor inputs
+	Path string `json:"path,omitempty"`
+	// Repository for data. More info: https://docs.splunk.com/Splexicon:Index
+	// Optional: Defaults to "main"
+	Index string `json:"index,omitempty"`
+	// Data structure of the event. More info: https://docs.splunk.com/Splexicon:Sourcetype
+	// Optional: Defaults to "_json"
+	SourceType string `json:"sourceType,omitempty"`
+	// Regex to monitor certain files. Multiple regex rules may be specified separated by "|" (OR)
+	// Optional: Defaults to monitoring all files in the specified Path
+	WhiteList string `json:"whiteList,omitempty"`
+	// Regex to exclude certain files from monitoring. Multiple regex rules may be specified separated by "|" (OR)
+	// Optional: Defaults to monitoring all files in the specified Path
+	BlackList string `json:"blackList,omitempty"`
+	// Node roles, such as "master" or "worker", whose nodes monitor this input. The role of a node is taken
+	// from its node-role.kubernetes.io/<role> label, and is added to the events as "noderole".
+	// A node with several roles monitors the inputs of each of them.
+	// Optional: Defaults to monitoring the input on every node
+	// +kubebuilder:validation:items:Pattern=`^[a-z0-9]([-a-z0-9]*[a-z0-9])?$`
+	// +kubebuilder:validation:items:MaxLength=40
+	// +listType=set
+	NodeRoles []string `json:"nodeRoles,omitempty"`
+	// Systemd units, such as "kubelet.service", whose journal entries a Journald input reads.
+	// The names are made of the characters systemd allows in unit names.
+	// Optional: Defaults to reading the entries of every unit
+	// +kubebuilder:validation:items:Pattern=`^[-A-Za-z0-9:_.@\\]+$`
+	// +kubebuilder:validation:items:MaxLength=255
+	// +listType=set
+	Units []string `json:"units,omitempty"`
+	// Lowest priority of the journal entries a Journald input reads, from "emerg" to "debug".
+	// Optional: Defaults to reading the entries of every priority
+	// +kubebuilder:validation:Enum=emerg;alert;crit;err;warning;notice;info;debug
+	Priority string `json:"priority,omitempty"`
+}
+
+func init() {
+	SchemeBuilder.Register(&SplunkForwarder{}, &SplunkForwarderList{})
+}
@@ -0,0 +1,587 @@
+//go:build !ignore_autogenerated
+
+// Code generated by controller-gen. DO NOT EDIT.
+
+package v1beta1
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1"
+	runtime "k8s.io/apimachinery/pkg/runtime"
+)
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *AuthSpec) DeepCopyInto(out *AuthSpec) {
+	*out = *in
+	if in.CertificateIssuerRef != nil {
+		in, out := &in.CertificateIssuerRef, &out.CertificateIssuerRef
+		*out = new(CertificateIssuerReference)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new AuthSpec.
+func (in *AuthSpec) DeepCopy() *AuthSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(AuthSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *CertificateIssuerReference) DeepCopyInto(out *CertificateIssuerReference) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new CertificateIssuerReference.
+func (in *CertificateIssuerReference) DeepCopy() *CertificateIssuerReference {
+	if in == nil {
+		return nil
+	}
+	out := new(CertificateIssuerReference)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ForwarderSpec) DeepCopyInto(out *ForwarderSpec) {
+	*out = *in
+	out.Image = in.Image
+	if in.ImagePullSecrets != nil {
+		in, out := &in.ImagePullSecrets, &out.ImagePullSecrets
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Inputs != nil {
+		in, out := &in.Inputs, &out.Inputs
+		*out = make([]SplunkForwarderInputs, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.PodLogs != nil {
+		in, out := &in.PodLogs, &out.PodLogs
+		*out = make([]SplunkPodLogsInput, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.ScriptedInputs != nil {
+		in, out := &in.ScriptedInputs, &out.ScriptedInputs
+		*out = make([]SplunkScriptedInput, len(*in))
+		copy(*out, *in)
+	}
+	if in.Apps != nil {
+		in, out := &in.Apps, &out.Apps
+		*out = make([]SplunkApp, len(*in))
+		copy(*out, *in)
+	}
+	if in.RolloutStrategy != nil {
+		in, out := &in.RolloutStrategy, &out.RolloutStrategy
+		*out = new(SplunkRolloutStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ForwarderSpec.
+func (in *ForwarderSpec) DeepCopy() *ForwarderSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ForwarderSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *HeavyForwarderSpec) DeepCopyInto(out *HeavyForwarderSpec) {
+	*out = *in
+	out.Image = in.Image
+	if in.Filters != nil {
+		in, out := &in.Filters, &out.Filters
+		*out = make([]SplunkFilter, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new HeavyForwarderSpec.
+func (in *HeavyForwarderSpec) DeepCopy() *HeavyForwarderSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(HeavyForwarderSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *ImageSpec) DeepCopyInto(out *ImageSpec) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new ImageSpec.
+func (in *ImageSpec) DeepCopy() *ImageSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(ImageSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *OutputsSpec) DeepCopyInto(out *OutputsSpec) {
+	*out = *in
+	if in.MetadataFields != nil {
+		in, out := &in.MetadataFields, &out.MetadataFields
+		*out = new(SplunkMetadataFields)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new OutputsSpec.
+func (in *OutputsSpec) DeepCopy() *OutputsSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(OutputsSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SplunkApp) DeepCopyInto(out *SplunkApp) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SplunkApp.
+func (in *SplunkApp) DeepCopy() *SplunkApp {
+	if in == nil {
+		return nil
+	}
+	out := new(SplunkApp)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SplunkAutoRollback) DeepCopyInto(out *SplunkAutoRollback) {
+	*out = *in
+	if in.Enabled != nil {
+		in, out := &in.Enabled, &out.Enabled
+		*out = new(bool)
+		**out = **in
+	}
+	if in.ProgressDeadline != nil {
+		in, out := &in.ProgressDeadline, &out.ProgressDeadline
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SplunkAutoRollback.
+func (in *SplunkAutoRollback) DeepCopy() *SplunkAutoRollback {
+	if in == nil {
+		return nil
+	}
+	out := new(SplunkAutoRollback)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SplunkCanaryRollout) DeepCopyInto(out *SplunkCanaryRollout) {
+	*out = *in
+	if in.NodeSelector != nil {
+		in, out := &in.NodeSelector, &out.NodeSelector
+		*out = make(map[string]string, len(*in))
+		for key, val := range *in {
+			(*out)[key] = val
+		}
+	}
+	if in.SoakDuration != nil {
+		in, out := &in.SoakDuration, &out.SoakDuration
+		*out = new(v1.Duration)
+		**out = **in
+	}
+	if in.ProgressDeadline != nil {
+		in, out := &in.ProgressDeadline, &out.ProgressDeadline
+		*out = new(v1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SplunkCanaryRollout.
+func (in *SplunkCanaryRollout) DeepCopy() *SplunkCanaryRollout {
+	if in == nil {
+		return nil
+	}
+	out := new(SplunkCanaryRollout)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SplunkEventsCollector) DeepCopyInto(out *SplunkEventsCollector) {
+	*out = *in
+	if in.Namespaces != nil {
+		in, out := &in.Namespaces, &out.Namespaces
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Resources != nil {
+		in, out := &in.Resources, &out.Resources
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SplunkEventsCollector.
+func (in *SplunkEventsCollector) DeepCopy() *SplunkEventsCollector {
+	if in == nil {
+		return nil
+	}
+	out := new(SplunkEventsCollector)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SplunkFilter) DeepCopyInto(out *SplunkFilter) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SplunkFilter.
+func (in *SplunkFilter) DeepCopy() *SplunkFilter {
+	if in == nil {
+		return nil
+	}
+	out := new(SplunkFilter)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SplunkForwarder) DeepCopyInto(out *SplunkForwarder) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ObjectMeta.DeepCopyInto(&out.ObjectMeta)
+	in.Spec.DeepCopyInto(&out.Spec)
+	in.Status.DeepCopyInto(&out.Status)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SplunkForwarder.
+func (in *SplunkForwarder) DeepCopy() *SplunkForwarder {
+	if in == nil {
+		return nil
+	}
+	out := new(SplunkForwarder)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SplunkForwarder) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SplunkForwarderInputs) DeepCopyInto(out *SplunkForwarderInputs) {
+	*out = *in
+	if in.NodeRoles != nil {
+		in, out := &in.NodeRoles, &out.NodeRoles
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+	if in.Units != nil {
+		in, out := &in.Units, &out.Units
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SplunkForwarderInputs.
+func (in *SplunkForwarderInputs) DeepCopy() *SplunkForwarderInputs {
+	if in == nil {
+		return nil
+	}
+	out := new(SplunkForwarderInputs)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SplunkForwarderList) DeepCopyInto(out *SplunkForwarderList) {
+	*out = *in
+	out.TypeMeta = in.TypeMeta
+	in.ListMeta.DeepCopyInto(&out.ListMeta)
+	if in.Items != nil {
+		in, out := &in.Items, &out.Items
+		*out = make([]SplunkForwarder, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SplunkForwarderList.
+func (in *SplunkForwarderList) DeepCopy() *SplunkForwarderList {
+	if in == nil {
+		return nil
+	}
+	out := new(SplunkForwarderList)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyObject is an autogenerated deepcopy function, copying the receiver, creating a new runtime.Object.
+func (in *SplunkForwarderList) DeepCopyObject() runtime.Object {
+	if c := in.DeepCopy(); c != nil {
+		return c
+	}
+	return nil
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SplunkForwarderSpec) DeepCopyInto(out *SplunkForwarderSpec) {
+	*out = *in
+	in.Forwarder.DeepCopyInto(&out.Forwarder)
+	in.HeavyForwarder.DeepCopyInto(&out.HeavyForwarder)
+	in.Outputs.DeepCopyInto(&out.Outputs)
+	if in.Masking != nil {
+		in, out := &in.Masking, &out.Masking
+		*out = new(SplunkMasking)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.EventsCollector != nil {
+		in, out := &in.EventsCollector, &out.EventsCollector
+		*out = new(SplunkEventsCollector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Throughput != nil {
+		in, out := &in.Throughput, &out.Throughput
+		*out = new(SplunkThroughput)
+		**out = **in
+	}
+	if in.Queues != nil {
+		in, out := &in.Queues, &out.Queues
+		*out = new(SplunkQueues)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.ReliableDelivery != nil {
+		in, out := &in.ReliableDelivery, &out.ReliableDelivery
+		*out = new(SplunkReliableDelivery)
+		**out = **in
+	}
+	in.Auth.DeepCopyInto(&out.Auth)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SplunkForwarderSpec.
+func (in *SplunkForwarderSpec) DeepCopy() *SplunkForwarderSpec {
+	if in == nil {
+		return nil
+	}
+	out := new(SplunkForwarderSpec)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SplunkForwarderStatus) DeepCopyInto(out *SplunkForwarderStatus) {
+	*out = *in
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]v1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
+	if in.CanaryPhaseTime != nil {
+		in, out := &in.CanaryPhaseTime, &out.CanaryPhaseTime
+		*out = (*in).DeepCopy()
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SplunkForwarderStatus.
+func (in *SplunkForwarderStatus) DeepCopy() *SplunkForwarderStatus {
+	if in == nil {
+		return nil
+	}
+	out := new(SplunkForwarderStatus)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SplunkMasking) DeepCopyInto(out *SplunkMasking) {
+	*out = *in
+	if in.Rules != nil {
+		in, out := &in.Rules, &out.Rules
+		*out = make([]SplunkMaskingRule, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SplunkMasking.
+func (in *SplunkMasking) DeepCopy() *SplunkMasking {
+	if in == nil {
+		return nil
+	}
+	out := new(SplunkMasking)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SplunkMaskingRule) DeepCopyInto(out *SplunkMaskingRule) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SplunkMaskingRule.
+func (in *SplunkMaskingRule) DeepCopy() *SplunkMaskingRule {
+	if in == nil {
+		return nil
+	}
+	out := new(SplunkMaskingRule)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SplunkMetadataFields) DeepCopyInto(out *SplunkMetadataFields) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SplunkMetadataFields.
+func (in *SplunkMetadataFields) DeepCopy() *SplunkMetadataFields {
+	if in == nil {
+		return nil
+	}
+	out := new(SplunkMetadataFields)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SplunkPodLogsInput) DeepCopyInto(out *SplunkPodLogsInput) {
+	*out = *in
+	if in.Selector != nil {
+		in, out := &in.Selector, &out.Selector
+		*out = new(v1.LabelSelector)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Containers != nil {
+		in, out := &in.Containers, &out.Containers
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SplunkPodLogsInput.
+func (in *SplunkPodLogsInput) DeepCopy() *SplunkPodLogsInput {
+	if in == nil {
+		return nil
+	}
+	out := new(SplunkPodLogsInput)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SplunkQueues) DeepCopyInto(out *SplunkQueues) {
+	*out = *in
+	if in.UseACK != nil {
+		in, out := &in.UseACK, &out.UseACK
+		*out = new(bool)
+		**out = **in
+	}
+	if in.Compressed != nil {
+		in, out := &in.Compressed, &out.Compressed
+		*out = new(bool)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SplunkQueues.
+func (in *SplunkQueues) DeepCopy() *SplunkQueues {
+	if in == nil {
+		return nil
+	}
+	out := new(SplunkQueues)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SplunkReliableDelivery) DeepCopyInto(out *SplunkReliableDelivery) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SplunkReliableDelivery.
+func (in *SplunkReliableDelivery) DeepCopy() *SplunkReliableDelivery {
+	if in == nil {
+		return nil
+	}
+	out := new(SplunkReliableDelivery)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SplunkRolloutStrategy) DeepCopyInto(out *SplunkRolloutStrategy) {
+	*out = *in
+	if in.Canary != nil {
+		in, out := &in.Canary, &out.Canary
+		*out = new(SplunkCanaryRollout)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.AutoRollback != nil {
+		in, out := &in.AutoRollback, &out.AutoRollback
+		*out = new(SplunkAutoRollback)
+		(*in).DeepCopyInto(*out)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SplunkRolloutStrategy.
+func (in *SplunkRolloutStrategy) DeepCopy() *SplunkRolloutStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(SplunkRolloutStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SplunkScriptedInput) DeepCopyInto(out *SplunkScriptedInput) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SplunkScriptedInput.
+func (in *SplunkScriptedInput) DeepCopy() *SplunkScriptedInput {
+	if in == nil {
+		return nil
+	}
+	out := new(SplunkScriptedInput)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *SplunkThroughput) DeepCopyInto(out *SplunkThroughput) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new SplunkThroughput.
+func (in *SplunkThroughput) DeepCopy() *SplunkThroughput {
+	if in == nil {
+		return nil
+	}
+	out := new(SplunkThroughput)
+	in.DeepCopyInto(out)
+	return out
+}
@@ -0,0 +1,262 @@
+//go:build !ignore_autogenerated
+// +build !ignore_autogenerated
+
+// Code generated by openapi-gen. DO NOT EDIT.
+
+// This file was autogenerated by openapi-gen. Do not edit it manually!
+
+package v1beta1
+
+import (
+	common "k8s.io/kube-openapi/pkg/common"
+	spec "k8s.io/kube-openapi/pkg/validation/spec"
+)
+
+func GetOpenAPIDefinitions(ref common.ReferenceCallback) map[string]common.OpenAPIDefinition {
+	return map[string]common.OpenAPIDefinition{
+		"github.com/openshift/splunk-forwarder-operator/api/v1beta1.SplunkForwarder":       schema_openshift_splunk_forwarder_operator_api_v1beta1_SplunkForwarder(ref),
+		"github.com/openshift/splunk-forwarder-operator/api/v1beta1.SplunkForwarderSpec":   schema_openshift_splunk_forwarder_operator_api_v1beta1_SplunkForwarderSpec(ref),
+		"github.com/openshift/splunk-forwarder-operator/api/v1beta1.SplunkForwarderStatus": schema_openshift_splunk_forwarder_operator_api_v1beta1_SplunkForwarderStatus(ref),
+	}
+}
+
+func schema_openshift_splunk_forwarder_operator_api_v1beta1_SplunkForwarder(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "SplunkForwarder is the Schema for the splunkforwarders API",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"kind": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Kind is a string value representing the REST resource this object represents. Servers may infer this from the endpoint the client submits requests to. Cannot be updated. In CamelCase. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#types-kinds",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"apiVersion": {
+						SchemaProps: spec.SchemaProps{
+							Description: "APIVersion defines the versioned schema of this representation of an object. Servers should convert recognized schemas to the latest internal value, and may reject unrecognized values. More info: https://git.k8s.io/community/contributors/devel/sig-architecture/api-conventions.md#resources",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"metadata": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref("k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"),
+						},
+					},
+					"spec": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref("github.com/openshift/splunk-forwarder-operator/api/v1beta1.SplunkForwarderSpec"),
+						},
+					},
+					"status": {
+						SchemaProps: spec.SchemaProps{
+							Default: map[string]interface{}{},
+							Ref:     ref("github.com/openshift/splunk-forwarder-operator/api/v1beta1.SplunkForwarderStatus"),
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"github.com/openshift/splunk-forwarder-operator/api/v1beta1.SplunkForwarderSpec", "github.com/openshift/splunk-forwarder-operator/api/v1beta1.SplunkForwarderStatus", "k8s.io/apimachinery/pkg/apis/meta/v1.ObjectMeta"},
+	}
+}
+
+func schema_openshift_splunk_forwarder_operator_api_v1beta1_SplunkForwarderSpec(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "SplunkForwarderSpec defines the desired state of SplunkForwarder",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"splunkLicenseAccepted": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Adds an --accept-license flag to automatically accept the Splunk License Agreement. Must be true for the Red Hat provided Splunk Forwarder image. Optional: Defaults to false.",
+							Type:        []string{"boolean"},
+							Format:      "",
+						},
+					},
+					"forwarder": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The Splunk Universal Forwarders running on every node.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("github.com/openshift/splunk-forwarder-operator/api/v1beta1.ForwarderSpec"),
+						},
+					},
+					"heavyForwarder": {
+						SchemaProps: spec.SchemaProps{
+							Description: "An optional Splunk Heavy Forwarder the Universal Forwarders send their events through. Optional: Defaults to sending the events directly.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("github.com/openshift/splunk-forwarder-operator/api/v1beta1.HeavyForwarderSpec"),
+						},
+					},
+					"outputs": {
+						SchemaProps: spec.SchemaProps{
+							Description: "How the events are tagged before they are sent.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("github.com/openshift/splunk-forwarder-operator/api/v1beta1.OutputsSpec"),
+						},
+					},
+					"masking": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Masking rules that redact sensitive data, such as tokens and passwords, from the events before they leave the cluster. The rules are applied on the Heavy Forwarder when it is used, and on the Universal Forwarders otherwise. Optional: Defaults to no masking.",
+							Ref:         ref("github.com/openshift/splunk-forwarder-operator/api/v1beta1.SplunkMasking"),
+						},
+					},
+					"eventsCollector": {
+						SchemaProps: spec.SchemaProps{
+							Description: "A Deployment collecting the cluster Events, and optionally the objects of selected resources, as JSON and forwarding them with the authentication of the Universal Forwarders. Optional: Defaults to no events collector.",
+							Ref:         ref("github.com/openshift/splunk-forwarder-operator/api/v1beta1.SplunkEventsCollector"),
+						},
+					},
+					"throughput": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Throughput limits of the forwarders, applied to the Universal and Heavy Forwarders. Optional: Defaults to an unlimited rate and one pipeline.",
+							Ref:         ref("github.com/openshift/splunk-forwarder-operator/api/v1beta1.SplunkThroughput"),
+						},
+					},
+					"queues": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Queue sizes and receiver settings of the forwarders, applied to the Universal and Heavy Forwarders. Optional: Defaults to the Splunk defaults.",
+							Ref:         ref("github.com/openshift/splunk-forwarder-operator/api/v1beta1.SplunkQueues"),
+						},
+					},
+					"reliableDelivery": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Guaranteed delivery of the events, through indexer acknowledgement and persistent queues. Optional: Defaults to best effort delivery.",
+							Ref:         ref("github.com/openshift/splunk-forwarder-operator/api/v1beta1.SplunkReliableDelivery"),
+						},
+					},
+					"auth": {
+						SchemaProps: spec.SchemaProps{
+							Description: "How the forwarders authenticate against Splunk. The HEC token in the splunk-hec-token Secret is used when present, otherwise the mTLS material in the splunk-auth Secret.",
+							Default:     map[string]interface{}{},
+							Ref:         ref("github.com/openshift/splunk-forwarder-operator/api/v1beta1.AuthSpec"),
+						},
+					},
+				},
+				Required: []string{"forwarder"},
+			},
+		},
+		Dependencies: []string{
+			"github.com/openshift/splunk-forwarder-operator/api/v1beta1.AuthSpec", "github.com/openshift/splunk-forwarder-operator/api/v1beta1.ForwarderSpec", "github.com/openshift/splunk-forwarder-operator/api/v1beta1.HeavyForwarderSpec", "github.com/openshift/splunk-forwarder-operator/api/v1beta1.OutputsSpec", "github.com/openshift/splunk-forwarder-operator/api/v1beta1.SplunkEventsCollector", "github.com/openshift/splunk-forwarder-operator/api/v1beta1.SplunkMasking", "github.com/openshift/splunk-forwarder-operator/api/v1beta1.SplunkQueues", "github.com/openshift/splunk-forwarder-operator/api/v1beta1.SplunkReliableDelivery", "github.com/openshift/splunk-forwarder-operator/api/v1beta1.SplunkThroughput"},
+	}
+}
+
+func schema_openshift_splunk_forwarder_operator_api_v1beta1_SplunkForwarderStatus(ref common.ReferenceCallback) common.OpenAPIDefinition {
+	return common.OpenAPIDefinition{
+		Schema: spec.Schema{
+			SchemaProps: spec.SchemaProps{
+				Description: "SplunkForwarderStatus defines the observed state of SplunkForwarder",
+				Type:        []string{"object"},
+				Properties: map[string]spec.Schema{
+					"conditions": {
+						VendorExtensible: spec.VendorExtensible{
+							Extensions: spec.Extensions{
+								"x-kubernetes-list-map-keys": []interface{}{
+									"type",
+								},
+								"x-kubernetes-list-type": "map",
+							},
+						},
+						SchemaProps: spec.SchemaProps{
+							Description: "Conditions describe the state of the forwarder configuration.",
+							Type:        []string{"array"},
+							Items: &spec.SchemaOrArray{
+								Schema: &spec.Schema{
+									SchemaProps: spec.SchemaProps{
+										Default: map[string]interface{}{},
+										Ref:     ref("k8s.io/apimachinery/pkg/apis/meta/v1.Condition"),
+									},
+								},
+							},
+						},
+					},
+					"observedGeneration": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The most recent generation of the CR that was reconciled.",
+							Type:        []string{"integer"},
+							Format:      "int64",
+						},
+					},
+					"desiredNumberScheduled": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Number of nodes that should be running a forwarder pod, across all forwarder DaemonSets.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"numberReady": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Number of nodes running a ready forwarder pod, across all forwarder DaemonSets.",
+							Type:        []string{"integer"},
+							Format:      "int32",
+						},
+					},
+					"deliveryMode": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Delivery guarantee of the forwarders: Reliable when reliableDelivery is enabled and the forwarders send over mTLS, BestEffort otherwise. With the HEC token, acknowledgement is configured in the splunk-hec-token Secret.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"fipsMode": {
+						SchemaProps: spec.SchemaProps{
+							Description: "FIPS mode of the forwarders, as chosen by the fips field or detected from the cluster.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"resolvedImage": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The image:tag reference imageDigest was resolved from.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"imageDigest": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Digest the image tag was resolved to, which the forwarders run until the image or the tag changes.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"canaryRevision": {
+						SchemaProps: spec.SchemaProps{
+							Description: "The rollout revision, a hash of the forwarder DaemonSets and ConfigMaps, the canary is testing.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"canaryPhase": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Phase of the canary rollout of the canary revision.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+					"canaryPhaseTime": {
+						SchemaProps: spec.SchemaProps{
+							Description: "When the canary rollout entered its phase.",
+							Ref:         ref("k8s.io/apimachinery/pkg/apis/meta/v1.Time"),
+						},
+					},
+					"canaryMessage": {
+						SchemaProps: spec.SchemaProps{
+							Description: "Details of the canary phase, such as the pods that failed the canary.",
+							Type:        []string{"string"},
+							Format:      "",
+						},
+					},
+				},
+			},
+		},
+		Dependencies: []string{
+			"k8s.io/apimachinery/pkg/apis/meta/v1.Condition", "k8s.io/apimachinery/pkg/apis/meta/v1.Time"},
+	}
+}
@@ -1,8 +1,14 @@
 package splunkforwarder
 
 import (
+	"bytes"
 	"context"
+	goerr "errors"
+	"fmt"
+	"reflect"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/go-logr/logr"
 
@@ -12,20 +18,47 @@ import (
 	logf "sigs.k8s.io/controller-runtime/pkg/log"
 
 	configv1 "github.com/openshift/api/config/v1"
+	operatorv1alpha1 "github.com/openshift/api/operator/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 
 	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
 	"github.com/openshift/splunk-forwarder-operator/config"
 	"github.com/openshift/splunk-forwarder-operator/pkg/kube"
+	"github.com/openshift/splunk-forwarder-operator/pkg/lookup"
+	"github.com/openshift/splunk-forwarder-operator/pkg/registry"
 )
 
-var (
-	log = logf.Log.WithName("controller_splunkforwarder")
+var log = logf.Log.WithName("controller_splunkforwarder")
+
+// clusterMetadataRetryInterval is how long to wait before looking up the cluster metadata again
+// after the Infrastructure resource could not be read
+const clusterMetadataRetryInterval = 30 * time.Second
+
+// rolloutPollInterval is how often the canary forwarders, and the rollouts of the forwarder DaemonSets, are
+// checked until they are ready
+const rolloutPollInterval = 30 * time.Second
+
+const (
+	// podTemplateGenerationLabel is set by the DaemonSet controller on the pods to the template generation of
+	// the DaemonSet they were created from
+	podTemplateGenerationLabel = "pod-template-generation"
+	// templateGenerationAnnotation is set by the API server on the DaemonSets and holds their template
+	// generation, which is only bumped by changes of the pod template
+	templateGenerationAnnotation = "deprecated.daemonset.template.generation"
 )
 
 // SplunkForwarderReconciler reconciles a SplunkForwarder object
@@ -33,6 +66,12 @@ type SplunkForwarderReconciler struct {
 	Client    client.Client
 	Scheme    *runtime.Scheme
 	ReqLogger logr.Logger
+	// OperatorImage is the image of the operator, which the config-reload sidecar of the forwarders runs, and
+	// the events collector by default. Without it the forwarders run no sidecar, and are restarted instead.
+	OperatorImage string
+	// ImageResolver resolves the forwarder image tag to the digest pinned into the DaemonSets. Tags are
+	// used as is when it is nil.
+	ImageResolver registry.Resolver
 }
 
 // CheckGenerationVersionOlder is a function that checks against an annontations map with a splunk forwarder instance to compare the saved
@@ -59,6 +98,14 @@ func (r *SplunkForwarderReconciler) CheckGenerationVersionOlder(annontations map
 //+kubebuilder:rbac:groups=splunkforwarder.managed.openshift.io,resources=splunkforwarders,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=splunkforwarder.managed.openshift.io,resources=splunkforwarders/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=splunkforwarder.managed.openshift.io,resources=splunkforwarders/finalizers,verbs=update
+//+kubebuilder:rbac:groups=cert-manager.io,resources=certificates,verbs=get;list;watch;create;update;delete
+//+kubebuilder:rbac:groups=config.openshift.io,resources=infrastructures;clusterversions;proxies;apiservers;imagedigestmirrorsets,verbs=get;list;watch
+//+kubebuilder:rbac:groups=operator.openshift.io,resources=imagecontentsourcepolicies,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups=apps,resources=controllerrevisions,verbs=get;list;watch;create;update
+//+kubebuilder:rbac:groups="",resources=serviceaccounts,verbs=get;list;watch;create;delete
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterrolebindings,verbs=get;list;watch;create;update;delete
+//+kubebuilder:rbac:groups=rbac.authorization.k8s.io,resources=clusterroles,verbs=bind,resourceNames=splunk-events-collector
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -72,9 +119,9 @@ func (r *SplunkForwarderReconciler) Reconcile(ctx context.Context, request ctrl.
 	if err != nil {
 		if errors.IsNotFound(err) {
 			// Request object not found, could have been deleted after reconcile request.
-			// Owned objects are automatically garbage collected. For additional cleanup logic use finalizers.
-			// Return and don't requeue
-			return reconcile.Result{}, nil
+			// Owned objects are automatically garbage collected, but not the cluster-scoped binding of the
+			// events collector. Return and don't requeue
+			return reconcile.Result{}, r.deleteEventsCollectorBinding(ctx, request.NamespacedName)
 		}
 		// Error reading the object - requeue the request.
 		return reconcile.Result{}, err
@@ -87,89 +134,176 @@ func (r *SplunkForwarderReconciler) Reconcile(ctx context.Context, request ctrl.
 		return reconcile.Result{}, err
 	}
 
-	var clusterid string
-	if instance.Spec.ClusterID != "" {
-		clusterid = instance.Spec.ClusterID
+	// The trusted CA bundle stands in for the CA of issuers that do not publish it
+	err = r.reconcileTrustedCABundle(ctx, instance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	if instance.Spec.CertificateIssuerRef != nil {
+		issued, err := r.reconcileCertificate(ctx, instance, secFound)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if !issued {
+			// The issued Secret is watched, so there is no need to requeue
+			r.ReqLogger.Info("Waiting for cert-manager to issue the forwarder certificate", "Secret.Name", kube.CertificateSecretName(instance))
+			return reconcile.Result{}, nil
+		}
 	} else {
-		configFound := &configv1.Infrastructure{}
-		err = r.Client.Get(context.TODO(), types.NamespacedName{Name: "cluster"}, configFound)
+		err = r.deleteCertificate(ctx, instance)
 		if err != nil {
-			r.ReqLogger.Info(err.Error())
-			clusterid = "openshift"
-		} else {
-			clusterid = configFound.Status.InfrastructureName
+			return reconcile.Result{}, err
 		}
 	}
 
-	// ConfigMaps
-	// Define a new ConfigMap object
-	// TODO(wshearn) - check instance.Spec.ClusterID, if it is empty look it up on the cluster.
-	configMaps := kube.GenerateConfigMaps(instance, request.NamespacedName, clusterid)
-
-	for _, configmap := range configMaps {
-		// Set SplunkForwarder instance as the owner and controller
-		if err := controllerutil.SetControllerReference(instance, configmap, r.Scheme); err != nil {
+	state, err := lookup.ClusterState(ctx, r.Client, instance)
+	if goerr.Is(err, lookup.ErrInfrastructureUnavailable) {
+		// Rendering the inputs now would tag the events with an incomplete cluster ID until the CR
+		// changes again, so keep the current inputs and retry. The Infrastructure resource is watched.
+		r.ReqLogger.Info("Cluster metadata not available, not rendering inputs", "Error", err.Error())
+		err = r.setCondition(ctx, instance, sfv1alpha1.ConditionClusterMetadataResolved, metav1.ConditionFalse, "InfrastructureUnavailable", err.Error())
+		if err != nil {
 			return reconcile.Result{}, err
 		}
+		return reconcile.Result{RequeueAfter: clusterMetadataRetryInterval}, nil
+	} else if goerr.Is(err, lookup.ErrClusterVersionUnavailable) {
+		// The ClusterVersion is watched, the inputs are rendered again once it is created
+		r.ReqLogger.Info("Cluster version not available, rendering inputs without its fields", "Error", err.Error())
+		err = r.setCondition(ctx, instance, sfv1alpha1.ConditionClusterMetadataResolved, metav1.ConditionFalse, "ClusterVersionUnavailable", err.Error())
+	} else if err == nil {
+		err = r.setCondition(ctx, instance, sfv1alpha1.ConditionClusterMetadataResolved, metav1.ConditionTrue, "Resolved", "Cluster metadata resolved")
+	}
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	fips := kube.FIPSEnabled(instance, state.FIPS)
+	if state.UseHECToken {
+		r.ReqLogger.Info("HTTP Event Collector token found, using HEC mode for Splunk Universal Forwarder")
+	} else {
+		r.ReqLogger.Info("HTTP Event Collector token not present, using mTLS authentication")
+	}
 
-		// Check if this ConfigMap already exists
-		cmFound := &corev1.ConfigMap{}
-		err = r.Client.Get(context.TODO(), types.NamespacedName{Name: configmap.Name, Namespace: configmap.Namespace}, cmFound)
-		if err != nil && errors.IsNotFound(err) {
-			r.ReqLogger.Info("Creating a new ConfigMap", "ConfigMap.Namespace", configmap.Namespace, "ConfigMap.Name", configmap.Name)
-			err = r.Client.Create(context.TODO(), configmap)
-			if err != nil {
-				return reconcile.Result{}, err
-			}
-		} else if err != nil {
-			return reconcile.Result{}, err
-		} else if instance.CreationTimestamp.After(cmFound.CreationTimestamp.Time) || r.CheckGenerationVersionOlder(cmFound.GetAnnotations(), instance) {
-			r.ReqLogger.Info("Updating ConfigMap", "ConfigMap.Namespace", configmap.Namespace, "ConfigMap.Name", configmap.Name)
-			err = r.Client.Update(context.TODO(), configmap)
-			if err != nil {
-				return reconcile.Result{}, err
-			}
-			return reconcile.Result{Requeue: true}, nil
-		}
+	// ConfigMaps
+	configMaps := kube.BuildConfigMaps(instance, state)
+
+	compliant, err := r.checkFIPSCompliance(ctx, instance, fips, configMaps, secFound)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+	if !compliant {
+		// Keep the forwarders on their current configuration. The CR and the splunk-auth Secret are
+		// watched, so there is no need to requeue.
+		return reconcile.Result{}, nil
 	}
 
-	useHECToken := false
-	hecToken := &corev1.Secret{}
-	err = r.Client.Get(ctx, types.NamespacedName{Name: config.SplunkHECTokenSecretName, Namespace: request.Namespace}, hecToken)
-	if errors.IsNotFound(err) {
-		r.ReqLogger.Info("HTTP Event Collector token not present, using mTLS authentication")
-	} else if err != nil {
+	resolved, err := r.resolveImage(ctx, instance)
+	if err != nil {
 		return reconcile.Result{}, err
-	} else {
-		r.ReqLogger.Info("HTTP Event Collector token found, using HEC mode for Splunk Universal Forwarder")
-		useHECToken = true
+	}
+	if !resolved {
+		// Keep the forwarders on their current image until the CR changes
+		return reconcile.Result{}, nil
 	}
 
-	// DaemonSet
-	daemonSet := kube.GenerateDaemonSet(instance, useHECToken)
+	// Admin credentials for the config-reload sidecar, generated once
+	adminSecret, err := kube.GenerateAdminSecret(instance)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
 	// Set SplunkForwarder instance as the owner and controller
-	if err := controllerutil.SetControllerReference(instance, daemonSet, r.Scheme); err != nil {
+	if err := controllerutil.SetControllerReference(instance, adminSecret, r.Scheme); err != nil {
 		return reconcile.Result{}, err
 	}
-
-	// Check if this DaemonSet already exists
-	dsFound := &appsv1.DaemonSet{}
-	err = r.Client.Get(context.TODO(), types.NamespacedName{Name: daemonSet.Name, Namespace: daemonSet.Namespace}, dsFound)
+	err = r.Client.Get(ctx, types.NamespacedName{Name: adminSecret.Name, Namespace: adminSecret.Namespace}, &corev1.Secret{})
 	if err != nil && errors.IsNotFound(err) {
-		r.ReqLogger.Info("Creating a new DaemonSet", "DaemonSet.Namespace", daemonSet.Namespace, "DaemonSet.Name", daemonSet.Name)
-		err = r.Client.Create(context.TODO(), daemonSet)
+		r.ReqLogger.Info("Creating a new Secret", "Secret.Namespace", adminSecret.Namespace, "Secret.Name", adminSecret.Name)
+		err = r.Client.Create(ctx, adminSecret)
 		if err != nil {
 			return reconcile.Result{}, err
 		}
 	} else if err != nil {
 		return reconcile.Result{}, err
-	} else if instance.CreationTimestamp.After(dsFound.CreationTimestamp.Time) || r.CheckGenerationVersionOlder(dsFound.GetAnnotations(), instance) {
-		err = r.Client.Delete(context.TODO(), daemonSet)
+	}
+
+	// DaemonSets
+	if r.OperatorImage == "" {
+		r.ReqLogger.Info("No image to run the config-reload sidecar, changes to the inputs restart the forwarders")
+	}
+	daemonSets := kube.BuildDaemonSets(instance, state, r.OperatorImage)
+
+	rolledBack := meta.FindStatusCondition(instance.Status.Conditions, sfv1alpha1.ConditionRolledBack)
+	if rolledBack != nil && rolledBack.ObservedGeneration == instance.Generation && rolledBack.Status == metav1.ConditionTrue {
+		// Keep the forwarders on the last good state they were rolled back to until the CR changes
+		err = r.keepLastGoodStates(ctx, configMaps, daemonSets)
 		if err != nil {
 			return reconcile.Result{}, err
 		}
-		// Requeue to create the daemonset
-		return reconcile.Result{Requeue: true}, nil
+	} else if rolledBack != nil {
+		meta.RemoveStatusCondition(&instance.Status.Conditions, sfv1alpha1.ConditionRolledBack)
+		err = r.Client.Status().Update(ctx, instance)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	promote := true
+	requeueAfter := time.Duration(0)
+	if kube.CanaryEnabled(instance) {
+		promote, requeueAfter, err = r.reconcileCanary(ctx, instance, configMaps, daemonSets)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+	} else {
+		err = r.deleteCanary(ctx, instance)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+	}
+
+	// Until the canary is promoted, the other nodes are kept on their current forwarders
+	if promote {
+		updated, err := r.applyConfigMaps(ctx, instance, configMaps)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if updated {
+			return reconcile.Result{Requeue: true}, nil
+		}
+		recreated, err := r.applyDaemonSets(ctx, instance, daemonSets)
+		if err != nil {
+			return reconcile.Result{}, err
+		}
+		if recreated {
+			// Requeue to create the daemonset
+			return reconcile.Result{Requeue: true}, nil
+		}
+		if kube.AutoRollbackEnabled(instance) {
+			requeueAfter, err = r.checkRollouts(ctx, instance, daemonSets)
+			if err != nil {
+				return reconcile.Result{}, err
+			}
+		}
+	}
+
+	err = r.reconcileEventsCollector(ctx, instance, state)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	err = r.deleteUnusedNodeRoles(ctx, instance, state.NodeRoles)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	err = r.checkImageMirrors(ctx, instance, daemonSets)
+	if err != nil {
+		return reconcile.Result{}, err
+	}
+
+	err = r.updateStatus(ctx, instance, daemonSets, state.UseHECToken, fips)
+	if err != nil {
+		return reconcile.Result{}, err
 	}
 
 	// Service
@@ -192,15 +326,1139 @@ func (r *SplunkForwarderReconciler) Reconcile(ctx context.Context, request ctrl.
 		return reconcile.Result{Requeue: true}, nil
 	}
 
-	return ctrl.Result{}, nil
+	return ctrl.Result{RequeueAfter: requeueAfter}, nil
+}
+
+// applyConfigMaps creates the missing ConfigMaps and updates the first one whose content changed. It
+// returns whether a ConfigMap was updated, so that the others are updated when the request is requeued.
+func (r *SplunkForwarderReconciler) applyConfigMaps(ctx context.Context, instance *sfv1alpha1.SplunkForwarder, configMaps []*corev1.ConfigMap) (bool, error) {
+	for _, configmap := range configMaps {
+		// Set SplunkForwarder instance as the owner and controller
+		if err := controllerutil.SetControllerReference(instance, configmap, r.Scheme); err != nil {
+			return false, err
+		}
+
+		// Check if this ConfigMap already exists
+		cmFound := &corev1.ConfigMap{}
+		err := r.Client.Get(ctx, types.NamespacedName{Name: configmap.Name, Namespace: configmap.Namespace}, cmFound)
+		if err != nil && errors.IsNotFound(err) {
+			r.ReqLogger.Info("Creating a new ConfigMap", "ConfigMap.Namespace", configmap.Namespace, "ConfigMap.Name", configmap.Name)
+			err = r.Client.Create(ctx, configmap)
+			if err != nil {
+				return false, err
+			}
+		} else if err != nil {
+			return false, err
+		} else if instance.CreationTimestamp.After(cmFound.CreationTimestamp.Time) || r.CheckGenerationVersionOlder(cmFound.GetAnnotations(), instance) || !reflect.DeepEqual(cmFound.Data, configmap.Data) {
+			r.ReqLogger.Info("Updating ConfigMap", "ConfigMap.Namespace", configmap.Namespace, "ConfigMap.Name", configmap.Name)
+			err = r.Client.Update(ctx, configmap)
+			if err != nil {
+				return false, err
+			}
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// applyDaemonSets creates the DaemonSets, and updates those whose pod template changed. DaemonSets older
+// than the CR are deleted, and it returns true so that they are created again.
+func (r *SplunkForwarderReconciler) applyDaemonSets(ctx context.Context, instance *sfv1alpha1.SplunkForwarder, daemonSets []*appsv1.DaemonSet) (bool, error) {
+	for _, daemonSet := range daemonSets {
+		// Set SplunkForwarder instance as the owner and controller
+		if err := controllerutil.SetControllerReference(instance, daemonSet, r.Scheme); err != nil {
+			return false, err
+		}
+
+		// Check if this DaemonSet already exists
+		dsFound := &appsv1.DaemonSet{}
+		err := r.Client.Get(ctx, types.NamespacedName{Name: daemonSet.Name, Namespace: daemonSet.Namespace}, dsFound)
+		if err != nil && errors.IsNotFound(err) {
+			r.ReqLogger.Info("Creating a new DaemonSet", "DaemonSet.Namespace", daemonSet.Namespace, "DaemonSet.Name", daemonSet.Name)
+			err = r.Client.Create(ctx, daemonSet)
+			if err != nil {
+				return false, err
+			}
+		} else if err != nil {
+			return false, err
+		} else if instance.CreationTimestamp.After(dsFound.CreationTimestamp.Time) {
+			err = r.Client.Delete(ctx, daemonSet)
+			if err != nil {
+				return false, err
+			}
+			return true, nil
+		} else if r.CheckGenerationVersionOlder(dsFound.GetAnnotations(), instance) || dsFound.Annotations[kube.TemplateHashAnnotation] != daemonSet.Annotations[kube.TemplateHashAnnotation] {
+			// Update in place: the pods are only rolled, one node at a time, when the pod template changed,
+			// e.g. for a new image, authentication mode or certificate. Monitor inputs changes are picked up by the
+			// config-reload sidecar without a restart.
+			r.ReqLogger.Info("Updating DaemonSet", "DaemonSet.Namespace", dsFound.Namespace, "DaemonSet.Name", dsFound.Name)
+			// The progress of the new rollout is tracked anew
+			dsFound.Labels = daemonSet.Labels
+			dsFound.Annotations = daemonSet.Annotations
+			dsFound.Spec = daemonSet.Spec
+			err = r.Client.Update(ctx, dsFound)
+			if err != nil {
+				return false, err
+			}
+		}
+	}
+	return false, nil
+}
+
+// checkRollouts records the pod template of the forwarder DaemonSets, with the data of the ConfigMaps they
+// mount, as their last good state once every pod runs it and is ready. A DaemonSet whose new pods crash-loop
+// or cannot be started, or whose rollout makes no progress for the progress deadline, is rolled back to its
+// last good pod template and ConfigMaps, and the RolledBack condition of the CR is set. It returns how long
+// to wait before checking the rollouts in progress again.
+func (r *SplunkForwarderReconciler) checkRollouts(ctx context.Context, instance *sfv1alpha1.SplunkForwarder, daemonSets []*appsv1.DaemonSet) (time.Duration, error) {
+	requeueAfter := time.Duration(0)
+	deadline := kube.RollbackProgressDeadline(instance)
+	for _, daemonSet := range daemonSets {
+		dsFound := &appsv1.DaemonSet{}
+		err := r.Client.Get(ctx, types.NamespacedName{Name: daemonSet.Name, Namespace: daemonSet.Namespace}, dsFound)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return 0, err
+		}
+		if dsFound.Annotations == nil {
+			dsFound.Annotations = map[string]string{}
+		}
+		revision := &appsv1.ControllerRevision{}
+		err = r.Client.Get(ctx, types.NamespacedName{Name: kube.LastGoodRevisionName(dsFound.Name), Namespace: dsFound.Namespace}, revision)
+		if errors.IsNotFound(err) {
+			revision = nil
+		} else if err != nil {
+			return 0, err
+		}
+		current := revision != nil && revision.Annotations[kube.TemplateHashAnnotation] == dsFound.Annotations[kube.TemplateHashAnnotation]
+
+		if kube.RolloutComplete(dsFound) {
+			if !current {
+				err := r.recordLastGoodState(ctx, instance, dsFound, revision)
+				if err != nil {
+					return 0, err
+				}
+			}
+			if kube.EndRolloutTracking(dsFound) {
+				if err := r.Client.Update(ctx, dsFound); err != nil {
+					return 0, err
+				}
+			}
+			continue
+		}
+		if revision == nil || current {
+			// Nothing to roll back to
+			continue
+		}
+
+		// Only the pods of the new pod template count, the others are being replaced
+		podList := &corev1.PodList{}
+		err = r.Client.List(ctx, podList, client.InNamespace(dsFound.Namespace), client.MatchingLabels(dsFound.Spec.Selector.MatchLabels),
+			client.MatchingLabels{podTemplateGenerationLabel: dsFound.Annotations[templateGenerationAnnotation]})
+		if err != nil {
+			return 0, err
+		}
+		failing := kube.FailingPods(podList.Items)
+		changed, stalled := kube.TrackRolloutProgress(dsFound, time.Now())
+		message := ""
+		switch {
+		case len(failing) > 0:
+			message = fmt.Sprintf("DaemonSet %s was rolled back, containers failing: %s", dsFound.Name, strings.Join(failing, ", "))
+		case stalled >= deadline:
+			message = fmt.Sprintf("DaemonSet %s was rolled back, its rollout made no progress for %s", dsFound.Name, deadline)
+		default:
+			if changed {
+				if err := r.Client.Update(ctx, dsFound); err != nil {
+					return 0, err
+				}
+			}
+			requeueAfter = min(deadline-stalled, rolloutPollInterval)
+			continue
+		}
+
+		r.ReqLogger.Info("Rolling the DaemonSet back to its last good state", "DaemonSet.Namespace", dsFound.Namespace, "DaemonSet.Name", dsFound.Name, "Message", message)
+		state, err := kube.RollBackDaemonSet(dsFound, revision)
+		if err != nil {
+			return 0, err
+		}
+		for name := range state.ConfigMaps {
+			cmFound := &corev1.ConfigMap{}
+			err := r.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: dsFound.Namespace}, cmFound)
+			if errors.IsNotFound(err) {
+				continue
+			} else if err != nil {
+				return 0, err
+			}
+			if kube.RestoreConfigMap(cmFound, state) {
+				r.ReqLogger.Info("Restoring the last good ConfigMap", "ConfigMap.Namespace", cmFound.Namespace, "ConfigMap.Name", cmFound.Name)
+				if err := r.Client.Update(ctx, cmFound); err != nil {
+					return 0, err
+				}
+			}
+		}
+		if err := r.Client.Update(ctx, dsFound); err != nil {
+			return 0, err
+		}
+		return 0, r.setCondition(ctx, instance, sfv1alpha1.ConditionRolledBack, metav1.ConditionTrue, "RolloutFailed", message)
+	}
+	return requeueAfter, nil
+}
+
+// recordLastGoodState records the pod template of a DaemonSet whose rollout completed, and the data of the
+// ConfigMaps it mounts, in the ControllerRevision of its last good state
+func (r *SplunkForwarderReconciler) recordLastGoodState(ctx context.Context, instance *sfv1alpha1.SplunkForwarder, ds *appsv1.DaemonSet, revision *appsv1.ControllerRevision) error {
+	configMaps := []*corev1.ConfigMap{}
+	for _, volume := range ds.Spec.Template.Spec.Volumes {
+		if volume.ConfigMap == nil {
+			continue
+		}
+		cmFound := &corev1.ConfigMap{}
+		err := r.Client.Get(ctx, types.NamespacedName{Name: volume.ConfigMap.Name, Namespace: ds.Namespace}, cmFound)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		configMaps = append(configMaps, cmFound)
+	}
+
+	r.ReqLogger.Info("Recording the last good state", "DaemonSet.Namespace", ds.Namespace, "DaemonSet.Name", ds.Name)
+	if revision == nil {
+		revision = kube.GenerateLastGoodRevision(ds, configMaps, 1)
+		if err := controllerutil.SetControllerReference(instance, revision, r.Scheme); err != nil {
+			return err
+		}
+		return r.Client.Create(ctx, revision)
+	}
+	generated := kube.GenerateLastGoodRevision(ds, configMaps, revision.Revision+1)
+	revision.Labels = generated.Labels
+	revision.Annotations = generated.Annotations
+	revision.Data = generated.Data
+	revision.Revision = generated.Revision
+	return r.Client.Update(ctx, revision)
+}
+
+// keepLastGoodStates replaces the generated pod templates of the forwarder DaemonSets, and the configuration
+// files of the generated ConfigMaps, by the last good states the forwarders were rolled back to. The other
+// objects of the forwarders are still reconciled.
+func (r *SplunkForwarderReconciler) keepLastGoodStates(ctx context.Context, configMaps []*corev1.ConfigMap, daemonSets []*appsv1.DaemonSet) error {
+	for _, daemonSet := range daemonSets {
+		revision := &appsv1.ControllerRevision{}
+		err := r.Client.Get(ctx, types.NamespacedName{Name: kube.LastGoodRevisionName(daemonSet.Name), Namespace: daemonSet.Namespace}, revision)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		if err := kube.KeepLastGoodState(daemonSet, configMaps, revision); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// updateStatus records the reconciled generation, the delivery and FIPS modes and the number of forwarder
+// pods of the DaemonSets in the status of the CR.
+func (r *SplunkForwarderReconciler) updateStatus(ctx context.Context, instance *sfv1alpha1.SplunkForwarder, daemonSets []*appsv1.DaemonSet, useHECToken, fips bool) error {
+	status := instance.Status.DeepCopy()
+	status.ObservedGeneration = instance.Generation
+	status.DeliveryMode = kube.DeliveryMode(instance, useHECToken)
+	status.FIPSMode = kube.FIPSMode(fips)
+	status.DesiredNumberScheduled = 0
+	status.NumberReady = 0
+	for _, daemonSet := range daemonSets {
+		dsFound := &appsv1.DaemonSet{}
+		err := r.Client.Get(ctx, types.NamespacedName{Name: daemonSet.Name, Namespace: daemonSet.Namespace}, dsFound)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		status.DesiredNumberScheduled += dsFound.Status.DesiredNumberScheduled
+		status.NumberReady += dsFound.Status.NumberReady
+	}
+
+	if reflect.DeepEqual(*status, instance.Status) {
+		return nil
+	}
+	instance.Status = *status
+	return r.Client.Status().Update(ctx, instance)
+}
+
+// checkImageMirrors records in the ImageMirrorsAvailable condition of the CR whether the image mirrors of
+// the cluster cover the images of the forwarders, which could otherwise not be pulled on a disconnected
+// cluster. The condition is removed on clusters without image mirrors.
+func (r *SplunkForwarderReconciler) checkImageMirrors(ctx context.Context, instance *sfv1alpha1.SplunkForwarder, daemonSets []*appsv1.DaemonSet) error {
+	sources, err := lookup.ImageMirrorSources(ctx, r.Client)
+	if err != nil {
+		return err
+	}
+	if len(sources) == 0 {
+		if meta.RemoveStatusCondition(&instance.Status.Conditions, sfv1alpha1.ConditionImageMirrorsAvailable) {
+			return r.Client.Status().Update(ctx, instance)
+		}
+		return nil
+	}
+
+	templates := []*corev1.PodTemplateSpec{}
+	for _, daemonSet := range daemonSets {
+		templates = append(templates, &daemonSet.Spec.Template)
+	}
+	unmirrored := kube.UnmirroredImages(templates, sources)
+	if len(unmirrored) > 0 {
+		r.ReqLogger.Info("Forwarder images are not covered by the image mirrors of the cluster", "Images", unmirrored)
+		return r.setCondition(ctx, instance, sfv1alpha1.ConditionImageMirrorsAvailable, metav1.ConditionFalse, "MirrorMissing",
+			"Not covered by an ImageDigestMirrorSet or ImageContentSourcePolicy: "+strings.Join(unmirrored, ", ")+". Only images referenced by digest are mirrored.")
+	}
+	return r.setCondition(ctx, instance, sfv1alpha1.ConditionImageMirrorsAvailable, metav1.ConditionTrue, "Mirrored", "The image mirrors of the cluster cover the forwarder images")
+}
+
+// deleteUnusedNodeRoles deletes the ConfigMaps and DaemonSets generated for node roles that are no
+// longer referenced by the inputs of the CR, and for combinations of roles no node has anymore.
+func (r *SplunkForwarderReconciler) deleteUnusedNodeRoles(ctx context.Context, instance *sfv1alpha1.SplunkForwarder, nodeRoles [][]string) error {
+	roles := map[string]bool{}
+	for _, key := range kube.NodeRoleSetKeys(instance, nodeRoles) {
+		roles[key] = true
+	}
+	listOpts := []client.ListOption{
+		client.InNamespace(instance.Namespace),
+		client.MatchingLabels{"app": instance.Name},
+		client.HasLabels{kube.NodeRoleLabel},
+	}
+
+	dsList := &appsv1.DaemonSetList{}
+	if err := r.Client.List(ctx, dsList, listOpts...); err != nil {
+		return err
+	}
+	for i := range dsList.Items {
+		ds := &dsList.Items[i]
+		if roles[ds.Labels[kube.NodeRoleLabel]] {
+			continue
+		}
+		r.ReqLogger.Info("Deleting the DaemonSet of an unused node role", "DaemonSet.Namespace", ds.Namespace, "DaemonSet.Name", ds.Name)
+		if err := r.Client.Delete(ctx, ds); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	cmList := &corev1.ConfigMapList{}
+	if err := r.Client.List(ctx, cmList, listOpts...); err != nil {
+		return err
+	}
+	for i := range cmList.Items {
+		cm := &cmList.Items[i]
+		if roles[cm.Labels[kube.NodeRoleLabel]] {
+			continue
+		}
+		r.ReqLogger.Info("Deleting the ConfigMap of an unused node role", "ConfigMap.Namespace", cm.Namespace, "ConfigMap.Name", cm.Name)
+		if err := r.Client.Delete(ctx, cm); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// reconcileCanary rolls the forwarder ConfigMaps and DaemonSets out to the canary nodes first. It returns
+// whether they can be applied to every node: when they are new or already live, or once the canary
+// forwarders stayed ready for the soak duration. Otherwise it returns how long to wait before checking the
+// canary again. A failed canary is rolled back to the live forwarders, and the change is not promoted until
+// the generated objects change again. The progress is recorded in the status of the CR.
+func (r *SplunkForwarderReconciler) reconcileCanary(ctx context.Context, instance *sfv1alpha1.SplunkForwarder, configMaps []*corev1.ConfigMap, daemonSets []*appsv1.DaemonSet) (bool, time.Duration, error) {
+	pending := false
+	liveConfigMaps := []*corev1.ConfigMap{}
+	for _, configMap := range configMaps {
+		cmFound := &corev1.ConfigMap{}
+		err := r.Client.Get(ctx, types.NamespacedName{Name: configMap.Name, Namespace: configMap.Namespace}, cmFound)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return false, 0, err
+		}
+		liveConfigMaps = append(liveConfigMaps, cmFound)
+		pending = pending || !reflect.DeepEqual(kube.RolloutConfigData(cmFound), kube.RolloutConfigData(configMap))
+	}
+	liveDaemonSets := []*appsv1.DaemonSet{}
+	for _, daemonSet := range daemonSets {
+		dsFound := &appsv1.DaemonSet{}
+		err := r.Client.Get(ctx, types.NamespacedName{Name: daemonSet.Name, Namespace: daemonSet.Namespace}, dsFound)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return false, 0, err
+		}
+		liveDaemonSets = append(liveDaemonSets, dsFound)
+		pending = pending || dsFound.Annotations[kube.TemplateHashAnnotation] != daemonSet.Annotations[kube.TemplateHashAnnotation]
+	}
+
+	nodeSelector := instance.Spec.RolloutStrategy.Canary.NodeSelector
+	canaryConfigMaps, canaryDaemonSets := kube.GenerateCanaryObjects(configMaps, daemonSets, nodeSelector)
+	if !pending {
+		// Nothing to test, the canary nodes run the same forwarders as the other nodes
+		return true, 0, r.applyCanary(ctx, instance, canaryConfigMaps, canaryDaemonSets)
+	}
+
+	status := &instance.Status
+	revision := kube.RolloutRevision(configMaps, daemonSets)
+	if status.CanaryRevision != revision {
+		r.ReqLogger.Info("Rolling out to the canary nodes", "Revision", revision)
+		err := r.setCanaryPhase(ctx, instance, revision, sfv1alpha1.CanaryProgressing, "Rolling out to the canary nodes")
+		if err != nil {
+			return false, 0, err
+		}
+	}
+	switch status.CanaryPhase {
+	case sfv1alpha1.CanaryPromoted:
+		return true, 0, nil
+	case sfv1alpha1.CanaryFailed:
+		canaryConfigMaps, canaryDaemonSets = kube.GenerateCanaryObjects(liveConfigMaps, liveDaemonSets, nodeSelector)
+		return false, 0, r.applyCanary(ctx, instance, canaryConfigMaps, canaryDaemonSets)
+	}
+
+	err := r.applyCanary(ctx, instance, canaryConfigMaps, canaryDaemonSets)
+	if err != nil {
+		return false, 0, err
+	}
+	failing, ready, scheduled, err := r.canaryHealth(ctx, canaryDaemonSets)
+	if err != nil {
+		return false, 0, err
+	}
+	elapsed := time.Since(status.CanaryPhaseTime.Time)
+	deadline := kube.CanaryProgressDeadline(instance)
+	soak := kube.CanarySoakDuration(instance)
+	message := ""
+	switch {
+	case len(failing) > 0:
+		message = "Canary containers failing: " + strings.Join(failing, ", ")
+	case !ready && status.CanaryPhase == sfv1alpha1.CanaryProgressing && elapsed >= deadline:
+		message = fmt.Sprintf("The canary forwarders were not ready after %s", deadline)
+		if scheduled == 0 {
+			message = "No node matches the canary nodeSelector"
+		}
+	case !ready:
+		if status.CanaryPhase == sfv1alpha1.CanarySoaking {
+			err = r.setCanaryPhase(ctx, instance, revision, sfv1alpha1.CanaryProgressing, "The canary forwarders are no longer ready")
+		}
+		return false, rolloutPollInterval, err
+	case status.CanaryPhase == sfv1alpha1.CanaryProgressing:
+		err = r.setCanaryPhase(ctx, instance, revision, sfv1alpha1.CanarySoaking, "The canary forwarders are ready")
+		return false, min(soak, rolloutPollInterval), err
+	case elapsed < soak:
+		return false, min(soak-elapsed, rolloutPollInterval), nil
+	default:
+		r.ReqLogger.Info("Promoting the canary to every node", "Revision", revision)
+		err = r.setCanaryPhase(ctx, instance, revision, sfv1alpha1.CanaryPromoted, fmt.Sprintf("The canary forwarders stayed ready for %s", soak))
+		return err == nil, 0, err
+	}
+
+	r.ReqLogger.Info("The canary failed, rolling it back", "Revision", revision, "Message", message)
+	err = r.setCanaryPhase(ctx, instance, revision, sfv1alpha1.CanaryFailed, message)
+	if err != nil {
+		return false, 0, err
+	}
+	canaryConfigMaps, canaryDaemonSets = kube.GenerateCanaryObjects(liveConfigMaps, liveDaemonSets, nodeSelector)
+	return false, 0, r.applyCanary(ctx, instance, canaryConfigMaps, canaryDaemonSets)
+}
+
+// applyCanary creates or updates the canary ConfigMaps and DaemonSets. They are stamped with the current
+// generation of the CR, as they may be copies of the live objects.
+func (r *SplunkForwarderReconciler) applyCanary(ctx context.Context, instance *sfv1alpha1.SplunkForwarder, configMaps []*corev1.ConfigMap, daemonSets []*appsv1.DaemonSet) error {
+	genVersion := strconv.FormatInt(instance.Generation, 10)
+	for _, configMap := range configMaps {
+		configMap.Annotations["genVersion"] = genVersion
+	}
+	for _, daemonSet := range daemonSets {
+		daemonSet.Annotations["genVersion"] = genVersion
+	}
+
+	for range configMaps {
+		updated, err := r.applyConfigMaps(ctx, instance, configMaps)
+		if err != nil {
+			return err
+		}
+		if !updated {
+			break
+		}
+	}
+	_, err := r.applyDaemonSets(ctx, instance, daemonSets)
+	return err
+}
+
+// canaryHealth returns the failing containers of the canary pods, whether every canary forwarder runs
+// the current pod template and is ready, and the number of nodes scheduled to run a canary forwarder
+func (r *SplunkForwarderReconciler) canaryHealth(ctx context.Context, daemonSets []*appsv1.DaemonSet) ([]string, bool, int32, error) {
+	failing := []string{}
+	ready := true
+	scheduled := int32(0)
+	for _, daemonSet := range daemonSets {
+		dsFound := &appsv1.DaemonSet{}
+		err := r.Client.Get(ctx, types.NamespacedName{Name: daemonSet.Name, Namespace: daemonSet.Namespace}, dsFound)
+		if errors.IsNotFound(err) {
+			ready = false
+			continue
+		} else if err != nil {
+			return nil, false, 0, err
+		}
+		status := dsFound.Status
+		scheduled += status.DesiredNumberScheduled
+		ready = ready && status.ObservedGeneration >= dsFound.Generation &&
+			status.UpdatedNumberScheduled == status.DesiredNumberScheduled && status.NumberReady == status.DesiredNumberScheduled
+
+		podList := &corev1.PodList{}
+		err = r.Client.List(ctx, podList, client.InNamespace(daemonSet.Namespace), client.MatchingLabels(daemonSet.Spec.Selector.MatchLabels))
+		if err != nil {
+			return nil, false, 0, err
+		}
+		failing = append(failing, kube.FailingPods(podList.Items)...)
+	}
+	// Without canary nodes, nothing is tested
+	return failing, ready && scheduled > 0, scheduled, nil
+}
+
+// setCanaryPhase records the phase of the canary rollout of a revision in the status of the CR
+func (r *SplunkForwarderReconciler) setCanaryPhase(ctx context.Context, instance *sfv1alpha1.SplunkForwarder, revision, phase, message string) error {
+	now := metav1.Now()
+	instance.Status.CanaryRevision = revision
+	instance.Status.CanaryPhase = phase
+	instance.Status.CanaryPhaseTime = &now
+	instance.Status.CanaryMessage = message
+	return r.Client.Status().Update(ctx, instance)
+}
+
+// deleteCanary deletes the canary ConfigMaps and DaemonSets, and the canary status of the CR, once the CR
+// no longer uses a canary rollout
+func (r *SplunkForwarderReconciler) deleteCanary(ctx context.Context, instance *sfv1alpha1.SplunkForwarder) error {
+	listOpts := []client.ListOption{
+		client.InNamespace(instance.Namespace),
+		client.MatchingLabels{"app": instance.Name},
+		client.HasLabels{kube.CanaryLabel},
+	}
+
+	dsList := &appsv1.DaemonSetList{}
+	if err := r.Client.List(ctx, dsList, listOpts...); err != nil {
+		return err
+	}
+	for i := range dsList.Items {
+		ds := &dsList.Items[i]
+		r.ReqLogger.Info("Deleting the canary DaemonSet", "DaemonSet.Namespace", ds.Namespace, "DaemonSet.Name", ds.Name)
+		if err := r.Client.Delete(ctx, ds); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	cmList := &corev1.ConfigMapList{}
+	if err := r.Client.List(ctx, cmList, listOpts...); err != nil {
+		return err
+	}
+	for i := range cmList.Items {
+		cm := &cmList.Items[i]
+		r.ReqLogger.Info("Deleting the canary ConfigMap", "ConfigMap.Namespace", cm.Namespace, "ConfigMap.Name", cm.Name)
+		if err := r.Client.Delete(ctx, cm); err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	}
+
+	if instance.Status.CanaryRevision == "" {
+		return nil
+	}
+	instance.Status.CanaryRevision = ""
+	instance.Status.CanaryPhase = ""
+	instance.Status.CanaryPhaseTime = nil
+	instance.Status.CanaryMessage = ""
+	return r.Client.Status().Update(ctx, instance)
+}
+
+// checkFIPSCompliance returns whether the generated ConfigMaps and the splunk-auth Secret can be rolled out,
+// which in FIPS mode requires FIPS-approved ciphers and TLS versions only. The result is recorded in the
+// FIPSCompliant condition of the CR, which is removed outside of FIPS mode, and the FIPS mode in its status
+// whether or not the configuration can be rolled out.
+func (r *SplunkForwarderReconciler) checkFIPSCompliance(ctx context.Context, instance *sfv1alpha1.SplunkForwarder, fips bool, configMaps []*corev1.ConfigMap, authSecret *corev1.Secret) (bool, error) {
+	changed := instance.Status.FIPSMode != kube.FIPSMode(fips)
+	instance.Status.FIPSMode = kube.FIPSMode(fips)
+	compliant := true
+	if !fips {
+		changed = meta.RemoveStatusCondition(&instance.Status.Conditions, sfv1alpha1.ConditionFIPSCompliant) || changed
+	} else if err := kube.CheckFIPSConfigs(configMaps, authSecret); err != nil {
+		r.ReqLogger.Info("Not rolling out a configuration with non-FIPS ciphers", "Error", err.Error())
+		compliant = false
+		changed = meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+			Type:               sfv1alpha1.ConditionFIPSCompliant,
+			Status:             metav1.ConditionFalse,
+			ObservedGeneration: instance.Generation,
+			Reason:             "NonFIPSCiphers",
+			Message:            err.Error(),
+		}) || changed
+	} else {
+		changed = meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+			Type:               sfv1alpha1.ConditionFIPSCompliant,
+			Status:             metav1.ConditionTrue,
+			ObservedGeneration: instance.Generation,
+			Reason:             "Compliant",
+			Message:            "Only FIPS-approved ciphers and TLS versions are configured",
+		}) || changed
+	}
+	if !changed {
+		return compliant, nil
+	}
+	return compliant, r.Client.Status().Update(ctx, instance)
+}
+
+// resolveImage validates the forwarder image of the CR and pins an image tag to the digest it points to,
+// resolved once through the registry and recorded in the status. It returns false when the forwarders
+// must not be updated, as reported by the ImageResolved condition.
+func (r *SplunkForwarderReconciler) resolveImage(ctx context.Context, instance *sfv1alpha1.SplunkForwarder) (bool, error) {
+	err := kube.ValidateImage(instance)
+	if err != nil {
+		r.ReqLogger.Info("Not rolling out an invalid forwarder image", "Error", err.Error())
+		return false, r.setCondition(ctx, instance, sfv1alpha1.ConditionImageResolved, metav1.ConditionFalse, "InvalidImage", err.Error())
+	}
+
+	reference := kube.TagReference(instance)
+	if reference == "" || r.ImageResolver == nil {
+		status := instance.Status.DeepCopy()
+		status.ResolvedImage = ""
+		status.ImageDigest = ""
+		if reference == "" {
+			meta.SetStatusCondition(&status.Conditions, metav1.Condition{
+				Type:               sfv1alpha1.ConditionImageResolved,
+				Status:             metav1.ConditionTrue,
+				ObservedGeneration: instance.Generation,
+				Reason:             "Pinned",
+				Message:            "The image is pinned by imageDigest",
+			})
+		} else {
+			meta.RemoveStatusCondition(&status.Conditions, sfv1alpha1.ConditionImageResolved)
+		}
+		if reflect.DeepEqual(*status, instance.Status) {
+			return true, nil
+		}
+		instance.Status = *status
+		return true, r.Client.Status().Update(ctx, instance)
+	}
+
+	if instance.Status.ResolvedImage != reference || instance.Status.ImageDigest == "" {
+		credentials, err := r.pullCredentials(ctx, instance)
+		if err != nil {
+			return false, err
+		}
+		digest, err := r.ImageResolver.Resolve(ctx, instance.Spec.Image, instance.Spec.ImageTag, credentials)
+		if err != nil {
+			err = fmt.Errorf("unable to resolve %s: %w", reference, err)
+			if condErr := r.setCondition(ctx, instance, sfv1alpha1.ConditionImageResolved, metav1.ConditionFalse, "ResolutionFailed", err.Error()); condErr != nil {
+				return false, condErr
+			}
+			return false, err
+		}
+		r.ReqLogger.Info("Resolved the forwarder image", "Image", reference, "Digest", digest)
+		instance.Status.ResolvedImage = reference
+		instance.Status.ImageDigest = digest
+	}
+
+	err = r.setCondition(ctx, instance, sfv1alpha1.ConditionImageResolved, metav1.ConditionTrue, "Resolved", reference+" is pinned to "+instance.Status.ImageDigest)
+	if err != nil {
+		return false, err
+	}
+	// The spec is not written back: the digest only replaces the tag in the generated objects
+	kube.PinImageDigest(instance, instance.Status)
+	return true, nil
+}
+
+// pullCredentials returns the registry credentials of the image pull secrets of the CR. Missing
+// Secrets are left out, like the kubelet does.
+func (r *SplunkForwarderReconciler) pullCredentials(ctx context.Context, instance *sfv1alpha1.SplunkForwarder) (registry.Credentials, error) {
+	credentials := registry.Credentials{}
+	for _, name := range instance.Spec.ImagePullSecrets {
+		secret := &corev1.Secret{}
+		err := r.Client.Get(ctx, types.NamespacedName{Name: name, Namespace: instance.Namespace}, secret)
+		if errors.IsNotFound(err) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		data, ok := secret.Data[corev1.DockerConfigJsonKey]
+		if !ok {
+			continue
+		}
+		if err := credentials.AddDockerConfigJSON(data); err != nil {
+			return nil, fmt.Errorf("pull secret %s: %w", name, err)
+		}
+	}
+	return credentials, nil
+}
+
+// setCondition sets a condition of the CR, updating the status only when the condition changes.
+func (r *SplunkForwarderReconciler) setCondition(ctx context.Context, instance *sfv1alpha1.SplunkForwarder, conditionType string, status metav1.ConditionStatus, reason, message string) error {
+	changed := meta.SetStatusCondition(&instance.Status.Conditions, metav1.Condition{
+		Type:               conditionType,
+		Status:             status,
+		ObservedGeneration: instance.Generation,
+		Reason:             reason,
+		Message:            message,
+	})
+	if !changed {
+		return nil
+	}
+	return r.Client.Status().Update(ctx, instance)
+}
+
+// clusterVersionPredicate only lets through the ClusterVersion updates that change the metadata fields
+// taken from it. Its status is updated all along an upgrade.
+var clusterVersionPredicate = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		oldVersion, ok := e.ObjectOld.(*configv1.ClusterVersion)
+		if !ok {
+			return true
+		}
+		newVersion, ok := e.ObjectNew.(*configv1.ClusterVersion)
+		if !ok {
+			return true
+		}
+		return oldVersion.Status.Desired.Version != newVersion.Status.Desired.Version || oldVersion.Spec.ClusterID != newVersion.Spec.ClusterID
+	},
+	GenericFunc: func(event.GenericEvent) bool { return false },
+}
+
+// nodeRolesPredicate only lets through the node events that can change the role combinations of the nodes:
+// nodes being created or deleted, and changes of their role labels
+var nodeRolesPredicate = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return !reflect.DeepEqual(kube.NodeRoleLabels(e.ObjectOld.GetLabels()), kube.NodeRoleLabels(e.ObjectNew.GetLabels()))
+	},
+	GenericFunc: func(event.GenericEvent) bool { return false },
+}
+
+// clusterConfigToSplunkForwarders maps a change of the cluster configuration to every SplunkForwarder.
+func (r *SplunkForwarderReconciler) clusterConfigToSplunkForwarders(ctx context.Context, obj client.Object) []reconcile.Request {
+	sfList := &sfv1alpha1.SplunkForwarderList{}
+	if err := r.Client.List(ctx, sfList); err != nil {
+		log.Error(err, "Unable to list SplunkForwarders")
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, sf := range sfList.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: sf.Name, Namespace: sf.Namespace},
+		})
+	}
+	return requests
+}
+
+// reconcileEventsCollector creates or updates the events collector Deployment, and its ServiceAccount and
+// ClusterRoleBinding, when the CR enables it, and deletes the collector, its inputs ConfigMap, ServiceAccount
+// and ClusterRoleBinding when it does not.
+func (r *SplunkForwarderReconciler) reconcileEventsCollector(ctx context.Context, instance *sfv1alpha1.SplunkForwarder, state kube.ClusterState) error {
+	name := types.NamespacedName{Name: kube.EventsCollectorName(instance), Namespace: instance.Namespace}
+	if !kube.EventsCollectorEnabled(instance) {
+		err := r.deleteEventsCollectorBinding(ctx, types.NamespacedName{Name: instance.Name, Namespace: instance.Namespace})
+		if err != nil {
+			return err
+		}
+		for _, obj := range []client.Object{&appsv1.Deployment{}, &corev1.ConfigMap{}, &corev1.ServiceAccount{}} {
+			err := r.Client.Get(ctx, name, obj)
+			if errors.IsNotFound(err) {
+				continue
+			} else if err != nil {
+				return err
+			}
+			r.ReqLogger.Info("Deleting the events collector", "Namespace", name.Namespace, "Name", name.Name, "Kind", fmt.Sprintf("%T", obj))
+			err = r.Client.Delete(ctx, obj)
+			if err != nil && !errors.IsNotFound(err) {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if r.OperatorImage == "" && instance.Spec.EventsCollector.Image == "" {
+		r.ReqLogger.Info("No image to run the events collector, eventsCollector.image has to be set when the operator image is not known")
+		return nil
+	}
+	if err := r.reconcileEventsCollectorRBAC(ctx, instance); err != nil {
+		return err
+	}
+
+	deployment := kube.BuildEventsCollectorDeployment(instance, state, r.OperatorImage)
+	// Set SplunkForwarder instance as the owner and controller
+	if err := controllerutil.SetControllerReference(instance, deployment, r.Scheme); err != nil {
+		return err
+	}
+
+	deploymentFound := &appsv1.Deployment{}
+	err := r.Client.Get(ctx, name, deploymentFound)
+	if errors.IsNotFound(err) {
+		r.ReqLogger.Info("Creating a new Deployment", "Deployment.Namespace", deployment.Namespace, "Deployment.Name", deployment.Name)
+		return r.Client.Create(ctx, deployment)
+	} else if err != nil {
+		return err
+	}
+	if r.CheckGenerationVersionOlder(deploymentFound.GetAnnotations(), instance) || deploymentFound.Annotations[kube.TemplateHashAnnotation] != deployment.Annotations[kube.TemplateHashAnnotation] {
+		r.ReqLogger.Info("Updating Deployment", "Deployment.Namespace", deploymentFound.Namespace, "Deployment.Name", deploymentFound.Name)
+		deploymentFound.Labels = deployment.Labels
+		deploymentFound.Annotations = deployment.Annotations
+		deploymentFound.Spec = deployment.Spec
+		return r.Client.Update(ctx, deploymentFound)
+	}
+	return nil
+}
+
+// reconcileEventsCollectorRBAC creates the ServiceAccount of the events collector in the namespace of the CR,
+// and binds the events collector ClusterRole to it
+func (r *SplunkForwarderReconciler) reconcileEventsCollectorRBAC(ctx context.Context, instance *sfv1alpha1.SplunkForwarder) error {
+	serviceAccount := kube.GenerateEventsCollectorServiceAccount(instance)
+	// Set SplunkForwarder instance as the owner and controller
+	if err := controllerutil.SetControllerReference(instance, serviceAccount, r.Scheme); err != nil {
+		return err
+	}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: serviceAccount.Name, Namespace: serviceAccount.Namespace}, &corev1.ServiceAccount{})
+	if errors.IsNotFound(err) {
+		r.ReqLogger.Info("Creating a new ServiceAccount", "ServiceAccount.Namespace", serviceAccount.Namespace, "ServiceAccount.Name", serviceAccount.Name)
+		err = r.Client.Create(ctx, serviceAccount)
+	}
+	if err != nil {
+		return err
+	}
+
+	binding := kube.GenerateEventsCollectorClusterRoleBinding(instance)
+	bindingFound := &rbacv1.ClusterRoleBinding{}
+	err = r.Client.Get(ctx, types.NamespacedName{Name: binding.Name}, bindingFound)
+	if errors.IsNotFound(err) {
+		r.ReqLogger.Info("Creating a new ClusterRoleBinding", "ClusterRoleBinding.Name", binding.Name)
+		return r.Client.Create(ctx, binding)
+	} else if err != nil {
+		return err
+	}
+	// The role of a binding cannot be changed, only its subjects
+	if !reflect.DeepEqual(bindingFound.Subjects, binding.Subjects) {
+		r.ReqLogger.Info("Updating ClusterRoleBinding", "ClusterRoleBinding.Name", binding.Name)
+		bindingFound.Subjects = binding.Subjects
+		return r.Client.Update(ctx, bindingFound)
+	}
+	return nil
+}
+
+// deleteEventsCollectorBinding deletes the ClusterRoleBinding of the events collector of the CR with the given
+// namespace and name. Being cluster-scoped, the binding is not garbage collected along with the CR.
+func (r *SplunkForwarderReconciler) deleteEventsCollectorBinding(ctx context.Context, namespacedName types.NamespacedName) error {
+	binding := &rbacv1.ClusterRoleBinding{}
+	err := r.Client.Get(ctx, types.NamespacedName{Name: kube.EventsCollectorClusterRoleBindingName(namespacedName)}, binding)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	r.ReqLogger.Info("Deleting the events collector ClusterRoleBinding", "ClusterRoleBinding.Name", binding.Name)
+	err = r.Client.Delete(ctx, binding)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// podToSplunkForwarders maps a pod to the SplunkForwarders with a podLogs input selecting it, so that
+// the monitored workloads follow the pods as they come and go. Only the metadata of the pods is watched.
+func (r *SplunkForwarderReconciler) podToSplunkForwarders(ctx context.Context, obj client.Object) []reconcile.Request {
+	sfList := &sfv1alpha1.SplunkForwarderList{}
+	if err := r.Client.List(ctx, sfList); err != nil {
+		log.Error(err, "Unable to list SplunkForwarders")
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, sf := range sfList.Items {
+		for _, input := range sf.Spec.PodLogs {
+			if input.Namespace != obj.GetNamespace() {
+				continue
+			}
+			selector, err := lookup.PodLogsSelector(input)
+			if err != nil || !selector.Matches(labels.Set(obj.GetLabels())) {
+				continue
+			}
+			requests = append(requests, reconcile.Request{
+				NamespacedName: types.NamespacedName{Name: sf.Name, Namespace: sf.Namespace},
+			})
+			break
+		}
+	}
+	return requests
+}
+
+// podLogsPredicate only lets through the pod events that can change the monitored workloads:
+// pods being created or deleted, and label changes. Status updates are ignored.
+var podLogsPredicate = predicate.Funcs{
+	UpdateFunc: func(e event.UpdateEvent) bool {
+		return !reflect.DeepEqual(e.ObjectOld.GetLabels(), e.ObjectNew.GetLabels())
+	},
+	GenericFunc: func(event.GenericEvent) bool { return false },
+}
+
+// reconcileCertificate requests the forwarder client certificate from cert-manager and copies the
+// issued key pair into the splunk-auth Secret. It returns false while cert-manager has not issued the
+// certificate yet.
+func (r *SplunkForwarderReconciler) reconcileCertificate(ctx context.Context, instance *sfv1alpha1.SplunkForwarder, authSecret *corev1.Secret) (bool, error) {
+	certificate := kube.GenerateCertificate(instance)
+	// Set SplunkForwarder instance as the owner and controller
+	if err := controllerutil.SetControllerReference(instance, certificate, r.Scheme); err != nil {
+		return false, err
+	}
+
+	certFound := &unstructured.Unstructured{}
+	certFound.SetGroupVersionKind(kube.CertificateGVK)
+	err := r.Client.Get(ctx, types.NamespacedName{Name: certificate.GetName(), Namespace: certificate.GetNamespace()}, certFound)
+	if err != nil && errors.IsNotFound(err) {
+		r.ReqLogger.Info("Creating a new Certificate", "Certificate.Namespace", certificate.GetNamespace(), "Certificate.Name", certificate.GetName())
+		err = r.Client.Create(ctx, certificate)
+		if err != nil {
+			return false, err
+		}
+	} else if err != nil {
+		return false, err
+	} else if !reflect.DeepEqual(certFound.Object["spec"], certificate.Object["spec"]) {
+		r.ReqLogger.Info("Updating Certificate", "Certificate.Namespace", certFound.GetNamespace(), "Certificate.Name", certFound.GetName())
+		certFound.Object["spec"] = certificate.Object["spec"]
+		err = r.Client.Update(ctx, certFound)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	tlsSecret := &corev1.Secret{}
+	err = r.Client.Get(ctx, types.NamespacedName{Name: kube.CertificateSecretName(instance), Namespace: instance.Namespace}, tlsSecret)
+	if errors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+
+	trustedCABundle, err := lookup.TrustedCABundle(ctx, r.Client, instance)
+	if err != nil {
+		return false, err
+	}
+	authData, err := kube.GenerateSplunkAuthData(tlsSecret, trustedCABundle)
+	if err != nil {
+		return false, err
+	}
+
+	changed := false
+	if authSecret.Data == nil {
+		authSecret.Data = map[string][]byte{}
+	}
+	for key, value := range authData {
+		if !bytes.Equal(authSecret.Data[key], value) {
+			authSecret.Data[key] = value
+			changed = true
+		}
+	}
+	if changed {
+		r.ReqLogger.Info("Updating the issued certificate in the Secret", "Secret.Namespace", authSecret.Namespace, "Secret.Name", authSecret.Name)
+		err = r.Client.Update(ctx, authSecret)
+		if err != nil {
+			return false, err
+		}
+	}
+
+	return true, nil
+}
+
+// deleteCertificate deletes the Certificate requested for the CR, and the Secret cert-manager issued it to,
+// once the CR no longer references an issuer. Only the Certificate the CR owns, and the Secret labelled for the
+// CR, are deleted. Without cert-manager installed there is no Certificate.
+func (r *SplunkForwarderReconciler) deleteCertificate(ctx context.Context, instance *sfv1alpha1.SplunkForwarder) error {
+	certFound := &unstructured.Unstructured{}
+	certFound.SetGroupVersionKind(kube.CertificateGVK)
+	err := r.Client.Get(ctx, types.NamespacedName{Name: kube.CertificateName(instance), Namespace: instance.Namespace}, certFound)
+	if err == nil && metav1.IsControlledBy(certFound, instance) {
+		r.ReqLogger.Info("Deleting the Certificate", "Certificate.Namespace", certFound.GetNamespace(), "Certificate.Name", certFound.GetName())
+		err = r.Client.Delete(ctx, certFound)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+	} else if err != nil && !errors.IsNotFound(err) && !meta.IsNoMatchError(err) && !runtime.IsNotRegisteredError(err) {
+		return err
+	}
+
+	tlsSecret := &corev1.Secret{}
+	err = r.Client.Get(ctx, types.NamespacedName{Name: kube.CertificateSecretName(instance), Namespace: instance.Namespace}, tlsSecret)
+	if errors.IsNotFound(err) {
+		return nil
+	} else if err != nil {
+		return err
+	}
+	if tlsSecret.Labels[kube.CertificateInstanceLabel] != instance.Name {
+		return nil
+	}
+	r.ReqLogger.Info("Deleting the issued certificate Secret", "Secret.Namespace", tlsSecret.Namespace, "Secret.Name", tlsSecret.Name)
+	err = r.Client.Delete(ctx, tlsSecret)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	return nil
+}
+
+// reconcileTrustedCABundle creates the ConfigMap the trusted CA bundle of the cluster is injected into
+// when the CR trusts it, and deletes it when it does not. The injected data is never updated, only the
+// injection label is restored.
+func (r *SplunkForwarderReconciler) reconcileTrustedCABundle(ctx context.Context, instance *sfv1alpha1.SplunkForwarder) error {
+	name := types.NamespacedName{Name: kube.TrustedCABundleName(instance), Namespace: instance.Namespace}
+	cmFound := &corev1.ConfigMap{}
+	err := r.Client.Get(ctx, name, cmFound)
+	if err != nil && !errors.IsNotFound(err) {
+		return err
+	}
+	found := err == nil
+
+	if !kube.TrustedCABundleEnabled(instance) {
+		if !found {
+			return nil
+		}
+		r.ReqLogger.Info("Deleting the trusted CA bundle ConfigMap", "ConfigMap.Namespace", name.Namespace, "ConfigMap.Name", name.Name)
+		err = r.Client.Delete(ctx, cmFound)
+		if err != nil && !errors.IsNotFound(err) {
+			return err
+		}
+		return nil
+	}
+
+	configMap := kube.GenerateTrustedCABundleConfigMap(instance)
+	// Set SplunkForwarder instance as the owner and controller
+	if err := controllerutil.SetControllerReference(instance, configMap, r.Scheme); err != nil {
+		return err
+	}
+	if !found {
+		r.ReqLogger.Info("Creating a new ConfigMap", "ConfigMap.Namespace", configMap.Namespace, "ConfigMap.Name", configMap.Name)
+		return r.Client.Create(ctx, configMap)
+	}
+	if cmFound.Labels[kube.TrustedCABundleInjectLabel] != "true" {
+		r.ReqLogger.Info("Updating ConfigMap", "ConfigMap.Namespace", cmFound.Namespace, "ConfigMap.Name", cmFound.Name)
+		if cmFound.Labels == nil {
+			cmFound.Labels = map[string]string{}
+		}
+		cmFound.Labels[kube.TrustedCABundleInjectLabel] = "true"
+		return r.Client.Update(ctx, cmFound)
+	}
+	return nil
+}
+
+// appSourceToSplunkForwarders maps a ConfigMap or Secret to the SplunkForwarders with an extra app
+// taken from it, so that the forwarders are rolled when the app changes.
+func (r *SplunkForwarderReconciler) appSourceToSplunkForwarders(ctx context.Context, obj client.Object) []reconcile.Request {
+	sfList := &sfv1alpha1.SplunkForwarderList{}
+	if err := r.Client.List(ctx, sfList, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.Error(err, "Unable to list SplunkForwarders")
+		return nil
+	}
+	_, isSecret := obj.(*corev1.Secret)
+	requests := []reconcile.Request{}
+	for _, sf := range sfList.Items {
+		for _, app := range sf.Spec.Apps {
+			if (isSecret && app.Secret == obj.GetName()) || (!isSecret && app.ConfigMap == obj.GetName()) {
+				requests = append(requests, reconcile.Request{
+					NamespacedName: types.NamespacedName{Name: sf.Name, Namespace: sf.Namespace},
+				})
+				break
+			}
+		}
+	}
+	return requests
+}
+
+// certificateSecretToSplunkForwarder maps a Secret issued by cert-manager to the SplunkForwarder
+// that requested it.
+func certificateSecretToSplunkForwarder(ctx context.Context, obj client.Object) []reconcile.Request {
+	name, ok := obj.GetLabels()[kube.CertificateInstanceLabel]
+	if !ok {
+		return nil
+	}
+	return []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Name: name, Namespace: obj.GetNamespace()}},
+	}
+}
+
+// authSecretToSplunkForwarders maps the splunk-auth and splunk-hec-token Secrets to the SplunkForwarders of
+// their namespace, so that the forwarders switch between the mTLS and HEC modes and roll with new credentials,
+// and a configuration held back by the FIPS check is rolled out once splunk-auth is fixed.
+func (r *SplunkForwarderReconciler) authSecretToSplunkForwarders(ctx context.Context, obj client.Object) []reconcile.Request {
+	if obj.GetName() != config.SplunkAuthSecretName && obj.GetName() != config.SplunkHECTokenSecretName {
+		return nil
+	}
+	sfList := &sfv1alpha1.SplunkForwarderList{}
+	if err := r.Client.List(ctx, sfList, client.InNamespace(obj.GetNamespace())); err != nil {
+		log.Error(err, "Unable to list SplunkForwarders")
+		return nil
+	}
+	requests := []reconcile.Request{}
+	for _, sf := range sfList.Items {
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{Name: sf.Name, Namespace: sf.Namespace},
+		})
+	}
+	return requests
+}
+
+// secretToSplunkForwarders maps a Secret to the SplunkForwarder cert-manager issued it for, to the
+// SplunkForwarders using it as splunk-auth, and to the SplunkForwarders with an extra app taken from it.
+func (r *SplunkForwarderReconciler) secretToSplunkForwarders(ctx context.Context, obj client.Object) []reconcile.Request {
+	requests := append(certificateSecretToSplunkForwarder(ctx, obj), r.authSecretToSplunkForwarders(ctx, obj)...)
+	return append(requests, r.appSourceToSplunkForwarders(ctx, obj)...)
+}
+
+// dependencyPredicate only lets through the events of the ConfigMaps and Secrets mapped to a SplunkForwarder,
+// and of their updates the ones that change the data, so that the watches of every ConfigMap and Secret of
+// the cluster do not reconcile on unrelated changes.
+func dependencyPredicate(mapFunc handler.MapFunc) predicate.Predicate {
+	return predicate.And(
+		predicate.NewPredicateFuncs(func(obj client.Object) bool {
+			return len(mapFunc(context.TODO(), obj)) > 0
+		}),
+		predicate.Funcs{
+			UpdateFunc: func(e event.UpdateEvent) bool {
+				return !reflect.DeepEqual(objectData(e.ObjectOld), objectData(e.ObjectNew))
+			},
+		},
+	)
+}
+
+// objectData returns the data of a ConfigMap or Secret
+func objectData(obj client.Object) interface{} {
+	switch o := obj.(type) {
+	case *corev1.Secret:
+		return o.Data
+	case *corev1.ConfigMap:
+		return []interface{}{o.Data, o.BinaryData}
+	}
+	return nil
 }
 
 // SetupWithManager sets up the controller with the Manager.
 func (r *SplunkForwarderReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	b := ctrl.NewControllerManagedBy(mgr).
 		For(&sfv1alpha1.SplunkForwarder{}).
 		Owns(&corev1.ConfigMap{}).
 		Owns(&appsv1.DaemonSet{}).
+		Owns(&appsv1.Deployment{}).
 		Owns(&corev1.Service{}).
+		Owns(&corev1.ServiceAccount{})
+
+	// The Certificates are only watched when cert-manager is installed at startup. Without its CRD, the CRs
+	// referencing an issuer fail to create their Certificate until the operator is restarted.
+	_, err := mgr.GetRESTMapper().RESTMapping(kube.CertificateGVK.GroupKind(), kube.CertificateGVK.Version)
+	if err == nil {
+		certificate := &unstructured.Unstructured{}
+		certificate.SetGroupVersionKind(kube.CertificateGVK)
+		b = b.Owns(certificate, builder.WithPredicates(predicate.GenerationChangedPredicate{}))
+	} else if !meta.IsNoMatchError(err) {
+		return err
+	}
+
+	return b.
+		Watches(&corev1.Secret{}, handler.EnqueueRequestsFromMapFunc(r.secretToSplunkForwarders), builder.WithPredicates(dependencyPredicate(r.secretToSplunkForwarders))).
+		Watches(&corev1.ConfigMap{}, handler.EnqueueRequestsFromMapFunc(r.appSourceToSplunkForwarders), builder.WithPredicates(dependencyPredicate(r.appSourceToSplunkForwarders))).
+		Watches(&configv1.Infrastructure{}, handler.EnqueueRequestsFromMapFunc(r.clusterConfigToSplunkForwarders)).
+		Watches(&configv1.ClusterVersion{}, handler.EnqueueRequestsFromMapFunc(r.clusterConfigToSplunkForwarders), builder.WithPredicates(clusterVersionPredicate)).
+		Watches(&configv1.Proxy{}, handler.EnqueueRequestsFromMapFunc(r.clusterConfigToSplunkForwarders)).
+		Watches(&configv1.APIServer{}, handler.EnqueueRequestsFromMapFunc(r.clusterConfigToSplunkForwarders)).
+		Watches(&configv1.ImageDigestMirrorSet{}, handler.EnqueueRequestsFromMapFunc(r.clusterConfigToSplunkForwarders)).
+		Watches(&operatorv1alpha1.ImageContentSourcePolicy{}, handler.EnqueueRequestsFromMapFunc(r.clusterConfigToSplunkForwarders)).
+		Watches(&corev1.Pod{}, handler.EnqueueRequestsFromMapFunc(r.podToSplunkForwarders), builder.WithPredicates(podLogsPredicate), builder.OnlyMetadata).
+		Watches(&corev1.Node{}, handler.EnqueueRequestsFromMapFunc(r.clusterConfigToSplunkForwarders), builder.OnlyMetadata, builder.WithPredicates(nodeRolesPredicate)).
 		Complete(r)
 }
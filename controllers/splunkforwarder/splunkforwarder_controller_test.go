@@ -2,19 +2,32 @@ package splunkforwarder
 
 import (
 	"context"
+	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 	"time"
 
 	configv1 "github.com/openshift/api/config/v1"
+	operatorv1alpha1 "github.com/openshift/api/operator/v1alpha1"
 	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
 	"github.com/openshift/splunk-forwarder-operator/config"
+	"github.com/openshift/splunk-forwarder-operator/pkg/kube"
+	"github.com/openshift/splunk-forwarder-operator/pkg/registry"
+	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 	fakekubeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+	"sigs.k8s.io/controller-runtime/pkg/client/interceptor"
+	"sigs.k8s.io/controller-runtime/pkg/event"
 	"sigs.k8s.io/controller-runtime/pkg/reconcile"
 )
 
@@ -90,6 +103,42 @@ func testSplunkForwarderService() *corev1.Service {
 	return ret
 }
 
+// newTestReconciler returns a reconciler with a fake client holding the objects, and the fake client to
+// check what the reconciler did. The status of the CR and of the DaemonSets is a subresource, like in
+// the cluster.
+func newTestReconciler(t *testing.T, objs ...runtime.Object) (*SplunkForwarderReconciler, client.WithWatch) {
+	t.Helper()
+	return newTestReconcilerWithInterceptor(t, interceptor.Funcs{}, objs...)
+}
+
+// newTestReconcilerWithInterceptor is newTestReconciler with a fake client calling the interceptor functions
+func newTestReconcilerWithInterceptor(t *testing.T, funcs interceptor.Funcs, objs ...runtime.Object) (*SplunkForwarderReconciler, client.WithWatch) {
+	t.Helper()
+	for _, addToScheme := range []func(*runtime.Scheme) error{sfv1alpha1.AddToScheme, configv1.AddToScheme, operatorv1alpha1.AddToScheme} {
+		if err := addToScheme(scheme.Scheme); err != nil {
+			t.Fatalf("unable to add scheme: %v", err)
+		}
+	}
+	fakeClient := fakekubeclient.NewClientBuilder().WithScheme(scheme.Scheme).
+		WithStatusSubresource(&sfv1alpha1.SplunkForwarder{}, &appsv1.DaemonSet{}).
+		WithRuntimeObjects(objs...).WithInterceptorFuncs(funcs).Build()
+	return &SplunkForwarderReconciler{Client: fakeClient, Scheme: scheme.Scheme, ReqLogger: log.WithValues(), OperatorImage: "operator-image"}, fakeClient
+}
+
+// reconcileUntilDone reconciles the request until it is no longer requeued at once
+func reconcileUntilDone(t *testing.T, r *SplunkForwarderReconciler, request reconcile.Request) {
+	t.Helper()
+	for i := 0; i < 5; i++ {
+		result, err := r.Reconcile(context.TODO(), request)
+		if err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+		if !result.Requeue {
+			return
+		}
+	}
+}
+
 func TestReconcileSplunkForwarder_Reconcile(t *testing.T) {
 	if err := sfv1alpha1.AddToScheme(scheme.Scheme); err != nil {
 		t.Errorf("ReconcileSplunkForwarder.Reconcile() error = %v", err)
@@ -99,6 +148,10 @@ func TestReconcileSplunkForwarder_Reconcile(t *testing.T) {
 		t.Errorf("ReconcileSplunkForwarder.Reconcile() error = %v", err)
 		return
 	}
+	if err := operatorv1alpha1.AddToScheme(scheme.Scheme); err != nil {
+		t.Errorf("ReconcileSplunkForwarder.Reconcile() error = %v", err)
+		return
+	}
 	type args struct {
 		request reconcile.Request
 	}
@@ -152,6 +205,7 @@ func TestReconcileSplunkForwarder_Reconcile(t *testing.T) {
 				testSplunkForwarderCR(),
 				testSplunkForwarderService(),
 				testSplunkForwarderSecret(),
+				testInfrastructure(),
 			},
 		},
 		{
@@ -172,17 +226,19 @@ func TestReconcileSplunkForwarder_Reconcile(t *testing.T) {
 				testSplunkForwarderCR(),
 				testSplunkForwarderService(),
 				testSplunkForwarderSecret(),
+				testInfrastructure(),
 				testSplunkHECSecret(),
 			},
 		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			fakeClient := fakekubeclient.NewClientBuilder().WithScheme(scheme.Scheme).WithRuntimeObjects(tt.localObjects...).Build()
+			fakeClient := fakekubeclient.NewClientBuilder().WithScheme(scheme.Scheme).WithStatusSubresource(&sfv1alpha1.SplunkForwarder{}).WithRuntimeObjects(tt.localObjects...).Build()
 			r := &SplunkForwarderReconciler{
-				Client:    fakeClient,
-				Scheme:    scheme.Scheme,
-				ReqLogger: log.WithValues(),
+				Client:        fakeClient,
+				Scheme:        scheme.Scheme,
+				ReqLogger:     log.WithValues(),
+				OperatorImage: "operator-image",
 			}
 			got, err := r.Reconcile(context.TODO(), tt.args.request)
 			if (err != nil) != tt.wantErr {
@@ -195,3 +251,1544 @@ func TestReconcileSplunkForwarder_Reconcile(t *testing.T) {
 		})
 	}
 }
+
+// testCertificateTLSSecret returns the Secret cert-manager writes for an issued certificate.
+func testCertificateTLSSecret() *corev1.Secret {
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      instanceName + "-forwarder-tls",
+			Namespace: instanceNamespace,
+			Labels: map[string]string{
+				kube.CertificateInstanceLabel: instanceName,
+			},
+		},
+		Data: map[string][]byte{
+			"tls.crt": []byte("CERT\n"),
+			"tls.key": []byte("KEY\n"),
+			"ca.crt":  []byte("CA\n"),
+		},
+	}
+}
+
+func TestReconcileSplunkForwarder_Certificate(t *testing.T) {
+	// Stand-in for the cert-manager CRD, no issuer is running
+	scheme.Scheme.AddKnownTypeWithName(kube.CertificateGVK, &unstructured.Unstructured{})
+
+	cr := func() *sfv1alpha1.SplunkForwarder {
+		cr := testSplunkForwarderCR()
+		cr.Spec.CertificateIssuerRef = &sfv1alpha1.CertificateIssuerReference{Name: "splunk-ca"}
+		return cr
+	}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: instanceName, Namespace: instanceNamespace}}
+
+	t.Run("Certificate not issued yet", func(t *testing.T) {
+		r, fakeClient := newTestReconciler(t, cr(), testSplunkForwarderSecret())
+
+		got, err := r.Reconcile(context.TODO(), request)
+		if err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+		if !reflect.DeepEqual(got, reconcile.Result{}) {
+			t.Errorf("Reconcile() = %v, want %v", got, reconcile.Result{})
+		}
+
+		cert := &unstructured.Unstructured{}
+		cert.SetGroupVersionKind(kube.CertificateGVK)
+		if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: instanceName + "-forwarder", Namespace: instanceNamespace}, cert); err != nil {
+			t.Fatalf("Certificate was not created: %v", err)
+		}
+		ds := &appsv1.DaemonSet{}
+		if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: instanceName + "-ds", Namespace: instanceNamespace}, ds); err == nil {
+			t.Errorf("DaemonSet was created before the certificate was issued")
+		}
+	})
+
+	t.Run("Certificate issued and renewed", func(t *testing.T) {
+		tlsSecret := testCertificateTLSSecret()
+		r, fakeClient := newTestReconciler(t, cr(), testSplunkForwarderSecret(), tlsSecret, testInfrastructure())
+
+		if _, err := r.Reconcile(context.TODO(), request); err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+
+		authSecret := &corev1.Secret{}
+		if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: config.SplunkAuthSecretName, Namespace: instanceNamespace}, authSecret); err != nil {
+			t.Fatalf("unable to get splunk-auth: %v", err)
+		}
+		if string(authSecret.Data["server.pem"]) != "CERT\nKEY\n" || string(authSecret.Data["cacert.pem"]) != "CA\n" {
+			t.Errorf("splunk-auth was not assembled from the issued certificate: %q", authSecret.Data)
+		}
+		ds := &appsv1.DaemonSet{}
+		if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: instanceName + "-ds", Namespace: instanceNamespace}, ds); err != nil {
+			t.Fatalf("DaemonSet was not created: %v", err)
+		}
+		firstHash := ds.Spec.Template.Annotations[kube.CertificateHashAnnotation]
+		if firstHash == "" {
+			t.Fatalf("DaemonSet pod template is missing the certificate hash")
+		}
+
+		// Renewal: cert-manager rewrites the issued Secret
+		tlsSecret = testCertificateTLSSecret()
+		if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: tlsSecret.Name, Namespace: instanceNamespace}, tlsSecret); err != nil {
+			t.Fatalf("unable to get issued Secret: %v", err)
+		}
+		tlsSecret.Data["tls.crt"] = []byte("RENEWED\n")
+		if err := fakeClient.Update(context.TODO(), tlsSecret); err != nil {
+			t.Fatalf("unable to renew issued Secret: %v", err)
+		}
+		if _, err := r.Reconcile(context.TODO(), request); err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+
+		if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: instanceName + "-ds", Namespace: instanceNamespace}, ds); err != nil {
+			t.Fatalf("DaemonSet was deleted on renewal: %v", err)
+		}
+		if hash := ds.Spec.Template.Annotations[kube.CertificateHashAnnotation]; hash == "" || hash == firstHash {
+			t.Errorf("DaemonSet pod template was not rolled for the renewed certificate")
+		}
+	})
+
+	t.Run("Issuer without a CA", func(t *testing.T) {
+		instance := cr()
+		instance.Spec.TrustedCABundle = true
+		tlsSecret := testCertificateTLSSecret()
+		delete(tlsSecret.Data, "ca.crt")
+		bundle := &corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: kube.TrustedCABundleName(instance), Namespace: instanceNamespace},
+			Data:       map[string]string{kube.TrustedCABundleKey: "BUNDLE\n"},
+		}
+		r, fakeClient := newTestReconciler(t, instance, testSplunkForwarderSecret(), tlsSecret, bundle, testInfrastructure())
+
+		if _, err := r.Reconcile(context.TODO(), request); err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+		authSecret := &corev1.Secret{}
+		if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: config.SplunkAuthSecretName, Namespace: instanceNamespace}, authSecret); err != nil {
+			t.Fatalf("unable to get splunk-auth: %v", err)
+		}
+		if string(authSecret.Data["cacert.pem"]) != "BUNDLE\n" {
+			t.Errorf("cacert.pem = %q, want the trusted CA bundle", authSecret.Data["cacert.pem"])
+		}
+	})
+
+	t.Run("Issuer reference removed", func(t *testing.T) {
+		r, fakeClient := newTestReconciler(t, cr(), testSplunkForwarderSecret(), testCertificateTLSSecret(), testInfrastructure())
+		if _, err := r.Reconcile(context.TODO(), request); err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+
+		instance := &sfv1alpha1.SplunkForwarder{}
+		if err := fakeClient.Get(context.TODO(), request.NamespacedName, instance); err != nil {
+			t.Fatalf("unable to get SplunkForwarder: %v", err)
+		}
+		instance.Spec.CertificateIssuerRef = nil
+		if err := fakeClient.Update(context.TODO(), instance); err != nil {
+			t.Fatalf("unable to update SplunkForwarder: %v", err)
+		}
+		reconcileUntilDone(t, r, request)
+
+		cert := &unstructured.Unstructured{}
+		cert.SetGroupVersionKind(kube.CertificateGVK)
+		if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: instanceName + "-forwarder", Namespace: instanceNamespace}, cert); !errors.IsNotFound(err) {
+			t.Errorf("Certificate was not deleted: %v", err)
+		}
+		if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: instanceName + "-forwarder-tls", Namespace: instanceNamespace}, &corev1.Secret{}); !errors.IsNotFound(err) {
+			t.Errorf("issued Secret was not deleted: %v", err)
+		}
+	})
+}
+
+func testInfrastructure() *configv1.Infrastructure {
+	return &configv1.Infrastructure{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "cluster",
+		},
+		Status: configv1.InfrastructureStatus{
+			InfrastructureName: "mycluster-x7k2p",
+			Platform:           configv1.AWSPlatformType,
+			PlatformStatus: &configv1.PlatformStatus{
+				Type: configv1.AWSPlatformType,
+				AWS: &configv1.AWSPlatformStatus{
+					Region: "us-east-1",
+				},
+			},
+		},
+	}
+}
+
+func testClusterVersion() *configv1.ClusterVersion {
+	return &configv1.ClusterVersion{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "version",
+		},
+		Spec: configv1.ClusterVersionSpec{
+			ClusterID: "5c5a7a7c-8a2b-4d5e-9b41-0f4a1f2b3c4d",
+		},
+		Status: configv1.ClusterVersionStatus{
+			Desired: configv1.Release{
+				Version: "4.16.3",
+			},
+		},
+	}
+}
+
+func TestReconcileSplunkForwarder_MetadataFields(t *testing.T) {
+	cr := testSplunkForwarderCR()
+	cr.Spec.MetadataFields = &sfv1alpha1.SplunkMetadataFields{
+		ClusterVersion: true,
+		ClusterUUID:    true,
+		Platform:       true,
+		Region:         true,
+	}
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: instanceName, Namespace: instanceNamespace}}
+
+	tests := []struct {
+		name         string
+		localObjects []runtime.Object
+		wantMeta     string
+		wantReason   string
+	}{
+		{
+			name:         "Metadata looked up from the cluster configuration",
+			localObjects: []runtime.Object{cr.DeepCopy(), testSplunkForwarderSecret(), testInfrastructure(), testClusterVersion()},
+			wantMeta:     "_meta = clusterid::mycluster-x7k2p clusterversion::4.16.3 clusteruuid::5c5a7a7c-8a2b-4d5e-9b41-0f4a1f2b3c4d platform::AWS region::us-east-1\n",
+			wantReason:   "Resolved",
+		},
+		{
+			name:         "ClusterVersion missing",
+			localObjects: []runtime.Object{cr.DeepCopy(), testSplunkForwarderSecret(), testInfrastructure()},
+			wantMeta:     "_meta = clusterid::mycluster-x7k2p platform::AWS region::us-east-1\n",
+			wantReason:   "ClusterVersionUnavailable",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r, fakeClient := newTestReconciler(t, tt.localObjects...)
+
+			if _, err := r.Reconcile(context.TODO(), request); err != nil {
+				t.Fatalf("Reconcile() error = %v", err)
+			}
+			cm := &corev1.ConfigMap{}
+			if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: "osd-monitored-logs-local", Namespace: instanceNamespace}, cm); err != nil {
+				t.Fatalf("unable to get inputs ConfigMap: %v", err)
+			}
+			if !strings.Contains(cm.Data["inputs.conf"], tt.wantMeta) {
+				t.Errorf("inputs.conf = %q, want it to contain %q", cm.Data["inputs.conf"], tt.wantMeta)
+			}
+			instance := &sfv1alpha1.SplunkForwarder{}
+			if err := fakeClient.Get(context.TODO(), request.NamespacedName, instance); err != nil {
+				t.Fatalf("unable to get SplunkForwarder: %v", err)
+			}
+			condition := meta.FindStatusCondition(instance.Status.Conditions, sfv1alpha1.ConditionClusterMetadataResolved)
+			if condition == nil || condition.Reason != tt.wantReason {
+				t.Errorf("%s condition = %v, want reason %s", sfv1alpha1.ConditionClusterMetadataResolved, condition, tt.wantReason)
+			}
+		})
+	}
+
+	// Only a change of the fields taken from the ClusterVersion reconciles the CRs
+	channelChanged := testClusterVersion()
+	channelChanged.Spec.Channel = "stable-4.17"
+	if clusterVersionPredicate.Update(event.UpdateEvent{ObjectOld: testClusterVersion(), ObjectNew: channelChanged}) {
+		t.Errorf("clusterVersionPredicate let through a channel change")
+	}
+	upgraded := testClusterVersion()
+	upgraded.Status.Desired.Version = "4.16.4"
+	if !clusterVersionPredicate.Update(event.UpdateEvent{ObjectOld: testClusterVersion(), ObjectNew: upgraded}) {
+		t.Errorf("clusterVersionPredicate filtered out a version change")
+	}
+}
+
+func TestReconcileSplunkForwarder_NodeRoles(t *testing.T) {
+	cr := testSplunkForwarderCR()
+	cr.Spec.ClusterID = "test"
+	cr.Spec.SplunkInputs = []sfv1alpha1.SplunkForwarderInputs{
+		{Path: "/var/log/openshift-apiserver/audit.log", NodeRoles: []string{"master"}},
+		{Path: "/var/log/test"},
+	}
+	r, fakeClient := newTestReconciler(t, cr, testSplunkForwarderSecret())
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: instanceName, Namespace: instanceNamespace}}
+
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	for _, name := range []string{instanceName + "-ds", instanceName + "-ds-master"} {
+		if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: name, Namespace: instanceNamespace}, &appsv1.DaemonSet{}); err != nil {
+			t.Errorf("DaemonSet %s was not created: %v", name, err)
+		}
+	}
+	cm := &corev1.ConfigMap{}
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: "osd-monitored-logs-local-master", Namespace: instanceNamespace}, cm); err != nil {
+		t.Fatalf("unable to get master inputs ConfigMap: %v", err)
+	}
+	if !strings.Contains(cm.Data["inputs.conf"], "_meta = clusterid::test noderole::master\n") {
+		t.Errorf("master inputs.conf = %q, want the node role in _meta", cm.Data["inputs.conf"])
+	}
+
+	// Dropping the role from the inputs removes the forwarders of the role
+	if err := fakeClient.Get(context.TODO(), request.NamespacedName, cr); err != nil {
+		t.Fatalf("unable to get SplunkForwarder: %v", err)
+	}
+	cr.Spec.SplunkInputs[0].NodeRoles = nil
+	if err := fakeClient.Update(context.TODO(), cr); err != nil {
+		t.Fatalf("unable to update SplunkForwarder: %v", err)
+	}
+	reconcileUntilDone(t, r, request)
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: instanceName + "-ds-master", Namespace: instanceNamespace}, &appsv1.DaemonSet{}); err == nil {
+		t.Errorf("DaemonSet of the unused node role was not deleted")
+	}
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: "osd-monitored-logs-local-master", Namespace: instanceNamespace}, &corev1.ConfigMap{}); err == nil {
+		t.Errorf("ConfigMap of the unused node role was not deleted")
+	}
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: "osd-monitored-logs-metadata", Namespace: instanceNamespace}, &corev1.ConfigMap{}); err != nil {
+		t.Errorf("ConfigMap without a node role was deleted: %v", err)
+	}
+}
+
+func TestReconcileSplunkForwarder_MultiRoleNodes(t *testing.T) {
+	cr := testSplunkForwarderCR()
+	cr.Spec.ClusterID = "test"
+	cr.Spec.SplunkInputs = []sfv1alpha1.SplunkForwarderInputs{
+		{Path: "/var/log/openshift-apiserver/audit.log", NodeRoles: []string{"master"}},
+		{Path: "/var/log/containers", NodeRoles: []string{"infra"}},
+	}
+	node := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: "node-1",
+			Labels: map[string]string{
+				"node-role.kubernetes.io/master": "",
+				"node-role.kubernetes.io/infra":  "",
+			},
+		},
+	}
+	r, fakeClient := newTestReconciler(t, cr, testSplunkForwarderSecret(), node)
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: instanceName, Namespace: instanceNamespace}}
+	key := kube.NodeRoleSetKeys(cr, [][]string{{"infra", "master"}})[2]
+
+	reconcileUntilDone(t, r, request)
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: instanceName + "-ds-" + key, Namespace: instanceNamespace}, &appsv1.DaemonSet{}); err != nil {
+		t.Fatalf("DaemonSet of the nodes with both roles was not created: %v", err)
+	}
+	cm := &corev1.ConfigMap{}
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: "osd-monitored-logs-local-" + key, Namespace: instanceNamespace}, cm); err != nil {
+		t.Fatalf("ConfigMap of the nodes with both roles was not created: %v", err)
+	}
+	for _, path := range []string{"/var/log/openshift-apiserver/audit.log", "/var/log/containers"} {
+		if !strings.Contains(cm.Data["inputs.conf"], "[monitor://"+path+"]") {
+			t.Errorf("inputs.conf of the nodes with both roles = %q, want %s", cm.Data["inputs.conf"], path)
+		}
+	}
+
+	// Once no node has both roles, their forwarders are removed
+	oldNode := node.DeepCopy()
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: node.Name}, node); err != nil {
+		t.Fatalf("unable to get Node: %v", err)
+	}
+	delete(node.Labels, "node-role.kubernetes.io/infra")
+	if !nodeRolesPredicate.Update(event.UpdateEvent{ObjectOld: oldNode, ObjectNew: node}) {
+		t.Errorf("nodeRolesPredicate filtered out a role change")
+	}
+	if err := fakeClient.Update(context.TODO(), node); err != nil {
+		t.Fatalf("unable to update Node: %v", err)
+	}
+	reconcileUntilDone(t, r, request)
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: instanceName + "-ds-" + key, Namespace: instanceNamespace}, &appsv1.DaemonSet{}); err == nil {
+		t.Errorf("DaemonSet of a role combination no node has was not deleted")
+	}
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: instanceName + "-ds-infra", Namespace: instanceNamespace}, &appsv1.DaemonSet{}); err != nil {
+		t.Errorf("DaemonSet of a single role was deleted: %v", err)
+	}
+}
+
+func TestReconcileSplunkForwarder_InfrastructureUnavailable(t *testing.T) {
+	r, fakeClient := newTestReconciler(t, testSplunkForwarderCR(), testSplunkForwarderSecret())
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: instanceName, Namespace: instanceNamespace}}
+
+	got, err := r.Reconcile(context.TODO(), request)
+	if err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if got.RequeueAfter == 0 {
+		t.Errorf("Reconcile() = %v, want a requeue while the Infrastructure resource is unavailable", got)
+	}
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: "osd-monitored-logs-local", Namespace: instanceNamespace}, &corev1.ConfigMap{}); err == nil {
+		t.Errorf("inputs ConfigMap was rendered without a cluster ID")
+	}
+	instance := &sfv1alpha1.SplunkForwarder{}
+	if err := fakeClient.Get(context.TODO(), request.NamespacedName, instance); err != nil {
+		t.Fatalf("unable to get SplunkForwarder: %v", err)
+	}
+	if !meta.IsStatusConditionFalse(instance.Status.Conditions, sfv1alpha1.ConditionClusterMetadataResolved) {
+		t.Errorf("conditions = %v, want %s to be False", instance.Status.Conditions, sfv1alpha1.ConditionClusterMetadataResolved)
+	}
+
+	// The Infrastructure resource becomes available
+	if err := fakeClient.Create(context.TODO(), testInfrastructure()); err != nil {
+		t.Fatalf("unable to create Infrastructure: %v", err)
+	}
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	cm := &corev1.ConfigMap{}
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: "osd-monitored-logs-local", Namespace: instanceNamespace}, cm); err != nil {
+		t.Fatalf("unable to get inputs ConfigMap: %v", err)
+	}
+	if !strings.Contains(cm.Data["inputs.conf"], "_meta = clusterid::mycluster-x7k2p\n") {
+		t.Errorf("inputs.conf = %q, want the infrastructure name as cluster ID", cm.Data["inputs.conf"])
+	}
+	if err := fakeClient.Get(context.TODO(), request.NamespacedName, instance); err != nil {
+		t.Fatalf("unable to get SplunkForwarder: %v", err)
+	}
+	if !meta.IsStatusConditionTrue(instance.Status.Conditions, sfv1alpha1.ConditionClusterMetadataResolved) {
+		t.Errorf("conditions = %v, want %s to be True", instance.Status.Conditions, sfv1alpha1.ConditionClusterMetadataResolved)
+	}
+}
+
+func TestReconcileSplunkForwarder_ConfigReload(t *testing.T) {
+	cr := testSplunkForwarderCR()
+	cr.Spec.ClusterID = "test"
+	cr.Generation = 1
+	daemonSetDeletes := 0
+	r, fakeClient := newTestReconcilerWithInterceptor(t, interceptor.Funcs{
+		Delete: func(ctx context.Context, c client.WithWatch, obj client.Object, opts ...client.DeleteOption) error {
+			if _, ok := obj.(*appsv1.DaemonSet); ok {
+				daemonSetDeletes++
+			}
+			return c.Delete(ctx, obj, opts...)
+		},
+	}, cr, testSplunkForwarderSecret())
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: instanceName, Namespace: instanceNamespace}}
+
+	getDaemonSet := func() *appsv1.DaemonSet {
+		t.Helper()
+		ds := &appsv1.DaemonSet{}
+		if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: instanceName + "-ds", Namespace: instanceNamespace}, ds); err != nil {
+			t.Fatalf("unable to get DaemonSet: %v", err)
+		}
+		return ds
+	}
+	updateCR := func(update func(*sfv1alpha1.SplunkForwarder)) {
+		t.Helper()
+		instance := &sfv1alpha1.SplunkForwarder{}
+		if err := fakeClient.Get(context.TODO(), request.NamespacedName, instance); err != nil {
+			t.Fatalf("unable to get SplunkForwarder: %v", err)
+		}
+		update(instance)
+		instance.Generation++
+		if err := fakeClient.Update(context.TODO(), instance); err != nil {
+			t.Fatalf("unable to update SplunkForwarder: %v", err)
+		}
+	}
+
+	reconcileUntilDone(t, r, request)
+	ds := getDaemonSet()
+	adminSecret := &corev1.Secret{}
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: instanceName + "-admin", Namespace: instanceNamespace}, adminSecret); err != nil {
+		t.Fatalf("admin Secret was not created: %v", err)
+	}
+
+	// An inputs change updates the ConfigMap and keeps the pods
+	updateCR(func(instance *sfv1alpha1.SplunkForwarder) {
+		instance.Spec.SplunkInputs = append(instance.Spec.SplunkInputs, sfv1alpha1.SplunkForwarderInputs{Path: "/var/log/other"})
+	})
+	reconcileUntilDone(t, r, request)
+	cm := &corev1.ConfigMap{}
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: "osd-monitored-logs-local", Namespace: instanceNamespace}, cm); err != nil {
+		t.Fatalf("unable to get inputs ConfigMap: %v", err)
+	}
+	if !strings.Contains(cm.Data["inputs.conf"], "[monitor:///var/log/other]") {
+		t.Errorf("inputs.conf = %q, want the new input", cm.Data["inputs.conf"])
+	}
+	afterInputs := getDaemonSet()
+	if daemonSetDeletes != 0 {
+		t.Errorf("DaemonSet was recreated for an inputs change")
+	}
+	if !reflect.DeepEqual(afterInputs.Spec.Template, ds.Spec.Template) {
+		t.Errorf("DaemonSet pod template changed for an inputs change")
+	}
+	if afterInputs.Annotations["genVersion"] != "2" {
+		t.Errorf("DaemonSet genVersion = %q, want \"2\"", afterInputs.Annotations["genVersion"])
+	}
+
+	// An image change rolls the pods in place
+	updateCR(func(instance *sfv1alpha1.SplunkForwarder) {
+		instance.Spec.ImageTag = "0.0.2"
+	})
+	reconcileUntilDone(t, r, request)
+	afterImage := getDaemonSet()
+	if daemonSetDeletes != 0 {
+		t.Errorf("DaemonSet was recreated for an image change")
+	}
+	if afterImage.Spec.Template.Spec.Containers[0].Image != image+":0.0.2" {
+		t.Errorf("DaemonSet image = %s, want %s", afterImage.Spec.Template.Spec.Containers[0].Image, image+":0.0.2")
+	}
+
+	// The admin credentials are kept
+	secretAfter := &corev1.Secret{}
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: instanceName + "-admin", Namespace: instanceNamespace}, secretAfter); err != nil {
+		t.Fatalf("unable to get admin Secret: %v", err)
+	}
+	if !reflect.DeepEqual(secretAfter.Data, adminSecret.Data) {
+		t.Errorf("admin Secret was regenerated")
+	}
+
+	// Without the operator image there is no sidecar, and an inputs change rolls the pods
+	r.OperatorImage = ""
+	reconcileUntilDone(t, r, request)
+	withoutSidecar := getDaemonSet()
+	for _, container := range withoutSidecar.Spec.Template.Spec.Containers {
+		if container.Name == "config-reload" {
+			t.Errorf("DaemonSet runs the config-reload sidecar without the operator image")
+		}
+	}
+	updateCR(func(instance *sfv1alpha1.SplunkForwarder) {
+		instance.Spec.SplunkInputs = append(instance.Spec.SplunkInputs, sfv1alpha1.SplunkForwarderInputs{Path: "/var/log/third"})
+	})
+	reconcileUntilDone(t, r, request)
+	if getDaemonSet().Spec.Template.Annotations[kube.ConfigHashAnnotation] == withoutSidecar.Spec.Template.Annotations[kube.ConfigHashAnnotation] {
+		t.Errorf("DaemonSet pod template did not change for an inputs change without the sidecar")
+	}
+}
+
+func TestReconcileSplunkForwarder_Status(t *testing.T) {
+	cr := testSplunkForwarderCR()
+	cr.Spec.ClusterID = "test"
+	r, fakeClient := newTestReconciler(t, cr, testSplunkForwarderSecret())
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: instanceName, Namespace: instanceNamespace}}
+
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	ds := &appsv1.DaemonSet{}
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: instanceName + "-ds", Namespace: instanceNamespace}, ds); err != nil {
+		t.Fatalf("unable to get DaemonSet: %v", err)
+	}
+	ds.Status.DesiredNumberScheduled = 3
+	ds.Status.NumberReady = 2
+	if err := fakeClient.Status().Update(context.TODO(), ds); err != nil {
+		t.Fatalf("unable to update DaemonSet status: %v", err)
+	}
+
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+
+	instance := &sfv1alpha1.SplunkForwarder{}
+	if err := fakeClient.Get(context.TODO(), request.NamespacedName, instance); err != nil {
+		t.Fatalf("unable to get SplunkForwarder: %v", err)
+	}
+	if instance.Status.ObservedGeneration != cr.Generation {
+		t.Errorf("ObservedGeneration = %d, want %d", instance.Status.ObservedGeneration, cr.Generation)
+	}
+	if instance.Status.DesiredNumberScheduled != 3 || instance.Status.NumberReady != 2 {
+		t.Errorf("DesiredNumberScheduled = %d, NumberReady = %d, want 3 and 2", instance.Status.DesiredNumberScheduled, instance.Status.NumberReady)
+	}
+	if instance.Status.DeliveryMode != sfv1alpha1.DeliveryModeBestEffort {
+		t.Errorf("DeliveryMode = %q, want %q", instance.Status.DeliveryMode, sfv1alpha1.DeliveryModeBestEffort)
+	}
+
+	// Enabling reliable delivery is reported in the status
+	instance.Spec.ReliableDelivery = &sfv1alpha1.SplunkReliableDelivery{Enabled: true}
+	if err := fakeClient.Update(context.TODO(), instance); err != nil {
+		t.Fatalf("unable to update SplunkForwarder: %v", err)
+	}
+	reconcileUntilDone(t, r, request)
+	if err := fakeClient.Get(context.TODO(), request.NamespacedName, instance); err != nil {
+		t.Fatalf("unable to get SplunkForwarder: %v", err)
+	}
+	if instance.Status.DeliveryMode != sfv1alpha1.DeliveryModeReliable {
+		t.Errorf("DeliveryMode = %q, want %q", instance.Status.DeliveryMode, sfv1alpha1.DeliveryModeReliable)
+	}
+}
+
+func testPod(name string, labels map[string]string, containers ...string) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "app", UID: types.UID(name + "-uid"), Labels: labels},
+	}
+	for _, container := range containers {
+		pod.Spec.Containers = append(pod.Spec.Containers, corev1.Container{Name: container})
+	}
+	return pod
+}
+
+func TestReconcileSplunkForwarder_PodLogs(t *testing.T) {
+	cr := testSplunkForwarderCR()
+	cr.Spec.ClusterID = "test"
+	cr.Spec.PodLogs = []sfv1alpha1.SplunkPodLogsInput{
+		{
+			Name:       "web",
+			Namespace:  "app",
+			Selector:   &metav1.LabelSelector{MatchLabels: map[string]string{"app": "web"}},
+			Containers: []string{"server"},
+			Index:      "app_logs",
+		},
+	}
+	r, fakeClient := newTestReconciler(t,
+		cr, testSplunkForwarderSecret(),
+		testPod("web-1", map[string]string{"app": "web"}, "server", "proxy"),
+		testPod("db-1", map[string]string{"app": "db"}, "server"),
+	)
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: instanceName, Namespace: instanceNamespace}}
+
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	cm := &corev1.ConfigMap{}
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: "osd-monitored-logs-local", Namespace: instanceNamespace}, cm); err != nil {
+		t.Fatalf("unable to get inputs ConfigMap: %v", err)
+	}
+	want := `[monitor:///host/var/log/pods/app_web-1_*]
+sourcetype = kube:container
+index = app_logs
+whitelist = ^/host/var/log/pods/app_web-1_[^/]+/(server)/[^/]+\.log$
+_meta = clusterid::test
+disabled = false
+`
+	if !strings.Contains(cm.Data["inputs.conf"], want) {
+		t.Errorf("inputs.conf = %q, want the stanza of the selected container %q", cm.Data["inputs.conf"], want)
+	}
+	if strings.Contains(cm.Data["inputs.conf"], "proxy") || strings.Contains(cm.Data["inputs.conf"], "db-1") {
+		t.Errorf("inputs.conf = %q, want only the selected containers", cm.Data["inputs.conf"])
+	}
+	if !strings.Contains(cm.Data["transforms.conf"], "[podlogs_metadata]\n") {
+		t.Errorf("transforms.conf = %q, want the pod logs metadata transform", cm.Data["transforms.conf"])
+	}
+
+	// A new pod matching the selector is mapped to the CR and added to the inputs
+	pod := testPod("web-2", map[string]string{"app": "web"}, "server")
+	if err := fakeClient.Create(context.TODO(), pod); err != nil {
+		t.Fatalf("unable to create pod: %v", err)
+	}
+	if got := r.podToSplunkForwarders(context.TODO(), pod); !reflect.DeepEqual(got, []reconcile.Request{request}) {
+		t.Errorf("podToSplunkForwarders() = %v, want %v", got, []reconcile.Request{request})
+	}
+	if got := r.podToSplunkForwarders(context.TODO(), testPod("db-2", map[string]string{"app": "db"})); len(got) != 0 {
+		t.Errorf("podToSplunkForwarders() of a pod not selected = %v, want none", got)
+	}
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: "osd-monitored-logs-local", Namespace: instanceNamespace}, cm); err != nil {
+		t.Fatalf("unable to get inputs ConfigMap: %v", err)
+	}
+	if !strings.Contains(cm.Data["inputs.conf"], "[monitor:///host/var/log/pods/app_web-2_*]\n") {
+		t.Errorf("inputs.conf = %q, want the stanza of the new pod", cm.Data["inputs.conf"])
+	}
+}
+
+func TestReconcileSplunkForwarder_EventsCollector(t *testing.T) {
+	cr := testSplunkForwarderCR()
+	cr.Spec.ClusterID = "test"
+	cr.Spec.EventsCollector = &sfv1alpha1.SplunkEventsCollector{Enabled: true}
+	r, fakeClient := newTestReconciler(t, cr, testSplunkForwarderSecret())
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: instanceName, Namespace: instanceNamespace}}
+	name := types.NamespacedName{Name: instanceName + "-events-collector", Namespace: instanceNamespace}
+
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	deployment := &appsv1.Deployment{}
+	if err := fakeClient.Get(context.TODO(), name, deployment); err != nil {
+		t.Fatalf("events collector Deployment was not created: %v", err)
+	}
+	if image := deployment.Spec.Template.Spec.Containers[0].Image; image != "operator-image" {
+		t.Errorf("events collector image = %q, want the operator image", image)
+	}
+	if err := fakeClient.Get(context.TODO(), name, &corev1.ConfigMap{}); err != nil {
+		t.Errorf("events collector ConfigMap was not created: %v", err)
+	}
+	if err := fakeClient.Get(context.TODO(), name, &corev1.ServiceAccount{}); err != nil {
+		t.Errorf("events collector ServiceAccount was not created in the namespace of the CR: %v", err)
+	}
+	bindingName := types.NamespacedName{Name: kube.EventsCollectorClusterRoleBindingName(request.NamespacedName)}
+	binding := &rbacv1.ClusterRoleBinding{}
+	if err := fakeClient.Get(context.TODO(), bindingName, binding); err != nil {
+		t.Fatalf("events collector ClusterRoleBinding was not created: %v", err)
+	}
+	if subject := binding.Subjects[0]; subject.Name != name.Name || subject.Namespace != name.Namespace {
+		t.Errorf("ClusterRoleBinding subject = %s/%s, want the ServiceAccount %s", subject.Namespace, subject.Name, name)
+	}
+
+	// Disabling the collector deletes it
+	if err := fakeClient.Get(context.TODO(), request.NamespacedName, cr); err != nil {
+		t.Fatalf("unable to get SplunkForwarder: %v", err)
+	}
+	cr.Spec.EventsCollector.Enabled = false
+	if err := fakeClient.Update(context.TODO(), cr); err != nil {
+		t.Fatalf("unable to update SplunkForwarder: %v", err)
+	}
+	reconcileUntilDone(t, r, request)
+	if err := fakeClient.Get(context.TODO(), name, &appsv1.Deployment{}); err == nil {
+		t.Errorf("events collector Deployment was not deleted")
+	}
+	if err := fakeClient.Get(context.TODO(), name, &corev1.ConfigMap{}); err == nil {
+		t.Errorf("events collector ConfigMap was not deleted")
+	}
+	if err := fakeClient.Get(context.TODO(), name, &corev1.ServiceAccount{}); err == nil {
+		t.Errorf("events collector ServiceAccount was not deleted")
+	}
+	if err := fakeClient.Get(context.TODO(), bindingName, &rbacv1.ClusterRoleBinding{}); err == nil {
+		t.Errorf("events collector ClusterRoleBinding was not deleted")
+	}
+
+	// The binding of a deleted CR is deleted, as it is not garbage collected
+	cr.Spec.EventsCollector.Enabled = true
+	if err := fakeClient.Update(context.TODO(), cr); err != nil {
+		t.Fatalf("unable to update SplunkForwarder: %v", err)
+	}
+	reconcileUntilDone(t, r, request)
+	if err := fakeClient.Delete(context.TODO(), cr); err != nil {
+		t.Fatalf("unable to delete SplunkForwarder: %v", err)
+	}
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if err := fakeClient.Get(context.TODO(), bindingName, &rbacv1.ClusterRoleBinding{}); err == nil {
+		t.Errorf("events collector ClusterRoleBinding of the deleted CR was not deleted")
+	}
+}
+
+func TestReconcileSplunkForwarder_Apps(t *testing.T) {
+	cr := testSplunkForwarderCR()
+	cr.Spec.ClusterID = "test"
+	cr.Spec.Apps = []sfv1alpha1.SplunkApp{
+		{Name: "Splunk_TA_nix", ConfigMap: "ta-nix"},
+		{Name: "TA-oci", Image: "quay.io/example/ta-oci:1.0"},
+	}
+	appConfigMap := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "ta-nix", Namespace: instanceNamespace},
+		Data:       map[string]string{"inputs.conf": "[script://./bin/cpu.sh]\n"},
+	}
+	r, fakeClient := newTestReconciler(t, cr, testSplunkForwarderSecret(), appConfigMap)
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: instanceName, Namespace: instanceNamespace}}
+	dsName := types.NamespacedName{Name: instanceName + "-ds", Namespace: instanceNamespace}
+
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	ds := &appsv1.DaemonSet{}
+	if err := fakeClient.Get(context.TODO(), dsName, ds); err != nil {
+		t.Fatalf("unable to get DaemonSet: %v", err)
+	}
+	appsHash := ds.Spec.Template.Annotations[kube.AppsHashAnnotation]
+	if appsHash == "" {
+		t.Fatalf("DaemonSet pod template has no %s annotation", kube.AppsHashAnnotation)
+	}
+	templateHash := ds.Annotations[kube.TemplateHashAnnotation]
+
+	// Changing the app rolls the forwarders
+	appConfigMap.Data["inputs.conf"] = "[script://./bin/memory.sh]\n"
+	if err := fakeClient.Update(context.TODO(), appConfigMap); err != nil {
+		t.Fatalf("unable to update the app ConfigMap: %v", err)
+	}
+	if requests := r.appSourceToSplunkForwarders(context.TODO(), appConfigMap); len(requests) != 1 || requests[0] != request {
+		t.Errorf("appSourceToSplunkForwarders() = %v, want %v", requests, request)
+	}
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if err := fakeClient.Get(context.TODO(), dsName, ds); err != nil {
+		t.Fatalf("unable to get DaemonSet: %v", err)
+	}
+	if ds.Spec.Template.Annotations[kube.AppsHashAnnotation] == appsHash || ds.Annotations[kube.TemplateHashAnnotation] == templateHash {
+		t.Errorf("DaemonSet was not rolled after the app changed")
+	}
+
+	// Unrelated ConfigMaps and Secrets do not enqueue the CR
+	other := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "ta-nix", Namespace: instanceNamespace}}
+	if requests := r.appSourceToSplunkForwarders(context.TODO(), other); len(requests) != 0 {
+		t.Errorf("appSourceToSplunkForwarders() = %v, want no request for a Secret of the same name", requests)
+	}
+}
+
+func TestReconcileSplunkForwarder_DependencyPredicate(t *testing.T) {
+	cr := testSplunkForwarderCR()
+	cr.Spec.Apps = []sfv1alpha1.SplunkApp{{Name: "Splunk_TA_nix", Secret: "ta-nix"}}
+	r, _ := newTestReconciler(t, cr)
+	p := dependencyPredicate(r.secretToSplunkForwarders)
+
+	appSecret := &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{Name: "ta-nix", Namespace: instanceNamespace},
+		Data:       map[string][]byte{"inputs.conf": []byte("[script://./bin/cpu.sh]\n")},
+	}
+	tlsSecret := testCertificateTLSSecret()
+	other := &corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: instanceNamespace}}
+	for _, tt := range []struct {
+		name   string
+		secret *corev1.Secret
+		want   bool
+	}{
+		{"app Secret", appSecret, true},
+		{"issued Secret", tlsSecret, true},
+		{"HEC token Secret", testSplunkHECSecret(), true},
+		{"unrelated Secret", other, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := p.Create(event.CreateEvent{Object: tt.secret}); got != tt.want {
+				t.Errorf("Create() = %v, want %v", got, tt.want)
+			}
+			if got := p.Delete(event.DeleteEvent{Object: tt.secret}); got != tt.want {
+				t.Errorf("Delete() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	// Only the updates that change the data are let through
+	relabeled := tlsSecret.DeepCopy()
+	relabeled.Labels["example.com/owner"] = "team"
+	if p.Update(event.UpdateEvent{ObjectOld: tlsSecret, ObjectNew: relabeled}) {
+		t.Errorf("Update() of the labels = true, want false")
+	}
+	renewed := tlsSecret.DeepCopy()
+	renewed.Data["tls.crt"] = []byte("RENEWED\n")
+	if !p.Update(event.UpdateEvent{ObjectOld: tlsSecret, ObjectNew: renewed}) {
+		t.Errorf("Update() of the data = false, want true")
+	}
+}
+
+func TestReconcileSplunkForwarder_AuthSecrets(t *testing.T) {
+	cr := testSplunkForwarderCR()
+	cr.Spec.ClusterID = "test"
+	secret := testSplunkForwarderSecret()
+	r, fakeClient := newTestReconciler(t, cr, secret)
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: instanceName, Namespace: instanceNamespace}}
+	dsName := types.NamespacedName{Name: instanceName + "-ds", Namespace: instanceNamespace}
+	getTemplate := func() *corev1.PodTemplateSpec {
+		t.Helper()
+		reconcileUntilDone(t, r, request)
+		ds := &appsv1.DaemonSet{}
+		if err := fakeClient.Get(context.TODO(), dsName, ds); err != nil {
+			t.Fatalf("unable to get DaemonSet: %v", err)
+		}
+		return &ds.Spec.Template
+	}
+	template := getTemplate()
+	authHash := template.Annotations[kube.AuthHashAnnotation]
+	if authHash == "" {
+		t.Fatalf("template annotations = %v, want %s", template.Annotations, kube.AuthHashAnnotation)
+	}
+
+	// New credentials in splunk-auth roll the pods
+	secret.Data = map[string][]byte{"cacert.pem": []byte("ROTATED\n")}
+	if err := fakeClient.Update(context.TODO(), secret); err != nil {
+		t.Fatalf("unable to update Secret: %v", err)
+	}
+	if got := r.secretToSplunkForwarders(context.TODO(), secret); !reflect.DeepEqual(got, []reconcile.Request{request}) {
+		t.Errorf("secretToSplunkForwarders() of splunk-auth = %v, want %v", got, []reconcile.Request{request})
+	}
+	template = getTemplate()
+	if template.Annotations[kube.AuthHashAnnotation] == authHash {
+		t.Errorf("auth hash did not change with splunk-auth")
+	}
+
+	// The HEC token switches the forwarders to the HEC mode
+	hecSecret := testSplunkHECSecret()
+	if err := fakeClient.Create(context.TODO(), hecSecret); err != nil {
+		t.Fatalf("unable to create Secret: %v", err)
+	}
+	if got := r.secretToSplunkForwarders(context.TODO(), hecSecret); !reflect.DeepEqual(got, []reconcile.Request{request}) {
+		t.Errorf("secretToSplunkForwarders() of splunk-hec-token = %v, want %v", got, []reconcile.Request{request})
+	}
+	template = getTemplate()
+	hecVolume := false
+	for _, volume := range template.Spec.Volumes {
+		if volume.Name == config.SplunkHECTokenSecretName {
+			hecVolume = true
+		}
+	}
+	if !hecVolume {
+		t.Errorf("volumes = %v, want the %s Secret", template.Spec.Volumes, config.SplunkHECTokenSecretName)
+	}
+	if want := kube.DataHash(hecSecret.Data); template.Annotations[kube.AuthHashAnnotation] != want {
+		t.Errorf("auth hash = %q, want the hash of the HEC token %q", template.Annotations[kube.AuthHashAnnotation], want)
+	}
+}
+
+func TestReconcileSplunkForwarder_Proxy(t *testing.T) {
+	cr := testSplunkForwarderCR()
+	cr.Spec.ClusterID = "test"
+	proxy := &configv1.Proxy{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Status: configv1.ProxyStatus{
+			HTTPProxy:  "http://proxy.example.com:3128",
+			HTTPSProxy: "http://proxy.example.com:3128",
+			NoProxy:    ".cluster.local,.svc,10.0.0.0/16",
+		},
+	}
+	r, fakeClient := newTestReconciler(t, cr, testSplunkForwarderSecret(), proxy)
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: instanceName, Namespace: instanceNamespace}}
+	dsName := types.NamespacedName{Name: instanceName + "-ds", Namespace: instanceNamespace}
+
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	ds := &appsv1.DaemonSet{}
+	if err := fakeClient.Get(context.TODO(), dsName, ds); err != nil {
+		t.Fatalf("unable to get DaemonSet: %v", err)
+	}
+	env := map[string]string{}
+	for _, v := range ds.Spec.Template.Spec.Containers[0].Env {
+		env[v.Name] = v.Value
+	}
+	if env["HTTPS_PROXY"] != proxy.Status.HTTPSProxy || env["NO_PROXY"] != proxy.Status.NoProxy {
+		t.Errorf("forwarder env = %v, want the proxy of the cluster", env)
+	}
+	cm := &corev1.ConfigMap{}
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: "osd-monitored-logs-local", Namespace: instanceNamespace}, cm); err != nil {
+		t.Fatalf("unable to get inputs ConfigMap: %v", err)
+	}
+	if !strings.Contains(cm.Data["server.conf"], "[proxyConfig]\nhttp_proxy = http://proxy.example.com:3128\n") {
+		t.Errorf("server.conf = %q, want a [proxyConfig] stanza", cm.Data["server.conf"])
+	}
+	templateHash := ds.Annotations[kube.TemplateHashAnnotation]
+
+	// Changing the proxy rolls the forwarders
+	proxy.Status.HTTPSProxy = "http://proxy2.example.com:3128"
+	if err := fakeClient.Update(context.TODO(), proxy); err != nil {
+		t.Fatalf("unable to update Proxy: %v", err)
+	}
+	reconcileUntilDone(t, r, request)
+	if err := fakeClient.Get(context.TODO(), dsName, ds); err != nil {
+		t.Fatalf("unable to get DaemonSet: %v", err)
+	}
+	if ds.Annotations[kube.TemplateHashAnnotation] == templateHash {
+		t.Errorf("DaemonSet was not rolled after the proxy changed")
+	}
+}
+
+func TestReconcileSplunkForwarder_TrustedCABundle(t *testing.T) {
+	cr := testSplunkForwarderCR()
+	cr.Spec.ClusterID = "test"
+	cr.Spec.TrustedCABundle = true
+	r, fakeClient := newTestReconciler(t, cr, testSplunkForwarderSecret())
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: instanceName, Namespace: instanceNamespace}}
+	cmName := types.NamespacedName{Name: instanceName + "-trusted-ca-bundle", Namespace: instanceNamespace}
+	dsName := types.NamespacedName{Name: instanceName + "-ds", Namespace: instanceNamespace}
+
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	cm := &corev1.ConfigMap{}
+	if err := fakeClient.Get(context.TODO(), cmName, cm); err != nil {
+		t.Fatalf("trusted CA bundle ConfigMap was not created: %v", err)
+	}
+	if cm.Labels[kube.TrustedCABundleInjectLabel] != "true" {
+		t.Errorf("ConfigMap labels = %v, want the injection label", cm.Labels)
+	}
+	ds := &appsv1.DaemonSet{}
+	if err := fakeClient.Get(context.TODO(), dsName, ds); err != nil {
+		t.Fatalf("unable to get DaemonSet: %v", err)
+	}
+	if _, ok := ds.Spec.Template.Annotations[kube.TrustedCABundleHashAnnotation]; ok {
+		t.Errorf("DaemonSet has a trusted CA bundle hash before the bundle is injected")
+	}
+
+	// The injected bundle is kept, and rolls the forwarders
+	cm.Data = map[string]string{kube.TrustedCABundleKey: "-----BEGIN CERTIFICATE-----\n"}
+	if err := fakeClient.Update(context.TODO(), cm); err != nil {
+		t.Fatalf("unable to inject the bundle: %v", err)
+	}
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if err := fakeClient.Get(context.TODO(), cmName, cm); err != nil {
+		t.Fatalf("unable to get ConfigMap: %v", err)
+	}
+	if cm.Data[kube.TrustedCABundleKey] == "" {
+		t.Errorf("the injected bundle was overwritten")
+	}
+	if err := fakeClient.Get(context.TODO(), dsName, ds); err != nil {
+		t.Fatalf("unable to get DaemonSet: %v", err)
+	}
+	if ds.Spec.Template.Annotations[kube.TrustedCABundleHashAnnotation] == "" {
+		t.Errorf("DaemonSet has no trusted CA bundle hash after the bundle was injected")
+	}
+
+	// Not trusting the bundle anymore deletes the ConfigMap
+	if err := fakeClient.Get(context.TODO(), request.NamespacedName, cr); err != nil {
+		t.Fatalf("unable to get SplunkForwarder: %v", err)
+	}
+	cr.Spec.TrustedCABundle = false
+	if err := fakeClient.Update(context.TODO(), cr); err != nil {
+		t.Fatalf("unable to update SplunkForwarder: %v", err)
+	}
+	reconcileUntilDone(t, r, request)
+	if err := fakeClient.Get(context.TODO(), cmName, &corev1.ConfigMap{}); err == nil {
+		t.Errorf("trusted CA bundle ConfigMap was not deleted")
+	}
+}
+
+func TestReconcileSplunkForwarder_FIPS(t *testing.T) {
+	cr := testSplunkForwarderCR()
+	cr.Spec.ClusterID = "test"
+	cr.Spec.FIPS = sfv1alpha1.FIPSAuto
+	installConfig := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster-config-v1", Namespace: "kube-system"},
+		Data:       map[string]string{"install-config": "apiVersion: v1\nfips: true\nmetadata:\n  name: test\n"},
+	}
+	secret := testSplunkForwarderSecret()
+	secret.Data = map[string][]byte{"outputs.conf": []byte("[tcpout:splunk]\ncipherSuite = ECDHE-RSA-CHACHA20-POLY1305\n")}
+	r, fakeClient := newTestReconciler(t, cr, secret, installConfig)
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: instanceName, Namespace: instanceNamespace}}
+	dsName := types.NamespacedName{Name: instanceName + "-ds", Namespace: instanceNamespace}
+
+	// A non-FIPS cipher in splunk-auth keeps the forwarders from being rolled out
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if err := fakeClient.Get(context.TODO(), dsName, &appsv1.DaemonSet{}); err == nil {
+		t.Errorf("DaemonSet was created with a non-FIPS cipher")
+	}
+	if err := fakeClient.Get(context.TODO(), request.NamespacedName, cr); err != nil {
+		t.Fatalf("unable to get SplunkForwarder: %v", err)
+	}
+	if !meta.IsStatusConditionFalse(cr.Status.Conditions, sfv1alpha1.ConditionFIPSCompliant) {
+		t.Errorf("conditions = %v, want FIPSCompliant false", cr.Status.Conditions)
+	}
+	if cr.Status.FIPSMode != sfv1alpha1.FIPSEnabled {
+		t.Errorf("status.fipsMode = %q, want %q", cr.Status.FIPSMode, sfv1alpha1.FIPSEnabled)
+	}
+
+	// Once the cipher is fixed, the forwarders run in FIPS mode. The splunk-auth Secret is watched, so
+	// that its update is reconciled.
+	secret.Data["outputs.conf"] = []byte("[tcpout:splunk]\ncipherSuite = ECDHE-RSA-AES256-GCM-SHA384\n")
+	if err := fakeClient.Update(context.TODO(), secret); err != nil {
+		t.Fatalf("unable to update Secret: %v", err)
+	}
+	if got := r.secretToSplunkForwarders(context.TODO(), secret); !reflect.DeepEqual(got, []reconcile.Request{request}) {
+		t.Errorf("secretToSplunkForwarders() of splunk-auth = %v, want %v", got, []reconcile.Request{request})
+	}
+	reconcileUntilDone(t, r, request)
+	ds := &appsv1.DaemonSet{}
+	if err := fakeClient.Get(context.TODO(), dsName, ds); err != nil {
+		t.Fatalf("unable to get DaemonSet: %v", err)
+	}
+	env := map[string]string{}
+	for _, v := range ds.Spec.Template.Spec.Containers[0].Env {
+		env[v.Name] = v.Value
+	}
+	if env["SPLUNK_FIPS"] != "1" {
+		t.Errorf("forwarder env = %v, want SPLUNK_FIPS", env)
+	}
+	cm := &corev1.ConfigMap{}
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: "osd-monitored-logs-local", Namespace: instanceNamespace}, cm); err != nil {
+		t.Fatalf("unable to get inputs ConfigMap: %v", err)
+	}
+	if !strings.Contains(cm.Data["outputs.conf"], "[tcpout]\nsslVersions = tls1.2\n") {
+		t.Errorf("outputs.conf = %q, want the FIPS TLS versions", cm.Data["outputs.conf"])
+	}
+	if err := fakeClient.Get(context.TODO(), request.NamespacedName, cr); err != nil {
+		t.Fatalf("unable to get SplunkForwarder: %v", err)
+	}
+	if !meta.IsStatusConditionTrue(cr.Status.Conditions, sfv1alpha1.ConditionFIPSCompliant) {
+		t.Errorf("conditions = %v, want FIPSCompliant true", cr.Status.Conditions)
+	}
+
+	// Disabling FIPS mode in the CR takes precedence over the cluster
+	cr.Spec.FIPS = sfv1alpha1.FIPSDisabled
+	if err := fakeClient.Update(context.TODO(), cr); err != nil {
+		t.Fatalf("unable to update SplunkForwarder: %v", err)
+	}
+	reconcileUntilDone(t, r, request)
+	if err := fakeClient.Get(context.TODO(), request.NamespacedName, cr); err != nil {
+		t.Fatalf("unable to get SplunkForwarder: %v", err)
+	}
+	if cr.Status.FIPSMode != sfv1alpha1.FIPSDisabled || meta.FindStatusCondition(cr.Status.Conditions, sfv1alpha1.ConditionFIPSCompliant) != nil {
+		t.Errorf("status = %+v, want FIPS mode disabled without the FIPSCompliant condition", cr.Status)
+	}
+}
+
+func TestReconcileSplunkForwarder_TLSProfile(t *testing.T) {
+	cr := testSplunkForwarderCR()
+	cr.Spec.ClusterID = "test"
+	apiServer := &configv1.APIServer{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Spec: configv1.APIServerSpec{
+			TLSSecurityProfile: &configv1.TLSSecurityProfile{Type: configv1.TLSProfileIntermediateType},
+		},
+	}
+	r, fakeClient := newTestReconciler(t, cr, testSplunkForwarderSecret(), apiServer)
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: instanceName, Namespace: instanceNamespace}}
+	dsName := types.NamespacedName{Name: instanceName + "-ds", Namespace: instanceNamespace}
+	cmName := types.NamespacedName{Name: "osd-monitored-logs-local", Namespace: instanceNamespace}
+
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	cm := &corev1.ConfigMap{}
+	if err := fakeClient.Get(context.TODO(), cmName, cm); err != nil {
+		t.Fatalf("unable to get inputs ConfigMap: %v", err)
+	}
+	for _, want := range []string{"[sslConfig]\nsslVersions = tls1.2, tls1.3\n", "cipherSuite = ECDHE-ECDSA-AES128-GCM-SHA256:"} {
+		if !strings.Contains(cm.Data["server.conf"], want) {
+			t.Errorf("server.conf = %q, want %q", cm.Data["server.conf"], want)
+		}
+	}
+	if !strings.Contains(cm.Data["outputs.conf"], "[tcpout]\nsslVersions = tls1.2, tls1.3\n") {
+		t.Errorf("outputs.conf = %q, want the TLS versions of the profile", cm.Data["outputs.conf"])
+	}
+	ds := &appsv1.DaemonSet{}
+	if err := fakeClient.Get(context.TODO(), dsName, ds); err != nil {
+		t.Fatalf("unable to get DaemonSet: %v", err)
+	}
+	templateHash := ds.Annotations[kube.TemplateHashAnnotation]
+
+	// Changing the profile rolls the forwarders
+	apiServer.Spec.TLSSecurityProfile = &configv1.TLSSecurityProfile{Type: configv1.TLSProfileModernType}
+	if err := fakeClient.Update(context.TODO(), apiServer); err != nil {
+		t.Fatalf("unable to update APIServer: %v", err)
+	}
+	reconcileUntilDone(t, r, request)
+	if err := fakeClient.Get(context.TODO(), cmName, cm); err != nil {
+		t.Fatalf("unable to get inputs ConfigMap: %v", err)
+	}
+	if !strings.Contains(cm.Data["outputs.conf"], "[tcpout]\nsslVersions = tls1.3\n") {
+		t.Errorf("outputs.conf = %q, want the TLS versions of the Modern profile", cm.Data["outputs.conf"])
+	}
+	if err := fakeClient.Get(context.TODO(), dsName, ds); err != nil {
+		t.Fatalf("unable to get DaemonSet: %v", err)
+	}
+	if ds.Annotations[kube.TemplateHashAnnotation] == templateHash {
+		t.Errorf("DaemonSet was not rolled after the TLS security profile changed")
+	}
+}
+
+func TestReconcileSplunkForwarder_ImageMirrors(t *testing.T) {
+	cr := testSplunkForwarderCR()
+	cr.Spec.ClusterID = "test"
+	cr.Spec.ImageDigest = "sha256:2452a3f01e840661ee1194777ed5a9185ceaaa9ec7329ed364fa2f02be22a701"
+	idms := &configv1.ImageDigestMirrorSet{
+		ObjectMeta: metav1.ObjectMeta{Name: "mirrors"},
+		Spec: configv1.ImageDigestMirrorSetSpec{
+			ImageDigestMirrors: []configv1.ImageDigestMirrors{
+				{Source: "quay.io/other", Mirrors: []configv1.ImageMirror{"mirror.example.com/other"}},
+			},
+		},
+	}
+	r, fakeClient := newTestReconciler(t, cr, testSplunkForwarderSecret(), idms)
+	r.OperatorImage = "quay.io/other/operator@" + cr.Spec.ImageDigest
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: instanceName, Namespace: instanceNamespace}}
+
+	// The forwarder image is not mirrored
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if err := fakeClient.Get(context.TODO(), request.NamespacedName, cr); err != nil {
+		t.Fatalf("unable to get SplunkForwarder: %v", err)
+	}
+	condition := meta.FindStatusCondition(cr.Status.Conditions, sfv1alpha1.ConditionImageMirrorsAvailable)
+	if condition == nil || condition.Status != metav1.ConditionFalse || !strings.Contains(condition.Message, image+"@") {
+		t.Errorf("ImageMirrorsAvailable condition = %v, want false naming the forwarder image", condition)
+	}
+
+	// An ImageContentSourcePolicy mirroring the forwarder repository covers it
+	icsp := &operatorv1alpha1.ImageContentSourcePolicy{
+		ObjectMeta: metav1.ObjectMeta{Name: "forwarder"},
+		Spec: operatorv1alpha1.ImageContentSourcePolicySpec{
+			RepositoryDigestMirrors: []operatorv1alpha1.RepositoryDigestMirrors{
+				{Source: image, Mirrors: []string{"mirror.example.com/" + image}},
+			},
+		},
+	}
+	if err := fakeClient.Create(context.TODO(), icsp); err != nil {
+		t.Fatalf("unable to create ImageContentSourcePolicy: %v", err)
+	}
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if err := fakeClient.Get(context.TODO(), request.NamespacedName, cr); err != nil {
+		t.Fatalf("unable to get SplunkForwarder: %v", err)
+	}
+	if !meta.IsStatusConditionTrue(cr.Status.Conditions, sfv1alpha1.ConditionImageMirrorsAvailable) {
+		t.Errorf("conditions = %v, want ImageMirrorsAvailable true", cr.Status.Conditions)
+	}
+
+	// Without mirrors the condition is removed
+	for _, obj := range []client.Object{idms, icsp} {
+		if err := fakeClient.Delete(context.TODO(), obj); err != nil {
+			t.Fatalf("unable to delete %T: %v", obj, err)
+		}
+	}
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if err := fakeClient.Get(context.TODO(), request.NamespacedName, cr); err != nil {
+		t.Fatalf("unable to get SplunkForwarder: %v", err)
+	}
+	if meta.FindStatusCondition(cr.Status.Conditions, sfv1alpha1.ConditionImageMirrorsAvailable) != nil {
+		t.Errorf("conditions = %v, want no ImageMirrorsAvailable condition", cr.Status.Conditions)
+	}
+}
+
+// fakeResolver resolves the tags it knows and counts the resolutions
+type fakeResolver struct {
+	digests     map[string]string
+	resolutions int
+}
+
+func (f *fakeResolver) Resolve(_ context.Context, repository, tag string, _ registry.Credentials) (string, error) {
+	f.resolutions++
+	digest, ok := f.digests[repository+":"+tag]
+	if !ok {
+		return "", fmt.Errorf("manifest unknown")
+	}
+	return digest, nil
+}
+
+func TestReconcileSplunkForwarder_ImageResolution(t *testing.T) {
+	digest := "sha256:2452a3f01e840661ee1194777ed5a9185ceaaa9ec7329ed364fa2f02be22a701"
+	cr := testSplunkForwarderCR()
+	cr.Spec.ClusterID = "test"
+	r, fakeClient := newTestReconciler(t, cr, testSplunkForwarderSecret())
+	resolver := &fakeResolver{digests: map[string]string{image + ":" + imageTag: digest}}
+	r.ImageResolver = resolver
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: instanceName, Namespace: instanceNamespace}}
+
+	// The tag is resolved once and the digest pinned into the DaemonSet
+	for range 2 {
+		if _, err := r.Reconcile(context.TODO(), request); err != nil {
+			t.Fatalf("Reconcile() error = %v", err)
+		}
+	}
+	if resolver.resolutions != 1 {
+		t.Errorf("the tag was resolved %d times, want once", resolver.resolutions)
+	}
+	if err := fakeClient.Get(context.TODO(), request.NamespacedName, cr); err != nil {
+		t.Fatalf("unable to get SplunkForwarder: %v", err)
+	}
+	if cr.Status.ImageDigest != digest || cr.Status.ResolvedImage != image+":"+imageTag {
+		t.Errorf("status image = %q, %q, want the resolved digest", cr.Status.ResolvedImage, cr.Status.ImageDigest)
+	}
+	if !meta.IsStatusConditionTrue(cr.Status.Conditions, sfv1alpha1.ConditionImageResolved) {
+		t.Errorf("conditions = %v, want ImageResolved true", cr.Status.Conditions)
+	}
+	if cr.Spec.ImageDigest != "" {
+		t.Errorf("imageDigest = %q, the spec must not be updated", cr.Spec.ImageDigest)
+	}
+	ds := &appsv1.DaemonSet{}
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: instanceName + "-ds", Namespace: instanceNamespace}, ds); err != nil {
+		t.Fatalf("unable to get DaemonSet: %v", err)
+	}
+	if got := ds.Spec.Template.Spec.Containers[0].Image; got != image+"@"+digest {
+		t.Errorf("forwarder image = %q, want %q", got, image+"@"+digest)
+	}
+
+	// The latest tag is refused and the DaemonSet is left as is
+	cr.Spec.ImageTag = "latest"
+	if err := fakeClient.Update(context.TODO(), cr); err != nil {
+		t.Fatalf("unable to update SplunkForwarder: %v", err)
+	}
+	if _, err := r.Reconcile(context.TODO(), request); err != nil {
+		t.Fatalf("Reconcile() error = %v", err)
+	}
+	if err := fakeClient.Get(context.TODO(), request.NamespacedName, cr); err != nil {
+		t.Fatalf("unable to get SplunkForwarder: %v", err)
+	}
+	condition := meta.FindStatusCondition(cr.Status.Conditions, sfv1alpha1.ConditionImageResolved)
+	if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != "InvalidImage" {
+		t.Errorf("ImageResolved condition = %v, want false for an invalid image", condition)
+	}
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: instanceName + "-ds", Namespace: instanceNamespace}, ds); err != nil {
+		t.Fatalf("unable to get DaemonSet: %v", err)
+	}
+	if got := ds.Spec.Template.Spec.Containers[0].Image; got != image+"@"+digest {
+		t.Errorf("forwarder image = %q, want the previous digest", got)
+	}
+
+	// A tag the registry does not know is an error
+	cr.Spec.ImageTag = "0.0.2"
+	if err := fakeClient.Update(context.TODO(), cr); err != nil {
+		t.Fatalf("unable to update SplunkForwarder: %v", err)
+	}
+	if _, err := r.Reconcile(context.TODO(), request); err == nil {
+		t.Errorf("Reconcile() succeeded for an unknown tag")
+	}
+	if err := fakeClient.Get(context.TODO(), request.NamespacedName, cr); err != nil {
+		t.Fatalf("unable to get SplunkForwarder: %v", err)
+	}
+	condition = meta.FindStatusCondition(cr.Status.Conditions, sfv1alpha1.ConditionImageResolved)
+	if condition == nil || condition.Status != metav1.ConditionFalse || condition.Reason != "ResolutionFailed" {
+		t.Errorf("ImageResolved condition = %v, want false for an unknown tag", condition)
+	}
+}
+
+func TestReconcileSplunkForwarder_Canary(t *testing.T) {
+	cr := testSplunkForwarderCR()
+	cr.Spec.ClusterID = "test"
+	cr.Spec.RolloutStrategy = &sfv1alpha1.SplunkRolloutStrategy{
+		Canary: &sfv1alpha1.SplunkCanaryRollout{
+			NodeSelector: map[string]string{"canary": "true"},
+			SoakDuration: &metav1.Duration{},
+		},
+	}
+	r, fakeClient := newTestReconciler(t, cr, testSplunkForwarderSecret())
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: instanceName, Namespace: instanceNamespace}}
+	images := func() (string, string) {
+		t.Helper()
+		main, canary := &appsv1.DaemonSet{}, &appsv1.DaemonSet{}
+		if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: instanceName + "-ds", Namespace: instanceNamespace}, main); err != nil {
+			t.Fatalf("unable to get DaemonSet: %v", err)
+		}
+		if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: instanceName + "-ds-canary", Namespace: instanceNamespace}, canary); err != nil {
+			t.Fatalf("unable to get canary DaemonSet: %v", err)
+		}
+		return main.Spec.Template.Spec.Containers[0].Image, canary.Spec.Template.Spec.Containers[0].Image
+	}
+	updateTag := func(tag string) {
+		t.Helper()
+		if err := fakeClient.Get(context.TODO(), request.NamespacedName, cr); err != nil {
+			t.Fatalf("unable to get SplunkForwarder: %v", err)
+		}
+		cr.Spec.ImageTag = tag
+		if err := fakeClient.Update(context.TODO(), cr); err != nil {
+			t.Fatalf("unable to update SplunkForwarder: %v", err)
+		}
+	}
+	getCR := func() *sfv1alpha1.SplunkForwarder {
+		t.Helper()
+		if err := fakeClient.Get(context.TODO(), request.NamespacedName, cr); err != nil {
+			t.Fatalf("unable to get SplunkForwarder: %v", err)
+		}
+		return cr
+	}
+
+	// A new CR is rolled out to every node at once
+	reconcileUntilDone(t, r, request)
+	if main, canary := images(); main != image+":"+imageTag || canary != main {
+		t.Fatalf("images = %s, %s, want %s on every node", main, canary, image+":"+imageTag)
+	}
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: "osd-monitored-logs-local-canary", Namespace: instanceNamespace}, &corev1.ConfigMap{}); err != nil {
+		t.Errorf("unable to get the canary ConfigMap: %v", err)
+	}
+
+	// A new image reaches the canary nodes first
+	updateTag("0.0.2")
+	reconcileUntilDone(t, r, request)
+	if main, canary := images(); main != image+":"+imageTag || canary != image+":0.0.2" {
+		t.Errorf("images = %s, %s, want the new image on the canary nodes only", main, canary)
+	}
+	if phase := getCR().Status.CanaryPhase; phase != sfv1alpha1.CanaryProgressing {
+		t.Errorf("canary phase = %s, want %s", phase, sfv1alpha1.CanaryProgressing)
+	}
+
+	// The objects outside of the forwarders are still reconciled while the canary is pending
+	cr = getCR()
+	cr.Spec.EventsCollector = &sfv1alpha1.SplunkEventsCollector{Enabled: true}
+	if err := fakeClient.Update(context.TODO(), cr); err != nil {
+		t.Fatalf("unable to update SplunkForwarder: %v", err)
+	}
+	reconcileUntilDone(t, r, request)
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: instanceName + "-events-collector", Namespace: instanceNamespace}, &appsv1.Deployment{}); err != nil {
+		t.Errorf("the events collector was not created during the canary: %v", err)
+	}
+	if main, _ := images(); main != image+":"+imageTag {
+		t.Errorf("image = %s, want the other nodes kept on %s", main, image+":"+imageTag)
+	}
+
+	// Once the canary forwarders are ready and soaked, the image is promoted
+	canaryDS := &appsv1.DaemonSet{}
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: instanceName + "-ds-canary", Namespace: instanceNamespace}, canaryDS); err != nil {
+		t.Fatalf("unable to get canary DaemonSet: %v", err)
+	}
+	canaryDS.Status = appsv1.DaemonSetStatus{ObservedGeneration: canaryDS.Generation, DesiredNumberScheduled: 1, UpdatedNumberScheduled: 1, NumberReady: 1}
+	if err := fakeClient.Status().Update(context.TODO(), canaryDS); err != nil {
+		t.Fatalf("unable to update canary DaemonSet: %v", err)
+	}
+	reconcileUntilDone(t, r, request)
+	if phase := getCR().Status.CanaryPhase; phase != sfv1alpha1.CanarySoaking {
+		t.Errorf("canary phase = %s, want %s", phase, sfv1alpha1.CanarySoaking)
+	}
+	reconcileUntilDone(t, r, request)
+	if phase := getCR().Status.CanaryPhase; phase != sfv1alpha1.CanaryPromoted {
+		t.Errorf("canary phase = %s, want %s", phase, sfv1alpha1.CanaryPromoted)
+	}
+	if main, canary := images(); main != image+":0.0.2" || canary != main {
+		t.Errorf("images = %s, %s, want the promoted image on every node", main, canary)
+	}
+
+	// A crash-looping canary is rolled back and not promoted
+	updateTag("0.0.3")
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "canary-pod", Namespace: instanceNamespace, Labels: map[string]string{"name": "splunk-forwarder-canary"}},
+		Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+			{Name: "splunk-uf", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "CrashLoopBackOff"}}},
+		}},
+	}
+	if err := fakeClient.Create(context.TODO(), pod); err != nil {
+		t.Fatalf("unable to create Pod: %v", err)
+	}
+	for range 2 {
+		reconcileUntilDone(t, r, request)
+	}
+	cr = getCR()
+	if cr.Status.CanaryPhase != sfv1alpha1.CanaryFailed || !strings.Contains(cr.Status.CanaryMessage, "canary-pod/splunk-uf: CrashLoopBackOff") {
+		t.Errorf("canary status = %s %q, want the failing container", cr.Status.CanaryPhase, cr.Status.CanaryMessage)
+	}
+	if main, canary := images(); main != image+":0.0.2" || canary != main {
+		t.Errorf("images = %s, %s, want the canary rolled back to %s", main, canary, image+":0.0.2")
+	}
+
+	// Without a canary rollout, the canary objects are deleted
+	cr.Spec.RolloutStrategy = nil
+	if err := fakeClient.Update(context.TODO(), cr); err != nil {
+		t.Fatalf("unable to update SplunkForwarder: %v", err)
+	}
+	reconcileUntilDone(t, r, request)
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: instanceName + "-ds-canary", Namespace: instanceNamespace}, &appsv1.DaemonSet{}); err == nil {
+		t.Errorf("the canary DaemonSet was not deleted")
+	}
+	if cr = getCR(); cr.Status.CanaryPhase != "" {
+		t.Errorf("canary phase = %s, want none", cr.Status.CanaryPhase)
+	}
+}
+
+func TestReconcileSplunkForwarder_AutoRollback(t *testing.T) {
+	cr := testSplunkForwarderCR()
+	cr.Spec.ClusterID = "test"
+	cr.Generation = 1
+	r, fakeClient := newTestReconciler(t, cr, testSplunkForwarderSecret())
+	request := reconcile.Request{NamespacedName: types.NamespacedName{Name: instanceName, Namespace: instanceNamespace}}
+	getDS := func() *appsv1.DaemonSet {
+		t.Helper()
+		ds := &appsv1.DaemonSet{}
+		if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: instanceName + "-ds", Namespace: instanceNamespace}, ds); err != nil {
+			t.Fatalf("unable to get DaemonSet: %v", err)
+		}
+		return ds
+	}
+	setDSStatus := func(status appsv1.DaemonSetStatus) {
+		t.Helper()
+		ds := getDS()
+		ds.Status = status
+		if err := fakeClient.Status().Update(context.TODO(), ds); err != nil {
+			t.Fatalf("unable to update DaemonSet: %v", err)
+		}
+	}
+	getCR := func() *sfv1alpha1.SplunkForwarder {
+		t.Helper()
+		if err := fakeClient.Get(context.TODO(), request.NamespacedName, cr); err != nil {
+			t.Fatalf("unable to get SplunkForwarder: %v", err)
+		}
+		return cr
+	}
+	updateTag := func(tag string) {
+		t.Helper()
+		cr = getCR()
+		cr.Spec.ImageTag = tag
+		cr.Generation++
+		if err := fakeClient.Update(context.TODO(), cr); err != nil {
+			t.Fatalf("unable to update SplunkForwarder: %v", err)
+		}
+	}
+
+	getCM := func() *corev1.ConfigMap {
+		t.Helper()
+		cm := &corev1.ConfigMap{}
+		if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: "osd-monitored-logs-local", Namespace: instanceNamespace}, cm); err != nil {
+			t.Fatalf("unable to get ConfigMap: %v", err)
+		}
+		return cm
+	}
+
+	// A completed rollout is recorded as the last good state
+	reconcileUntilDone(t, r, request)
+	setDSStatus(appsv1.DaemonSetStatus{DesiredNumberScheduled: 1, UpdatedNumberScheduled: 1, NumberReady: 1})
+	reconcileUntilDone(t, r, request)
+	ds := getDS()
+	goodHash := ds.Annotations[kube.TemplateHashAnnotation]
+	goodLimits := getCM().Data["limits.conf"]
+	revision := &appsv1.ControllerRevision{}
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: instanceName + "-ds-last-good", Namespace: instanceNamespace}, revision); err != nil {
+		t.Fatalf("last good state is not recorded: %v", err)
+	}
+	if revision.Annotations[kube.TemplateHashAnnotation] != goodHash || revision.Revision != 1 {
+		t.Errorf("last good revision %d annotations = %v, want revision 1 of template hash %s", revision.Revision, revision.Annotations, goodHash)
+	}
+
+	// A new image and throughput whose pods crash-loop are rolled back. The fake client does not bump the
+	// generation of the DaemonSet, so its status reports the new pod template as not rolled out up front.
+	setDSStatus(appsv1.DaemonSetStatus{DesiredNumberScheduled: 1, NumberReady: 1})
+	cr = getCR()
+	cr.Spec.Throughput = &sfv1alpha1.SplunkThroughput{MaxKBps: 512}
+	if err := fakeClient.Update(context.TODO(), cr); err != nil {
+		t.Fatalf("unable to update SplunkForwarder: %v", err)
+	}
+	updateTag("0.0.2")
+	reconcileUntilDone(t, r, request)
+	if ds = getDS(); ds.Spec.Template.Spec.Containers[0].Image != image+":0.0.2" || getCM().Data["limits.conf"] == goodLimits {
+		t.Fatalf("DaemonSet image = %s, want the new image and throughput", ds.Spec.Template.Spec.Containers[0].Image)
+	}
+	// The API server bumps the template generation of the DaemonSet, which labels the new pods
+	ds.Annotations[templateGenerationAnnotation] = "2"
+	if err := fakeClient.Update(context.TODO(), ds); err != nil {
+		t.Fatalf("unable to update DaemonSet: %v", err)
+	}
+	newPod := func(name, templateGeneration string) *corev1.Pod {
+		t.Helper()
+		pod := &corev1.Pod{
+			ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: instanceNamespace, Labels: map[string]string{"name": "splunk-forwarder", podTemplateGenerationLabel: templateGeneration}},
+			Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{
+				{Name: "splunk-uf", State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: "ImagePullBackOff"}}},
+			}},
+		}
+		if err := fakeClient.Create(context.TODO(), pod); err != nil {
+			t.Fatalf("unable to create Pod: %v", err)
+		}
+		return pod
+	}
+	// A pod of the previous pod template being replaced does not count
+	oldPod := newPod("old-forwarder-pod", "1")
+	reconcileUntilDone(t, r, request)
+	if ds = getDS(); ds.Spec.Template.Spec.Containers[0].Image != image+":0.0.2" {
+		t.Fatalf("DaemonSet image = %s, want it kept at the new image for a failing pod of the previous template", ds.Spec.Template.Spec.Containers[0].Image)
+	}
+	pod := newPod("forwarder-pod", "2")
+	reconcileUntilDone(t, r, request)
+	if ds = getDS(); ds.Spec.Template.Spec.Containers[0].Image != image+":"+imageTag || ds.Annotations[kube.TemplateHashAnnotation] != goodHash {
+		t.Errorf("DaemonSet image = %s, want it rolled back to %s", ds.Spec.Template.Spec.Containers[0].Image, image+":"+imageTag)
+	}
+	if got := getCM().Data["limits.conf"]; got != goodLimits {
+		t.Errorf("limits.conf = %q, want it rolled back to %q", got, goodLimits)
+	}
+	condition := meta.FindStatusCondition(getCR().Status.Conditions, sfv1alpha1.ConditionRolledBack)
+	if condition == nil || condition.Status != metav1.ConditionTrue || !strings.Contains(condition.Message, "forwarder-pod/splunk-uf: ImagePullBackOff") ||
+		strings.Contains(condition.Message, "old-forwarder-pod") {
+		t.Fatalf("RolledBack condition = %v, want the failing container of the new pod template", condition)
+	}
+
+	// The forwarders are kept on the last good state until the CR changes, but credential rotations are
+	// still rolled out
+	reconcileUntilDone(t, r, request)
+	if ds = getDS(); ds.Spec.Template.Spec.Containers[0].Image != image+":"+imageTag || getCM().Data["limits.conf"] != goodLimits {
+		t.Errorf("DaemonSet image = %s, want it kept at %s with the last good ConfigMap", ds.Spec.Template.Spec.Containers[0].Image, image+":"+imageTag)
+	}
+	authHash := ds.Spec.Template.Annotations[kube.AuthHashAnnotation]
+	secret := &corev1.Secret{}
+	if err := fakeClient.Get(context.TODO(), types.NamespacedName{Name: config.SplunkAuthSecretName, Namespace: instanceNamespace}, secret); err != nil {
+		t.Fatalf("unable to get Secret: %v", err)
+	}
+	secret.Data = map[string][]byte{"cacert.pem": []byte("ROTATED\n")}
+	if err := fakeClient.Update(context.TODO(), secret); err != nil {
+		t.Fatalf("unable to update Secret: %v", err)
+	}
+	reconcileUntilDone(t, r, request)
+	if ds = getDS(); ds.Spec.Template.Spec.Containers[0].Image != image+":"+imageTag || ds.Spec.Template.Annotations[kube.AuthHashAnnotation] == authHash {
+		t.Errorf("DaemonSet image = %s, auth hash = %s, want the last good image with the rotated credentials", ds.Spec.Template.Spec.Containers[0].Image, ds.Spec.Template.Annotations[kube.AuthHashAnnotation])
+	}
+
+	// A new CR generation is rolled out again
+	for _, p := range []*corev1.Pod{oldPod, pod} {
+		if err := fakeClient.Delete(context.TODO(), p); err != nil {
+			t.Fatalf("unable to delete Pod: %v", err)
+		}
+	}
+	updateTag("0.0.3")
+	reconcileUntilDone(t, r, request)
+	if ds = getDS(); ds.Spec.Template.Spec.Containers[0].Image != image+":0.0.3" {
+		t.Errorf("DaemonSet image = %s, want %s", ds.Spec.Template.Spec.Containers[0].Image, image+":0.0.3")
+	}
+	if condition := meta.FindStatusCondition(getCR().Status.Conditions, sfv1alpha1.ConditionRolledBack); condition != nil {
+		t.Errorf("RolledBack condition = %v, want none", condition)
+	}
+}
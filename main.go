@@ -28,11 +28,16 @@ import (
 	metricsserver "sigs.k8s.io/controller-runtime/pkg/metrics/server"
 
 	configv1 "github.com/openshift/api/config/v1"
+	operatorv1alpha1 "github.com/openshift/api/operator/v1alpha1"
 	opmetrics "github.com/openshift/operator-custom-metrics/pkg/metrics"
 	splunkforwarderv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	splunkforwarderv1beta1 "github.com/openshift/splunk-forwarder-operator/api/v1beta1"
 	"github.com/openshift/splunk-forwarder-operator/config"
-	"github.com/openshift/splunk-forwarder-operator/controllers/secret"
 	"github.com/openshift/splunk-forwarder-operator/controllers/splunkforwarder"
+	"github.com/openshift/splunk-forwarder-operator/pkg/events"
+	"github.com/openshift/splunk-forwarder-operator/pkg/registry"
+	"github.com/openshift/splunk-forwarder-operator/pkg/reload"
+	"github.com/openshift/splunk-forwarder-operator/pkg/render"
 	"github.com/openshift/splunk-forwarder-operator/version"
 	"github.com/operator-framework/operator-lib/leader"
 	monitoringv1 "github.com/prometheus-operator/prometheus-operator/pkg/apis/monitoring/v1"
@@ -45,6 +50,10 @@ const (
 	ForceRunModeEnv = "OSDK_FORCE_RUN_MODE"
 	// Flags that the operator is running locally
 	LocalRunMode = "local"
+	// Environment variable to enable the webhooks, which need a serving certificate
+	EnableWebhooksEnv = "ENABLE_WEBHOOKS"
+	// Environment variable holding the operator image, which the config-reload sidecar and the events collector run
+	OperatorImageEnv = "OPERATOR_IMAGE"
 )
 
 var (
@@ -63,12 +72,31 @@ func printVersion() {
 func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 	utilruntime.Must(splunkforwarderv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(splunkforwarderv1beta1.AddToScheme(scheme))
 	utilruntime.Must(configv1.Install(scheme))
+	utilruntime.Must(operatorv1alpha1.Install(scheme))
 	utilruntime.Must(monitoringv1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
 func main() {
+	// The render subcommand prints the generated objects for a CR without starting the manager
+	if len(os.Args) > 1 && os.Args[1] == "render" {
+		os.Exit(render.Run(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	// The diff subcommand prints what applying a CR changes in the live objects
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		os.Exit(render.RunDiff(os.Args[2:], os.Stdout, os.Stderr, render.NewClient))
+	}
+	// The reload subcommand is the config-reload sidecar of the forwarders
+	if len(os.Args) > 1 && os.Args[1] == "reload" {
+		os.Exit(reload.Run(os.Args[2:], os.Stdout, os.Stderr))
+	}
+	// The events subcommand is the events collector run by the operator in its own Deployment
+	if len(os.Args) > 1 && os.Args[1] == "events" {
+		os.Exit(events.Run(os.Args[2:], os.Stdout, os.Stderr))
+	}
+
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
@@ -133,20 +161,21 @@ func main() {
 
 	// Add SplunkForwarder controller to manager
 	if err = (&splunkforwarder.SplunkForwarderReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
+		Client:        mgr.GetClient(),
+		Scheme:        mgr.GetScheme(),
+		OperatorImage: os.Getenv(OperatorImageEnv),
+		ImageResolver: registry.NewResolver(),
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "SplunkForwarder")
 		os.Exit(1)
 	}
 
-	// Add Secret controller to manager
-	if err = (&secret.SecretReconciler{
-		Client: mgr.GetClient(),
-		Scheme: mgr.GetScheme(),
-	}).SetupWithManager(mgr); err != nil {
-		setupLog.Error(err, "unable to create controller", "controller", "Secret")
-		os.Exit(1)
+	// Add the SplunkForwarder conversion webhook, served when the serving certificate is mounted
+	if os.Getenv(EnableWebhooksEnv) == "true" {
+		if err = ctrl.NewWebhookManagedBy(mgr, &splunkforwarderv1beta1.SplunkForwarder{}).Complete(); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "SplunkForwarder")
+			os.Exit(1)
+		}
 	}
 
 	//+kubebuilder:scaffold:builder
@@ -0,0 +1,308 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"syscall"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/tools/cache"
+	ctrlconfig "sigs.k8s.io/controller-runtime/pkg/client/config"
+)
+
+const (
+	// DefaultDir is where the collector writes its files, in a volume shared with the forwarder
+	DefaultDir = "/var/log/kube-events"
+	// EventsFile is the file the Events are written to
+	EventsFile = "events.log"
+	// ObjectsFile is the file the objects of the selected resources are written to
+	ObjectsFile = "objects.log"
+)
+
+// resource is a resource whose objects can be collected
+type resource struct {
+	kind          string
+	clusterScoped bool
+	informer      func(informers.SharedInformerFactory) cache.SharedIndexInformer
+}
+
+// resources are the resources whose objects can be collected, by their name in the CR. The collector
+// ClusterRole allows listing and watching them.
+var resources = map[string]resource{
+	"Nodes": {kind: "Node", clusterScoped: true, informer: func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().Nodes().Informer()
+	}},
+	"Pods": {kind: "Pod", informer: func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().Pods().Informer()
+	}},
+	"PersistentVolumeClaims": {kind: "PersistentVolumeClaim", informer: func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Core().V1().PersistentVolumeClaims().Informer()
+	}},
+	"Deployments": {kind: "Deployment", informer: func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Apps().V1().Deployments().Informer()
+	}},
+	"DaemonSets": {kind: "DaemonSet", informer: func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Apps().V1().DaemonSets().Informer()
+	}},
+	"StatefulSets": {kind: "StatefulSet", informer: func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Apps().V1().StatefulSets().Informer()
+	}},
+	"Jobs": {kind: "Job", informer: func(f informers.SharedInformerFactory) cache.SharedIndexInformer {
+		return f.Batch().V1().Jobs().Informer()
+	}},
+}
+
+// record is a line written by the collector
+type record struct {
+	Action string      `json:"action"`
+	Kind   string      `json:"kind"`
+	Object interface{} `json:"object"`
+}
+
+// recordWriter writes records as JSON lines. The informers call it concurrently.
+type recordWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+func (w *recordWriter) write(action, kind string, obj interface{}) {
+	// The managed fields are large and of no use in Splunk. The objects are shared with the informer
+	// cache, so they are copied first.
+	if object, ok := obj.(runtime.Object); ok {
+		object = object.DeepCopyObject()
+		if accessor, ok := object.(metav1.Object); ok {
+			accessor.SetManagedFields(nil)
+		}
+		obj = object
+	}
+	data, err := json.Marshal(record{Action: action, Kind: kind, Object: obj})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "unable to marshal %s: %v\n", kind, err)
+		return
+	}
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if _, err := w.w.Write(append(data, '\n')); err != nil {
+		fmt.Fprintf(os.Stderr, "unable to write %s: %v\n", kind, err)
+	}
+}
+
+// Collector writes the Events of the cluster, and the objects of the selected resources, as JSON lines
+type Collector struct {
+	Client     kubernetes.Interface
+	Namespaces []string
+	Resources  []string
+	Events     io.Writer
+	Objects    io.Writer
+}
+
+// Start starts watching the Events and the objects, and returns once the informers are synced. The
+// Events that exist at start are not written, as they were collected before a restart of the collector.
+// The objects are, so that their state is known.
+func (c *Collector) Start(ctx context.Context) error {
+	for _, name := range c.Resources {
+		if _, ok := resources[name]; !ok {
+			return fmt.Errorf("unknown resource %q", name)
+		}
+	}
+	events := &recordWriter{w: c.Events}
+	objects := &recordWriter{w: c.Objects}
+
+	namespaces := c.Namespaces
+	if len(namespaces) == 0 {
+		namespaces = []string{metav1.NamespaceAll}
+	}
+	factories := []informers.SharedInformerFactory{}
+	for i, namespace := range namespaces {
+		factory := informers.NewSharedInformerFactoryWithOptions(c.Client, 0, informers.WithNamespace(namespace))
+		factories = append(factories, factory)
+
+		_, err := factory.Core().V1().Events().Informer().AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+			AddFunc: func(obj interface{}, isInInitialList bool) {
+				if !isInInitialList {
+					events.write("ADDED", "Event", obj)
+				}
+			},
+			UpdateFunc: func(_, obj interface{}) {
+				events.write("MODIFIED", "Event", obj)
+			},
+		})
+		if err != nil {
+			return err
+		}
+
+		for _, name := range c.Resources {
+			res := resources[name]
+			// The cluster scoped resources are only watched once
+			if res.clusterScoped && i > 0 {
+				continue
+			}
+			_, err := res.informer(factory).AddEventHandler(cache.ResourceEventHandlerFuncs{
+				AddFunc: func(obj interface{}) {
+					objects.write("ADDED", res.kind, obj)
+				},
+				UpdateFunc: func(old, obj interface{}) {
+					if old.(metav1.Object).GetResourceVersion() == obj.(metav1.Object).GetResourceVersion() {
+						return
+					}
+					objects.write("MODIFIED", res.kind, obj)
+				},
+				DeleteFunc: func(obj interface{}) {
+					if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+						obj = tombstone.Obj
+					}
+					objects.write("DELETED", res.kind, obj)
+				},
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	for _, factory := range factories {
+		factory.Start(ctx.Done())
+	}
+	for _, factory := range factories {
+		for informer, synced := range factory.WaitForCacheSync(ctx.Done()) {
+			if !synced {
+				return fmt.Errorf("unable to sync the %v informer", informer)
+			}
+		}
+	}
+	return nil
+}
+
+// logFile is a file that is rotated to <path>.1 when it grows over maxSize, so that the collector does
+// not fill its volume. The forwarder follows the rotation.
+type logFile struct {
+	path    string
+	maxSize int64
+	file    *os.File
+	size    int64
+}
+
+func openLogFile(path string, maxSize int64) (*logFile, error) {
+	f := &logFile{path: path, maxSize: maxSize}
+	return f, f.open()
+}
+
+func (f *logFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return err
+	}
+	info, err := file.Stat()
+	if err != nil {
+		file.Close()
+		return err
+	}
+	f.file, f.size = file, info.Size()
+	return nil
+}
+
+func (f *logFile) Write(data []byte) (int, error) {
+	if f.size > 0 && f.size+int64(len(data)) > f.maxSize {
+		if err := f.file.Close(); err != nil {
+			return 0, err
+		}
+		if err := os.Rename(f.path, f.path+".1"); err != nil {
+			return 0, err
+		}
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := f.file.Write(data)
+	f.size += int64(n)
+	return n, err
+}
+
+// splitList splits a comma separated flag value
+func splitList(value string) []string {
+	ret := []string{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			ret = append(ret, item)
+		}
+	}
+	return ret
+}
+
+// Run implements the events subcommand. It returns the exit code of the command.
+func Run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("events", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	names := make([]string, 0, len(resources))
+	for name := range resources {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: events [flags]\n\nWrites the Events of the cluster, and the objects of the selected resources, as JSON lines for the forwarder.\n\n")
+		fs.PrintDefaults()
+	}
+	var dir, namespaces, selected string
+	var maxSize int64
+	fs.StringVar(&dir, "dir", DefaultDir, "Directory the "+EventsFile+" and "+ObjectsFile+" files are written to")
+	fs.StringVar(&namespaces, "namespaces", "", "Comma separated namespaces to collect (default all namespaces)")
+	fs.StringVar(&selected, "resources", "", "Comma separated resources whose objects are collected, among "+strings.Join(names, ", "))
+	fs.Int64Var(&maxSize, "max-file-size", 100, "Size in MiB at which the files are rotated")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+
+	cfg, err := ctrlconfig.GetConfig()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	client, err := kubernetes.NewForConfig(cfg)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	eventsFile, err := openLogFile(filepath.Join(dir, EventsFile), maxSize<<20)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	objectsFile, err := openLogFile(filepath.Join(dir, ObjectsFile), maxSize<<20)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	collector := &Collector{
+		Client:     client,
+		Namespaces: splitList(namespaces),
+		Resources:  splitList(selected),
+		Events:     eventsFile,
+		Objects:    objectsFile,
+	}
+	if err := collector.Start(ctx); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	fmt.Fprintln(stdout, "Collecting events")
+	<-ctx.Done()
+	return 0
+}
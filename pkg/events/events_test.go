@@ -0,0 +1,123 @@
+package events
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+// syncBuffer is a bytes.Buffer that can be read while the collector writes to it
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) lines() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return strings.Split(strings.TrimSuffix(b.buf.String(), "\n"), "\n")
+}
+
+// waitForLines waits for the buffer to hold n lines
+func waitForLines(t *testing.T, b *syncBuffer, n int) []string {
+	t.Helper()
+	for i := 0; i < 50; i++ {
+		if lines := b.lines(); len(lines) >= n && lines[0] != "" {
+			return lines
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for %d lines, got %q", n, b.lines())
+	return nil
+}
+
+func TestCollector(t *testing.T) {
+	client := fake.NewClientset(
+		&corev1.Event{ObjectMeta: metav1.ObjectMeta{Name: "old", Namespace: "app"}, Reason: "Pulled"},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "app"}},
+		&corev1.Pod{ObjectMeta: metav1.ObjectMeta{Name: "other", Namespace: "other"}},
+	)
+	events, objects := &syncBuffer{}, &syncBuffer{}
+	collector := &Collector{
+		Client:     client,
+		Namespaces: []string{"app"},
+		Resources:  []string{"Pods"},
+		Events:     events,
+		Objects:    objects,
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	if err := collector.Start(ctx); err != nil {
+		t.Fatalf("Start() error = %v", err)
+	}
+
+	// The objects of the namespace are written on start
+	lines := waitForLines(t, objects, 1)
+	got := record{}
+	if err := json.Unmarshal([]byte(lines[0]), &got); err != nil {
+		t.Fatalf("unable to read %q: %v", lines[0], err)
+	}
+	if got.Action != "ADDED" || got.Kind != "Pod" || got.Object.(map[string]interface{})["metadata"].(map[string]interface{})["name"] != "web" {
+		t.Errorf("object record = %q, want the web pod added", lines[0])
+	}
+
+	// The Events are written as they come, not the ones that existed on start
+	event := &corev1.Event{
+		ObjectMeta:     metav1.ObjectMeta{Name: "new", Namespace: "app"},
+		Reason:         "FailedScheduling",
+		Message:        "0/3 nodes are available",
+		InvolvedObject: corev1.ObjectReference{Kind: "Pod", Name: "web"},
+	}
+	if _, err := client.CoreV1().Events("app").Create(ctx, event, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("unable to create event: %v", err)
+	}
+	lines = waitForLines(t, events, 1)
+	if len(lines) != 1 || !strings.Contains(lines[0], `"action":"ADDED","kind":"Event"`) || !strings.Contains(lines[0], `"reason":"FailedScheduling"`) {
+		t.Errorf("event records = %q, want only the new event", lines)
+	}
+}
+
+func TestCollectorUnknownResource(t *testing.T) {
+	collector := &Collector{Client: fake.NewClientset(), Resources: []string{"Secrets"}}
+	if err := collector.Start(context.Background()); err == nil || !strings.Contains(err.Error(), "unknown resource") {
+		t.Errorf("Start() error = %v, want an unknown resource error", err)
+	}
+}
+
+func TestLogFileRotation(t *testing.T) {
+	path := filepath.Join(t.TempDir(), EventsFile)
+	f, err := openLogFile(path, 10)
+	if err != nil {
+		t.Fatalf("openLogFile() error = %v", err)
+	}
+	for _, line := range []string{"first\n", "second\n"} {
+		if _, err := f.Write([]byte(line)); err != nil {
+			t.Fatalf("Write() error = %v", err)
+		}
+	}
+	for file, want := range map[string]string{path + ".1": "first\n", path: "second\n"} {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("unable to read %s: %v", file, err)
+		}
+		if string(data) != want {
+			t.Errorf("%s = %q, want %q", file, data, want)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package kube
+
+import (
+	"strconv"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// appsDir is where the Splunk apps are loaded from in the forwarder container
+const appsDir = "/opt/splunkforwarder/etc/apps/"
+
+// appVolumeName returns the name of the volume of the i-th app. The app names are not valid volume
+// names, so the volumes are named after their position.
+func appVolumeName(i int) string {
+	return "app-" + strconv.Itoa(i)
+}
+
+// getAppVolumes returns the volumes of the extra apps of the CR
+func getAppVolumes(instance *sfv1alpha1.SplunkForwarder) []corev1.Volume {
+	volumes := []corev1.Volume{}
+	for i, app := range instance.Spec.Apps {
+		volume := corev1.Volume{Name: appVolumeName(i)}
+		switch {
+		case app.ConfigMap != "":
+			volume.ConfigMap = &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: app.ConfigMap,
+				},
+			}
+		case app.Secret != "":
+			volume.Secret = &corev1.SecretVolumeSource{
+				SecretName: app.Secret,
+			}
+		default:
+			volume.Image = &corev1.ImageVolumeSource{
+				Reference:  app.Image,
+				PullPolicy: corev1.PullPolicy(app.ImagePullPolicy),
+			}
+		}
+		volumes = append(volumes, volume)
+	}
+	return volumes
+}
+
+// getAppVolumeMounts returns where the extra apps are mounted: the ConfigMaps and Secrets hold the
+// default directory of their app, and the images the whole app directory
+func getAppVolumeMounts(instance *sfv1alpha1.SplunkForwarder) []corev1.VolumeMount {
+	volumeMounts := []corev1.VolumeMount{}
+	for i, app := range instance.Spec.Apps {
+		mountPath := appsDir + app.Name
+		if app.Image == "" {
+			mountPath += "/default"
+		}
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      appVolumeName(i),
+			MountPath: mountPath,
+			ReadOnly:  true,
+		})
+	}
+	return volumeMounts
+}
+
+// AppsHash returns a stable hash of the content of the ConfigMaps and Secrets of the extra apps, given
+// by app name, used to roll the forwarders when an app changes. Splunk only loads the apps on start.
+func AppsHash(data map[string]map[string][]byte) string {
+	flat := map[string][]byte{}
+	for app, files := range data {
+		for file, content := range files {
+			flat[app+"/"+file] = content
+		}
+	}
+	return DataHash(flat)
+}
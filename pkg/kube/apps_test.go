@@ -0,0 +1,72 @@
+package kube
+
+import (
+	"testing"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestAppVolumes(t *testing.T) {
+	instance := splunkForwarderInstance(true)
+	instance.Spec.Apps = []sfv1alpha1.SplunkApp{
+		{Name: "Splunk_TA_nix", ConfigMap: "ta-nix"},
+		{Name: "TA-secrets", Secret: "ta-secrets"},
+		{Name: "TA-oci", Image: "quay.io/example/ta-oci:1.0", ImagePullPolicy: "IfNotPresent"},
+	}
+
+	volumes := map[string]corev1.Volume{}
+	for _, volume := range GetVolumes(instance, true, true, false) {
+		volumes[volume.Name] = volume
+	}
+	if v := volumes["app-0"]; v.ConfigMap == nil || v.ConfigMap.Name != "ta-nix" {
+		t.Errorf("app-0 volume = %+v, want the ta-nix ConfigMap", v)
+	}
+	if v := volumes["app-1"]; v.Secret == nil || v.Secret.SecretName != "ta-secrets" {
+		t.Errorf("app-1 volume = %+v, want the ta-secrets Secret", v)
+	}
+	if v := volumes["app-2"]; v.Image == nil || v.Image.Reference != "quay.io/example/ta-oci:1.0" || v.Image.PullPolicy != corev1.PullIfNotPresent {
+		t.Errorf("app-2 volume = %+v, want the ta-oci image", v)
+	}
+
+	mounts := map[string]corev1.VolumeMount{}
+	for _, mount := range GetVolumeMounts(instance, false) {
+		mounts[mount.Name] = mount
+	}
+	wantPaths := map[string]string{
+		"app-0": "/opt/splunkforwarder/etc/apps/Splunk_TA_nix/default",
+		"app-1": "/opt/splunkforwarder/etc/apps/TA-secrets/default",
+		"app-2": "/opt/splunkforwarder/etc/apps/TA-oci",
+	}
+	for name, path := range wantPaths {
+		if mount, ok := mounts[name]; !ok || mount.MountPath != path || !mount.ReadOnly {
+			t.Errorf("%s mount = %+v, want %s read-only", name, mount, path)
+		}
+	}
+
+	// The apps are part of the pod template, so adding one rolls the forwarders
+	ds := GenerateDaemonSet(instance, false, operatorImage)
+	instance.Spec.Apps = instance.Spec.Apps[:2]
+	if GenerateDaemonSet(instance, false, operatorImage).Annotations[TemplateHashAnnotation] == ds.Annotations[TemplateHashAnnotation] {
+		t.Errorf("template hash did not change with the apps")
+	}
+}
+
+func TestAppsHash(t *testing.T) {
+	hash := AppsHash(map[string]map[string][]byte{
+		"a": {"inputs.conf": []byte("x")},
+		"b": {"props.conf": []byte("y")},
+	})
+	if hash != AppsHash(map[string]map[string][]byte{
+		"b": {"props.conf": []byte("y")},
+		"a": {"inputs.conf": []byte("x")},
+	}) {
+		t.Errorf("AppsHash() is not stable")
+	}
+	if hash == AppsHash(map[string]map[string][]byte{
+		"a": {"inputs.conf": []byte("x")},
+		"b": {"props.conf": []byte("z")},
+	}) {
+		t.Errorf("AppsHash() did not change with the content")
+	}
+}
@@ -0,0 +1,98 @@
+package kube
+
+import (
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// ClusterState holds what the forwarder objects of a CR are generated from besides the CR. The operator
+// and the diff subcommand look it up from the cluster (see pkg/lookup), the render subcommand takes it
+// from its flags.
+type ClusterState struct {
+	// Cluster metadata added to the events
+	ClusterMetadata ClusterMetadata
+	// Workloads, and pods without one, selected by the podLogs inputs
+	PodLogs []PodLogTarget
+	// Roles of the nodes, one entry per distinct set of roles, which give the nodes with several of the
+	// roles of the inputs their own forwarders
+	NodeRoles [][]string
+	// Whether the splunk-hec-token Secret is present, selecting the HEC mode of the forwarders
+	UseHECToken bool
+	// Egress proxy of the cluster
+	Proxy ClusterProxy
+	// Whether the cluster is installed in FIPS mode, followed by the CRs in Auto FIPS mode
+	FIPS bool
+	// TLS security profile of the cluster
+	TLSProfile TLSProfile
+	// Hash of the Secret the forwarders authenticate with, splunk-hec-token in HEC mode and splunk-auth
+	// otherwise, when the CR does not reference an issuer
+	AuthHash string
+	// Hash of the key pair issued by cert-manager, when the CR references an issuer
+	CertificateHash string
+	// Hash of the content of the extra apps taken from ConfigMaps and Secrets
+	AppsHash string
+	// Hash of the injected trusted CA bundle, when the CR trusts it
+	TrustedCABundleHash string
+}
+
+// BuildConfigMaps returns the forwarder ConfigMaps of the CR with the proxy and TLS settings of the cluster
+func BuildConfigMaps(instance *sfv1alpha1.SplunkForwarder, state ClusterState) []*corev1.ConfigMap {
+	namespacedName := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}
+	configMaps := generateConfigMaps(instance, namespacedName, state.ClusterMetadata, state.PodLogs, state.NodeRoles)
+	ApplyProxyToConfigMaps(configMaps, state.Proxy)
+	ApplyTLSToConfigMaps(configMaps, state.TLSProfile, FIPSEnabled(instance, state.FIPS))
+	return configMaps
+}
+
+// BuildDaemonSets returns the forwarder DaemonSets of the CR for the cluster, whose config-reload sidecar runs
+// the operator image. Without the operator image there is no sidecar. The hashes of the content the pods
+// mount, including the configuration files of the ConfigMaps but the monitor inputs the sidecar reloads, are
+// added to the pod template so that a change rolls the pods, and the template hash the operator compares to
+// decide on an update is set.
+func BuildDaemonSets(instance *sfv1alpha1.SplunkForwarder, state ClusterState, operatorImage string) []*appsv1.DaemonSet {
+	configMaps := BuildConfigMaps(instance, state)
+	daemonSets := generateDaemonSets(instance, state.UseHECToken, state.NodeRoles, operatorImage)
+	for _, daemonSet := range daemonSets {
+		templateAnnotations := map[string]string{
+			ConfigHashAnnotation: ConfigHash(&daemonSet.Spec.Template, configMaps),
+		}
+		if state.AuthHash != "" {
+			templateAnnotations[AuthHashAnnotation] = state.AuthHash
+		}
+		if state.CertificateHash != "" {
+			templateAnnotations[CertificateHashAnnotation] = state.CertificateHash
+		}
+		if state.AppsHash != "" {
+			templateAnnotations[AppsHashAnnotation] = state.AppsHash
+		}
+		if state.TrustedCABundleHash != "" {
+			templateAnnotations[TrustedCABundleHashAnnotation] = state.TrustedCABundleHash
+		}
+		daemonSet.Spec.Template.Annotations = templateAnnotations
+		ApplyProxyToPodTemplate(&daemonSet.Spec.Template, state.Proxy)
+		ApplyFIPSToPodTemplate(&daemonSet.Spec.Template, FIPSEnabled(instance, state.FIPS))
+		ApplyTLSProfileToPodTemplate(&daemonSet.Spec.Template, state.TLSProfile)
+		if CanaryEnabled(instance) {
+			ExcludeCanaryNodes(&daemonSet.Spec.Template, instance.Spec.RolloutStrategy.Canary.NodeSelector)
+		}
+		daemonSet.Annotations[TemplateHashAnnotation] = TemplateHash(&daemonSet.Spec.Template)
+	}
+	return daemonSets
+}
+
+// BuildEventsCollectorDeployment returns the events collector Deployment of the CR for the cluster, running
+// the operator image unless the CR sets one. The hash of the inputs ConfigMap it mounts is added to the pod
+// template, as its forwarder only reads the inputs on start.
+func BuildEventsCollectorDeployment(instance *sfv1alpha1.SplunkForwarder, state ClusterState, operatorImage string) *appsv1.Deployment {
+	deployment := GenerateEventsCollectorDeployment(instance, state.UseHECToken, operatorImage)
+	deployment.Spec.Template.Annotations = map[string]string{
+		ConfigHashAnnotation: ConfigHash(&deployment.Spec.Template, BuildConfigMaps(instance, state)),
+	}
+	ApplyProxyToPodTemplate(&deployment.Spec.Template, state.Proxy)
+	ApplyFIPSToPodTemplate(&deployment.Spec.Template, FIPSEnabled(instance, state.FIPS))
+	ApplyTLSProfileToPodTemplate(&deployment.Spec.Template, state.TLSProfile)
+	deployment.Annotations[TemplateHashAnnotation] = TemplateHash(&deployment.Spec.Template)
+	return deployment
+}
@@ -0,0 +1,102 @@
+package kube
+
+import (
+	"testing"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+)
+
+func TestBuildDaemonSets(t *testing.T) {
+	instance := splunkForwarderInstance(true)
+	state := ClusterState{
+		Proxy:               ClusterProxy{HTTPSProxy: "http://proxy:3128"},
+		FIPS:                true,
+		AuthHash:            "auth",
+		CertificateHash:     "cert",
+		AppsHash:            "apps",
+		TrustedCABundleHash: "ca",
+	}
+
+	daemonSets := BuildDaemonSets(instance, state, operatorImage)
+	if len(daemonSets) != 1 {
+		t.Fatalf("BuildDaemonSets() returned %d DaemonSets, want 1", len(daemonSets))
+	}
+	template := &daemonSets[0].Spec.Template
+	for annotation, want := range map[string]string{
+		AuthHashAnnotation:            "auth",
+		CertificateHashAnnotation:     "cert",
+		AppsHashAnnotation:            "apps",
+		TrustedCABundleHashAnnotation: "ca",
+	} {
+		if got := template.Annotations[annotation]; got != want {
+			t.Errorf("template annotation %s = %q, want %q", annotation, got, want)
+		}
+	}
+	env := map[string]string{}
+	for _, e := range template.Spec.Containers[0].Env {
+		env[e.Name] = e.Value
+	}
+	if env["HTTPS_PROXY"] != "http://proxy:3128" || env["SPLUNK_FIPS"] != "1" {
+		t.Errorf("forwarder env = %v, want the proxy and SPLUNK_FIPS", env)
+	}
+	if got, want := daemonSets[0].Annotations[TemplateHashAnnotation], TemplateHash(template); got != want {
+		t.Errorf("template hash = %q, want %q", got, want)
+	}
+
+	// A change of the mounted content rolls the pods
+	state.AppsHash = "apps2"
+	if BuildDaemonSets(instance, state, operatorImage)[0].Annotations[TemplateHashAnnotation] == daemonSets[0].Annotations[TemplateHashAnnotation] {
+		t.Error("template hash did not change with the apps hash")
+	}
+
+	// splunkd reloads the inputs through the config-reload sidecar, other configuration files need a restart
+	hash := daemonSets[0].Spec.Template.Annotations[ConfigHashAnnotation]
+	instance.Spec.SplunkInputs = append(instance.Spec.SplunkInputs, sfv1alpha1.SplunkForwarderInputs{Path: "/var/log/other"})
+	if got := BuildDaemonSets(instance, state, operatorImage)[0].Spec.Template.Annotations[ConfigHashAnnotation]; got != hash {
+		t.Errorf("config hash changed with the inputs")
+	}
+	instance.Spec.Throughput = &sfv1alpha1.SplunkThroughput{MaxKBps: 512}
+	if got := BuildDaemonSets(instance, state, operatorImage)[0].Spec.Template.Annotations[ConfigHashAnnotation]; got == hash {
+		t.Errorf("config hash did not change with the limits.conf of the throughput")
+	}
+	if got := daemonSets[0].Spec.Template.Spec.Containers[1].Image; got != operatorImage {
+		t.Errorf("config-reload image = %q, want the operator image", got)
+	}
+
+	// The canary nodes are left to the canary DaemonSets
+	instance.Spec.RolloutStrategy = &sfv1alpha1.SplunkRolloutStrategy{
+		Canary: &sfv1alpha1.SplunkCanaryRollout{NodeSelector: map[string]string{"canary": "true"}},
+	}
+	if BuildDaemonSets(instance, state, operatorImage)[0].Spec.Template.Spec.Affinity == nil {
+		t.Error("canary nodes are not excluded")
+	}
+}
+
+func TestBuildEventsCollectorDeployment(t *testing.T) {
+	instance := splunkForwarderInstance(true)
+	instance.Spec.EventsCollector = &sfv1alpha1.SplunkEventsCollector{Enabled: true}
+	state := ClusterState{TLSProfile: TLSProfile{MinTLSVersion: "VersionTLS12"}}
+
+	deployment := BuildEventsCollectorDeployment(instance, state, operatorImage)
+	template := &deployment.Spec.Template
+	hash := template.Annotations[ConfigHashAnnotation]
+	if hash == "" {
+		t.Fatalf("template annotations = %v, want the config hash", template.Annotations)
+	}
+	if _, ok := template.Annotations[TLSProfileHashAnnotation]; !ok {
+		t.Errorf("template annotations = %v, want the TLS profile hash kept", template.Annotations)
+	}
+	if got, want := deployment.Annotations[TemplateHashAnnotation], TemplateHash(template); got != want {
+		t.Errorf("template hash = %q, want %q", got, want)
+	}
+
+	// The forwarder of the collector only reads its inputs on start, a new index rolls it
+	instance.Spec.EventsCollector.Index = "events"
+	changed := BuildEventsCollectorDeployment(instance, state, operatorImage)
+	if changed.Spec.Template.Annotations[ConfigHashAnnotation] == hash {
+		t.Error("config hash did not change with the index of the collector")
+	}
+	if changed.Annotations[TemplateHashAnnotation] == deployment.Annotations[TemplateHashAnnotation] {
+		t.Error("template hash did not change with the index of the collector")
+	}
+}
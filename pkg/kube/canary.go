@@ -0,0 +1,247 @@
+package kube
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"maps"
+	"reflect"
+	"slices"
+	"sort"
+	"time"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// canarySuffix is appended to the names of the canary ConfigMaps and DaemonSets, and of their pods
+	canarySuffix = "-canary"
+	// defaultCanarySoakDuration is how long the canary forwarders stay ready before a change is promoted
+	defaultCanarySoakDuration = 10 * time.Minute
+	// defaultCanaryProgressDeadline is how long the canary forwarders have to become ready
+	defaultCanaryProgressDeadline = 10 * time.Minute
+)
+
+// failingReasons are the waiting reasons of containers that do not recover until the pod template or the
+// image changes
+var failingReasons = map[string]bool{
+	"CrashLoopBackOff":           true,
+	"ErrImagePull":               true,
+	"ImagePullBackOff":           true,
+	"InvalidImageName":           true,
+	"CreateContainerConfigError": true,
+}
+
+// CanaryEnabled returns whether changes of the forwarders are rolled out to the canary nodes first
+func CanaryEnabled(instance *sfv1alpha1.SplunkForwarder) bool {
+	strategy := instance.Spec.RolloutStrategy
+	return strategy != nil && strategy.Canary != nil && len(strategy.Canary.NodeSelector) > 0
+}
+
+// CanarySoakDuration returns how long the canary forwarders have to stay ready before a change is promoted
+func CanarySoakDuration(instance *sfv1alpha1.SplunkForwarder) time.Duration {
+	if duration := instance.Spec.RolloutStrategy.Canary.SoakDuration; duration != nil {
+		return duration.Duration
+	}
+	return defaultCanarySoakDuration
+}
+
+// CanaryProgressDeadline returns how long the canary forwarders have to become ready before the canary fails
+func CanaryProgressDeadline(instance *sfv1alpha1.SplunkForwarder) time.Duration {
+	if deadline := instance.Spec.RolloutStrategy.Canary.ProgressDeadline; deadline != nil {
+		return deadline.Duration
+	}
+	return defaultCanaryProgressDeadline
+}
+
+// ExcludeCanaryNodes keeps the pods of a forwarder DaemonSet off the canary nodes, which run the canary
+// DaemonSets. A node is excluded when it has every label of the canary node selector, so each term of the
+// node affinity is split into one term per label the node must not have.
+func ExcludeCanaryNodes(template *corev1.PodTemplateSpec, nodeSelector map[string]string) {
+	if len(nodeSelector) == 0 {
+		return
+	}
+	terms := []corev1.NodeSelectorTerm{{}}
+	if affinity := template.Spec.Affinity; affinity != nil && affinity.NodeAffinity != nil && affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil {
+		terms = affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	}
+
+	excluded := []corev1.NodeSelectorTerm{}
+	for _, term := range terms {
+		for _, key := range slices.Sorted(maps.Keys(nodeSelector)) {
+			term := *term.DeepCopy()
+			term.MatchExpressions = append(term.MatchExpressions, corev1.NodeSelectorRequirement{
+				Key:      key,
+				Operator: corev1.NodeSelectorOpNotIn,
+				Values:   []string{nodeSelector[key]},
+			})
+			excluded = append(excluded, term)
+		}
+	}
+
+	if template.Spec.Affinity == nil {
+		template.Spec.Affinity = &corev1.Affinity{}
+	}
+	if template.Spec.Affinity.NodeAffinity == nil {
+		template.Spec.Affinity.NodeAffinity = &corev1.NodeAffinity{}
+	}
+	template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = &corev1.NodeSelector{NodeSelectorTerms: excluded}
+}
+
+// includeCanaryNodes reverts ExcludeCanaryNodes, so that the pods can be scheduled onto the canary nodes
+func includeCanaryNodes(template *corev1.PodTemplateSpec, nodeSelector map[string]string) {
+	affinity := template.Spec.Affinity
+	if affinity == nil || affinity.NodeAffinity == nil || affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution == nil {
+		return
+	}
+
+	terms := []corev1.NodeSelectorTerm{}
+	restricted := false
+	for _, term := range affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms {
+		included := corev1.NodeSelectorTerm{MatchFields: term.MatchFields}
+		for _, expression := range term.MatchExpressions {
+			value, ok := nodeSelector[expression.Key]
+			if ok && expression.Operator == corev1.NodeSelectorOpNotIn && slices.Equal(expression.Values, []string{value}) {
+				continue
+			}
+			included.MatchExpressions = append(included.MatchExpressions, expression)
+		}
+		if slices.ContainsFunc(terms, func(term corev1.NodeSelectorTerm) bool { return reflect.DeepEqual(term, included) }) {
+			continue
+		}
+		restricted = restricted || len(included.MatchExpressions) > 0 || len(included.MatchFields) > 0
+		terms = append(terms, included)
+	}
+
+	if !restricted {
+		affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution = nil
+		if reflect.DeepEqual(*affinity.NodeAffinity, corev1.NodeAffinity{}) && affinity.PodAffinity == nil && affinity.PodAntiAffinity == nil {
+			template.Spec.Affinity = nil
+		}
+		return
+	}
+	affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms = terms
+}
+
+// GenerateCanaryObjects returns the canary copies of forwarder ConfigMaps and DaemonSets. The canary
+// DaemonSets run on the canary nodes only and mount the canary copies of the ConfigMaps, so that both the
+// pod template and the configuration of the forwarders can be tested there first. Only the ConfigMaps the
+// DaemonSets mount are copied.
+func GenerateCanaryObjects(configMaps []*corev1.ConfigMap, daemonSets []*appsv1.DaemonSet, nodeSelector map[string]string) ([]*corev1.ConfigMap, []*appsv1.DaemonSet) {
+	mounted := map[string]bool{}
+	for _, daemonSet := range daemonSets {
+		for _, volume := range daemonSet.Spec.Template.Spec.Volumes {
+			if volume.ConfigMap != nil {
+				mounted[volume.ConfigMap.Name] = true
+			}
+		}
+	}
+
+	canaryConfigMaps := []*corev1.ConfigMap{}
+	copied := map[string]bool{}
+	for _, configMap := range configMaps {
+		if !mounted[configMap.Name] {
+			continue
+		}
+		copied[configMap.Name] = true
+		canaryConfigMaps = append(canaryConfigMaps, &corev1.ConfigMap{
+			ObjectMeta: canaryObjectMeta(configMap.ObjectMeta),
+			Data:       maps.Clone(configMap.Data),
+		})
+	}
+
+	canaryDaemonSets := []*appsv1.DaemonSet{}
+	for _, daemonSet := range daemonSets {
+		canary := &appsv1.DaemonSet{
+			ObjectMeta: canaryObjectMeta(daemonSet.ObjectMeta),
+			Spec:       *daemonSet.Spec.DeepCopy(),
+		}
+		selector := canary.Spec.Selector.MatchLabels
+		podName := selector["name"] + canarySuffix
+		selector["name"] = podName
+		template := &canary.Spec.Template
+		template.Name = podName
+		template.Labels["name"] = podName
+		includeCanaryNodes(template, nodeSelector)
+		if template.Spec.NodeSelector == nil {
+			template.Spec.NodeSelector = map[string]string{}
+		}
+		maps.Copy(template.Spec.NodeSelector, nodeSelector)
+		for _, volume := range template.Spec.Volumes {
+			if volume.ConfigMap != nil && copied[volume.ConfigMap.Name] {
+				volume.ConfigMap.Name += canarySuffix
+			}
+		}
+		canary.Annotations[TemplateHashAnnotation] = TemplateHash(template)
+		canaryDaemonSets = append(canaryDaemonSets, canary)
+	}
+	return canaryConfigMaps, canaryDaemonSets
+}
+
+// canaryObjectMeta returns the metadata of the canary copy of an object, which may be a live object
+func canaryObjectMeta(meta metav1.ObjectMeta) metav1.ObjectMeta {
+	labels := maps.Clone(meta.Labels)
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[CanaryLabel] = "true"
+	annotations := maps.Clone(meta.Annotations)
+	if annotations == nil {
+		annotations = map[string]string{}
+	}
+	return metav1.ObjectMeta{
+		Name:        meta.Name + canarySuffix,
+		Namespace:   meta.Namespace,
+		Labels:      labels,
+		Annotations: annotations,
+	}
+}
+
+// RolloutConfigData returns the data of a ConfigMap a rollout of the forwarders is made of. The podLogs stanzas
+// follow the workloads of the cluster, so they are left out for the pods coming and going not to start or
+// restart the canary.
+func RolloutConfigData(configMap *corev1.ConfigMap) map[string]string {
+	data := maps.Clone(configMap.Data)
+	if inputs, ok := data["inputs.conf"]; ok {
+		data["inputs.conf"] = withoutPodLogsInputs(inputs)
+	}
+	return data
+}
+
+// RolloutRevision returns a hash of the rollout data of the ConfigMaps and of the pod templates of the
+// DaemonSets, which identifies a change of the forwarders
+func RolloutRevision(configMaps []*corev1.ConfigMap, daemonSets []*appsv1.DaemonSet) string {
+	revision := map[string]any{}
+	for _, configMap := range configMaps {
+		revision["ConfigMap/"+configMap.Name] = RolloutConfigData(configMap)
+	}
+	for _, daemonSet := range daemonSets {
+		revision["DaemonSet/"+daemonSet.Name] = daemonSet.Annotations[TemplateHashAnnotation]
+	}
+	// Maps are marshalled with sorted keys
+	data, err := json.Marshal(revision)
+	if err != nil {
+		// Strings always marshal
+		panic(err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// FailingPods returns, in order, the pod/container: reason of the containers that are crash-looping or
+// cannot be started, and do not recover without a change
+func FailingPods(pods []corev1.Pod) []string {
+	ret := []string{}
+	for _, pod := range pods {
+		for _, status := range slices.Concat(pod.Status.InitContainerStatuses, pod.Status.ContainerStatuses) {
+			if status.State.Waiting != nil && failingReasons[status.State.Waiting.Reason] {
+				ret = append(ret, pod.Name+"/"+status.Name+": "+status.State.Waiting.Reason)
+			}
+		}
+	}
+	sort.Strings(ret)
+	return ret
+}
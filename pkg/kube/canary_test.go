@@ -0,0 +1,139 @@
+package kube
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestGenerateCanaryObjects(t *testing.T) {
+	instance := splunkForwarderInstance(true)
+	instance.Spec.SplunkInputs = []sfv1alpha1.SplunkForwarderInputs{
+		{Path: "/var/log/all"},
+		{Path: "/var/log/infra", NodeRoles: []string{"infra"}},
+	}
+	nodeSelector := map[string]string{"canary": "true", "zone": "a"}
+	namespacedName := types.NamespacedName{Namespace: instanceNamespace, Name: instanceName}
+	configMaps := GenerateConfigMaps(instance, namespacedName, ClusterMetadata{ClusterID: "test"}, nil)
+	daemonSets := GenerateDaemonSets(instance, true, operatorImage)
+	affinities := []*corev1.Affinity{daemonSets[0].Spec.Template.Spec.Affinity.DeepCopy(), daemonSets[1].Spec.Template.Spec.Affinity.DeepCopy()}
+	for _, ds := range daemonSets {
+		ExcludeCanaryNodes(&ds.Spec.Template, nodeSelector)
+	}
+
+	// A node is excluded when it has every canary label
+	terms := daemonSets[0].Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+	if len(terms) != 2 || len(terms[0].MatchExpressions) != 2 || terms[0].MatchExpressions[1].Key != "canary" || terms[1].MatchExpressions[1].Key != "zone" {
+		t.Fatalf("node selector terms = %v, want one term per canary label", terms)
+	}
+	if op := terms[0].MatchExpressions[1].Operator; op != corev1.NodeSelectorOpNotIn {
+		t.Errorf("canary requirement operator = %s, want NotIn", op)
+	}
+
+	canaryConfigMaps, canaryDaemonSets := GenerateCanaryObjects(configMaps, daemonSets, nodeSelector)
+	wantConfigMaps := []string{"osd-monitored-logs-metadata-canary", "osd-monitored-logs-local-canary", "osd-monitored-logs-local-infra-canary"}
+	if len(canaryConfigMaps) != len(wantConfigMaps) {
+		t.Fatalf("got %d canary ConfigMaps, want %v", len(canaryConfigMaps), wantConfigMaps)
+	}
+	for i, cm := range canaryConfigMaps {
+		if cm.Name != wantConfigMaps[i] || cm.Labels[CanaryLabel] != "true" || !reflect.DeepEqual(cm.Data, configMaps[i].Data) {
+			t.Errorf("canary ConfigMap %d = %s %v, want a copy named %s", i, cm.Name, cm.Labels, wantConfigMaps[i])
+		}
+	}
+
+	if len(canaryDaemonSets) != 2 {
+		t.Fatalf("got %d canary DaemonSets, want 2", len(canaryDaemonSets))
+	}
+	for i, ds := range canaryDaemonSets {
+		template := ds.Spec.Template
+		if ds.Name != daemonSets[i].Name+canarySuffix || ds.Labels[CanaryLabel] != "true" {
+			t.Errorf("canary DaemonSet = %s %v", ds.Name, ds.Labels)
+		}
+		if name := ds.Spec.Selector.MatchLabels["name"]; name != template.Labels["name"] || name != daemonSets[i].Spec.Selector.MatchLabels["name"]+canarySuffix {
+			t.Errorf("canary selector = %v, pod labels = %v", ds.Spec.Selector.MatchLabels, template.Labels)
+		}
+		for key, value := range daemonSets[i].Spec.Template.Spec.NodeSelector {
+			nodeSelector[key] = value
+		}
+		if !reflect.DeepEqual(template.Spec.NodeSelector, nodeSelector) {
+			t.Errorf("canary node selector = %v, want %v", template.Spec.NodeSelector, nodeSelector)
+		}
+		for _, volume := range template.Spec.Volumes {
+			if volume.ConfigMap != nil && volume.Name != "splunk-admin" && !strings.HasSuffix(volume.ConfigMap.Name, canarySuffix) {
+				t.Errorf("canary volume %s mounts ConfigMap %s", volume.Name, volume.ConfigMap.Name)
+			}
+		}
+		if ds.Annotations[TemplateHashAnnotation] != TemplateHash(&template) {
+			t.Errorf("canary template hash is not updated")
+		}
+		// The canary nodes are no longer excluded, only the node role affinity is left
+		if affinity := template.Spec.Affinity; !reflect.DeepEqual(affinity, affinities[i]) {
+			t.Errorf("canary affinity = %v, want %v", affinity, affinities[i])
+		}
+	}
+	// The generated objects are left as they are
+	if _, ok := daemonSets[0].Spec.Template.Spec.NodeSelector["canary"]; ok || configMaps[1].Name != "osd-monitored-logs-local" {
+		t.Errorf("GenerateCanaryObjects() changed the forwarder objects")
+	}
+}
+
+func TestRolloutRevision(t *testing.T) {
+	instance := splunkForwarderInstance(true)
+	namespacedName := types.NamespacedName{Namespace: instanceNamespace, Name: instanceName}
+	configMaps := GenerateConfigMaps(instance, namespacedName, ClusterMetadata{ClusterID: "test"}, nil)
+	daemonSets := GenerateDaemonSets(instance, true, operatorImage)
+	revision := RolloutRevision(configMaps, daemonSets)
+	if RolloutRevision(configMaps, daemonSets) != revision {
+		t.Errorf("RolloutRevision() is not stable")
+	}
+
+	// The pods of the podLogs workloads come and go without a new revision
+	instance.Spec.PodLogs = []sfv1alpha1.SplunkPodLogsInput{{Name: "app", Namespace: "app"}}
+	configMaps = GenerateConfigMaps(instance, namespacedName, ClusterMetadata{ClusterID: "test"}, nil)
+	revision = RolloutRevision(configMaps, daemonSets)
+	podLogs := PodLogTargets(instance.Spec.PodLogs[0], []metav1.ObjectMeta{{Name: "web-0", Namespace: "app"}})
+	withPodLogs := GenerateConfigMaps(instance, namespacedName, ClusterMetadata{ClusterID: "test"}, podLogs)
+	if !strings.Contains(withPodLogs[1].Data["inputs.conf"], "[monitor://"+podLogs[0].Path()+"]") {
+		t.Fatalf("inputs.conf = %q, want the podLogs stanza", withPodLogs[1].Data["inputs.conf"])
+	}
+	if RolloutRevision(withPodLogs, daemonSets) != revision {
+		t.Errorf("RolloutRevision() changed with the podLogs targets")
+	}
+	if !reflect.DeepEqual(RolloutConfigData(withPodLogs[1]), RolloutConfigData(configMaps[1])) {
+		t.Errorf("RolloutConfigData() changed with the podLogs targets")
+	}
+
+	configMaps[1].Data["inputs.conf"] += "\n"
+	if RolloutRevision(configMaps, daemonSets) == revision {
+		t.Errorf("RolloutRevision() does not change with the ConfigMaps")
+	}
+	revision = RolloutRevision(configMaps, daemonSets)
+	daemonSets = GenerateDaemonSets(splunkForwarderInstance(false), true, operatorImage)
+	if RolloutRevision(configMaps, daemonSets) == revision {
+		t.Errorf("RolloutRevision() does not change with the pod templates")
+	}
+}
+
+func TestFailingPods(t *testing.T) {
+	waiting := func(name, reason string) corev1.ContainerStatus {
+		return corev1.ContainerStatus{Name: name, State: corev1.ContainerState{Waiting: &corev1.ContainerStateWaiting{Reason: reason}}}
+	}
+	pods := []corev1.Pod{
+		{Status: corev1.PodStatus{ContainerStatuses: []corev1.ContainerStatus{waiting("splunk-uf", "ContainerCreating")}}},
+		{Status: corev1.PodStatus{
+			InitContainerStatuses: []corev1.ContainerStatus{waiting("init-config", "ImagePullBackOff")},
+			ContainerStatuses:     []corev1.ContainerStatus{waiting("splunk-uf", "CrashLoopBackOff")},
+		}},
+	}
+	pods[0].Name = "starting"
+	pods[1].Name = "failing"
+	want := []string{"failing/init-config: ImagePullBackOff", "failing/splunk-uf: CrashLoopBackOff"}
+	if got := FailingPods(pods); !reflect.DeepEqual(got, want) {
+		t.Errorf("FailingPods() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,129 @@
+package kube
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"strconv"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// CertificateGVK is the cert-manager Certificate kind. cert-manager is an optional dependency,
+// so Certificates are handled as unstructured objects.
+var CertificateGVK = schema.GroupVersionKind{Group: "cert-manager.io", Version: "v1", Kind: "Certificate"}
+
+// CertificateName returns the name of the cert-manager Certificate requested for the forwarders
+func CertificateName(instance *sfv1alpha1.SplunkForwarder) string {
+	return instance.Name + "-forwarder"
+}
+
+// CertificateSecretName returns the name of the Secret cert-manager writes the issued key pair to
+func CertificateSecretName(instance *sfv1alpha1.SplunkForwarder) string {
+	return instance.Name + "-forwarder-tls"
+}
+
+// GenerateCertificate returns the cert-manager Certificate for the forwarder client certificate
+func GenerateCertificate(instance *sfv1alpha1.SplunkForwarder) *unstructured.Unstructured {
+	issuerRef := instance.Spec.CertificateIssuerRef
+
+	kind := issuerRef.Kind
+	if kind == "" {
+		kind = "Issuer"
+	}
+	group := issuerRef.Group
+	if group == "" {
+		group = CertificateGVK.Group
+	}
+
+	cert := &unstructured.Unstructured{}
+	cert.SetGroupVersionKind(CertificateGVK)
+	cert.SetName(CertificateName(instance))
+	cert.SetNamespace(instance.Namespace)
+	cert.SetLabels(map[string]string{
+		"app": instance.Name,
+	})
+	cert.SetAnnotations(map[string]string{
+		"genVersion": strconv.FormatInt(instance.Generation, 10),
+	})
+	cert.Object["spec"] = map[string]interface{}{
+		"secretName": CertificateSecretName(instance),
+		"commonName": instance.Name + "." + instance.Namespace,
+		"usages": []interface{}{
+			"client auth",
+			"digital signature",
+			"key encipherment",
+		},
+		"privateKey": map[string]interface{}{
+			"algorithm":      "RSA",
+			"size":           int64(2048),
+			"rotationPolicy": "Always",
+		},
+		"issuerRef": map[string]interface{}{
+			"name":  issuerRef.Name,
+			"kind":  kind,
+			"group": group,
+		},
+		// Labels the issued Secret so that renewals can be mapped back to the SplunkForwarder
+		"secretTemplate": map[string]interface{}{
+			"labels": map[string]interface{}{
+				CertificateInstanceLabel: instance.Name,
+			},
+		},
+	}
+
+	return cert
+}
+
+// GenerateSplunkAuthData assembles a key pair issued by cert-manager into the file layout of
+// the splunkauth app: server.pem holds the certificate followed by its key, and cacert.pem
+// holds the issuing CA. Issuers that do not publish their CA, such as ACME issuers, leave ca.crt
+// out; cacert.pem then holds the given trusted CA bundle.
+func GenerateSplunkAuthData(tlsSecret *corev1.Secret, trustedCABundle string) (map[string][]byte, error) {
+	for _, key := range []string{corev1.TLSCertKey, corev1.TLSPrivateKeyKey} {
+		if len(tlsSecret.Data[key]) == 0 {
+			return nil, fmt.Errorf("secret %s/%s is missing %s", tlsSecret.Namespace, tlsSecret.Name, key)
+		}
+	}
+	caCert := tlsSecret.Data["ca.crt"]
+	if len(caCert) == 0 {
+		if trustedCABundle == "" {
+			return nil, fmt.Errorf("secret %s/%s is missing ca.crt, and no trusted CA bundle is injected", tlsSecret.Namespace, tlsSecret.Name)
+		}
+		caCert = []byte(trustedCABundle)
+	}
+
+	serverPem := append([]byte{}, tlsSecret.Data[corev1.TLSCertKey]...)
+	if serverPem[len(serverPem)-1] != '\n' {
+		serverPem = append(serverPem, '\n')
+	}
+	serverPem = append(serverPem, tlsSecret.Data[corev1.TLSPrivateKeyKey]...)
+
+	return map[string][]byte{
+		"server.pem": serverPem,
+		"cacert.pem": caCert,
+	}, nil
+}
+
+// DataHash returns a stable hash of the given Secret data, used to roll the forwarders when
+// the data changes
+func DataHash(data map[string][]byte) string {
+	keys := make([]string, 0, len(data))
+	for key := range data {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	h := sha256.New()
+	for _, key := range keys {
+		h.Write([]byte(key))
+		h.Write([]byte{0})
+		h.Write(data[key])
+		h.Write([]byte{0})
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
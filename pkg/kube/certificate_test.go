@@ -0,0 +1,180 @@
+package kube
+
+import (
+	"reflect"
+	"testing"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestGenerateCertificate(t *testing.T) {
+	tests := []struct {
+		name          string
+		issuerRef     *sfv1alpha1.CertificateIssuerReference
+		wantIssuerRef map[string]interface{}
+	}{
+		{
+			name:      "Defaults to a namespaced cert-manager Issuer",
+			issuerRef: &sfv1alpha1.CertificateIssuerReference{Name: "splunk-ca"},
+			wantIssuerRef: map[string]interface{}{
+				"name":  "splunk-ca",
+				"kind":  "Issuer",
+				"group": "cert-manager.io",
+			},
+		},
+		{
+			name: "ClusterIssuer from another group",
+			issuerRef: &sfv1alpha1.CertificateIssuerReference{
+				Name:  "corporate-ca",
+				Kind:  "ClusterIssuer",
+				Group: "example.com",
+			},
+			wantIssuerRef: map[string]interface{}{
+				"name":  "corporate-ca",
+				"kind":  "ClusterIssuer",
+				"group": "example.com",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := splunkForwarderInstance(true)
+			instance.Spec.CertificateIssuerRef = tt.issuerRef
+
+			cert := GenerateCertificate(instance)
+
+			if cert.GroupVersionKind() != CertificateGVK {
+				t.Errorf("GenerateCertificate() kind = %v, want %v", cert.GroupVersionKind(), CertificateGVK)
+			}
+			if cert.GetName() != instanceName+"-forwarder" || cert.GetNamespace() != instanceNamespace {
+				t.Errorf("GenerateCertificate() name = %s/%s", cert.GetNamespace(), cert.GetName())
+			}
+			if cert.GetAnnotations()["genVersion"] != "10" {
+				t.Errorf("GenerateCertificate() genVersion = %q, want \"10\"", cert.GetAnnotations()["genVersion"])
+			}
+			spec := cert.Object["spec"].(map[string]interface{})
+			if spec["secretName"] != instanceName+"-forwarder-tls" {
+				t.Errorf("GenerateCertificate() secretName = %v", spec["secretName"])
+			}
+			if !reflect.DeepEqual(spec["issuerRef"], tt.wantIssuerRef) {
+				t.Errorf("GenerateCertificate() issuerRef = %v, want %v", spec["issuerRef"], tt.wantIssuerRef)
+			}
+			labels := spec["secretTemplate"].(map[string]interface{})["labels"].(map[string]interface{})
+			if labels[CertificateInstanceLabel] != instanceName {
+				t.Errorf("GenerateCertificate() secretTemplate labels = %v", labels)
+			}
+		})
+	}
+}
+
+func TestGenerateSplunkAuthData(t *testing.T) {
+	tlsSecret := func(data map[string]string) *corev1.Secret {
+		secret := &corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: instanceName + "-forwarder-tls", Namespace: instanceNamespace},
+			Data:       map[string][]byte{},
+		}
+		for k, v := range data {
+			secret.Data[k] = []byte(v)
+		}
+		return secret
+	}
+	tests := []struct {
+		name            string
+		secret          *corev1.Secret
+		trustedCABundle string
+		want            map[string][]byte
+		wantErr         bool
+	}{
+		{
+			name: "Certificate and key are concatenated into server.pem",
+			secret: tlsSecret(map[string]string{
+				"tls.crt": "CERT\n",
+				"tls.key": "KEY\n",
+				"ca.crt":  "CA\n",
+			}),
+			want: map[string][]byte{
+				"server.pem": []byte("CERT\nKEY\n"),
+				"cacert.pem": []byte("CA\n"),
+			},
+		},
+		{
+			name: "Certificate without trailing newline",
+			secret: tlsSecret(map[string]string{
+				"tls.crt": "CERT",
+				"tls.key": "KEY\n",
+				"ca.crt":  "CA\n",
+			}),
+			want: map[string][]byte{
+				"server.pem": []byte("CERT\nKEY\n"),
+				"cacert.pem": []byte("CA\n"),
+			},
+		},
+		{
+			name: "Issuer CA has precedence over the trusted CA bundle",
+			secret: tlsSecret(map[string]string{
+				"tls.crt": "CERT\n",
+				"tls.key": "KEY\n",
+				"ca.crt":  "CA\n",
+			}),
+			trustedCABundle: "BUNDLE\n",
+			want: map[string][]byte{
+				"server.pem": []byte("CERT\nKEY\n"),
+				"cacert.pem": []byte("CA\n"),
+			},
+		},
+		{
+			name: "Missing CA falls back to the trusted CA bundle",
+			secret: tlsSecret(map[string]string{
+				"tls.crt": "CERT\n",
+				"tls.key": "KEY\n",
+			}),
+			trustedCABundle: "BUNDLE\n",
+			want: map[string][]byte{
+				"server.pem": []byte("CERT\nKEY\n"),
+				"cacert.pem": []byte("BUNDLE\n"),
+			},
+		},
+		{
+			name: "Missing CA without a trusted CA bundle",
+			secret: tlsSecret(map[string]string{
+				"tls.crt": "CERT\n",
+				"tls.key": "KEY\n",
+			}),
+			wantErr: true,
+		},
+		{
+			name: "Missing key",
+			secret: tlsSecret(map[string]string{
+				"tls.crt": "CERT\n",
+				"ca.crt":  "CA\n",
+			}),
+			trustedCABundle: "BUNDLE\n",
+			wantErr:         true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := GenerateSplunkAuthData(tt.secret, tt.trustedCABundle)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("GenerateSplunkAuthData() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("GenerateSplunkAuthData() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDataHash(t *testing.T) {
+	a := DataHash(map[string][]byte{"server.pem": []byte("a"), "cacert.pem": []byte("b")})
+	b := DataHash(map[string][]byte{"cacert.pem": []byte("b"), "server.pem": []byte("a")})
+	c := DataHash(map[string][]byte{"server.pem": []byte("ab"), "cacert.pem": []byte("")})
+	if a != b {
+		t.Errorf("DataHash() is not stable across map ordering: %s != %s", a, b)
+	}
+	if a == c {
+		t.Errorf("DataHash() does not separate keys from values")
+	}
+}
@@ -3,6 +3,7 @@ package kube
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
 	corev1 "k8s.io/api/core/v1"
@@ -10,8 +11,74 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 )
 
-// GenerateConfigMaps generates config maps based on the values in our CRD
-func GenerateConfigMaps(instance *sfv1alpha1.SplunkForwarder, namespacedName types.NamespacedName, clusterid string) []*corev1.ConfigMap {
+// appConf is the app.conf of the apps holding the generated inputs
+const appConf = `
+[install]
+state = enabled
+
+[package]
+check_for_updates = false
+
+[ui]
+is_visible = false
+is_manageable = false
+`
+
+// ClusterMetadata holds the cluster level values that can be added to every event through _meta
+type ClusterMetadata struct {
+	ClusterID      string
+	ClusterVersion string
+	ClusterUUID    string
+	Platform       string
+	Region         string
+}
+
+// metaFields renders the _meta value of an input: the cluster ID followed by the metadata fields
+// selected in the CR and the node roles, a node with several roles adding one noderole field per role.
+// Fields without a value are left out.
+func metaFields(instance *sfv1alpha1.SplunkForwarder, metadata ClusterMetadata, roles []string) string {
+	fields := []string{}
+	add := func(key, value string) {
+		if value == "" {
+			return
+		}
+		if strings.ContainsAny(value, " \t\"") {
+			value = strconv.Quote(value)
+		}
+		fields = append(fields, key+"::"+value)
+	}
+
+	add("clusterid", metadata.ClusterID)
+	if selected := instance.Spec.MetadataFields; selected != nil {
+		if selected.ClusterVersion {
+			add("clusterversion", metadata.ClusterVersion)
+		}
+		if selected.ClusterUUID {
+			add("clusteruuid", metadata.ClusterUUID)
+		}
+		if selected.Platform {
+			add("platform", metadata.Platform)
+		}
+		if selected.Region {
+			add("region", metadata.Region)
+		}
+	}
+	for _, role := range roles {
+		add("noderole", role)
+	}
+
+	return strings.Join(fields, " ")
+}
+
+// GenerateConfigMaps generates config maps based on the values in our CRD. podLogs are the workloads
+// currently selected by the podLogs inputs of the CR. The nodes with several of the node roles of the
+// inputs are given their inputs by BuildConfigMaps, from the roles of the nodes.
+func GenerateConfigMaps(instance *sfv1alpha1.SplunkForwarder, namespacedName types.NamespacedName, metadata ClusterMetadata, podLogs []PodLogTarget) []*corev1.ConfigMap {
+	return generateConfigMaps(instance, namespacedName, metadata, podLogs, nil)
+}
+
+// generateConfigMaps generates the config maps with one inputs ConfigMap per node role set
+func generateConfigMaps(instance *sfv1alpha1.SplunkForwarder, namespacedName types.NamespacedName, metadata ClusterMetadata, podLogs []PodLogTarget, nodeRoles [][]string) []*corev1.ConfigMap {
 	ret := []*corev1.ConfigMap{}
 
 	metadataCM := &corev1.ConfigMap{
@@ -35,20 +102,45 @@ export = system
 	}
 	ret = append(ret, metadataCM)
 
+	ret = append(ret, generateLocalConfigMap(instance, namespacedName, metadata, nil, podLogs))
+	for _, roles := range nodeRoleSets(instance, nodeRoles) {
+		ret = append(ret, generateLocalConfigMap(instance, namespacedName, metadata, roles, podLogs))
+	}
+	if EventsCollectorEnabled(instance) {
+		ret = append(ret, generateEventsCollectorConfigMap(instance, namespacedName, metaFields(instance, metadata, nil)))
+	}
+
+	return ret
+}
+
+// generateLocalConfigMap generates the osd_monitored_logs app config with the inputs monitored by the
+// forwarders of the nodes with the given set of node roles. No role is for the forwarders on nodes without
+// any of the roles. The pod log stanzas are added for every role, as the pods can be scheduled on any node.
+func generateLocalConfigMap(instance *sfv1alpha1.SplunkForwarder, namespacedName types.NamespacedName, metadata ClusterMetadata, roles []string, podLogs []PodLogTarget) *corev1.ConfigMap {
+	role := nodeRoleSetKey(roles)
 	inputsStr := ""
+	meta := metaFields(instance, metadata, roles)
+	inputs := []sfv1alpha1.SplunkForwarderInputs{}
+
+	journald := []sfv1alpha1.SplunkForwarderInputs{}
 
 	for _, input := range instance.Spec.SplunkInputs {
+		if !inputEnabledForRoles(input, roles) {
+			continue
+		}
+		if input.Type == sfv1alpha1.InputTypeJournald {
+			inputs = append(inputs, input)
+			journald = append(journald, input)
+			continue
+		}
 		// No path passed in, skip it
 		if input.Path == "" {
 			continue
 		}
+		inputs = append(inputs, input)
 
 		inputsStr += "[monitor://" + input.Path + "]\n"
-		if input.SourceType != "" {
-			inputsStr += "sourcetype = " + input.SourceType + "\n"
-		} else {
-			inputsStr += "sourcetype = _json\n"
-		}
+		inputsStr += "sourcetype = " + inputSourceType(input) + "\n"
 
 		if input.Index != "" {
 			inputsStr += "index = " + input.Index + "\n"
@@ -64,17 +156,34 @@ export = system
 			inputsStr += "blacklist = " + input.BlackList + "\n"
 		}
 
-		if clusterid != "" {
-			inputsStr += "_meta = clusterid::" + clusterid + "\n"
+		if meta != "" {
+			inputsStr += "_meta = " + meta + "\n"
 		}
 
 		inputsStr += "disabled = false\n"
 		inputsStr += "\n"
 	}
+	inputsStr += journaldInputs(journald, meta)
+	inputsStr += scriptedInputs(instance.Spec.ScriptedInputs, meta, persistentQueueSize(instance))
+	inputsStr += podLogsInputs(podLogs, meta)
+
+	props := newPropsConf()
+	// Without a Heavy Forwarder the events are only parsed by the indexers, so the Universal Forwarders
+	// have to parse them to apply the masking rules before they leave the cluster
+	if instance.Spec.Masking != nil && !instance.Spec.UseHeavyForwarder {
+		settings := "force_local_processing = true\n" + maskingSettings(instance)
+		for _, sourceType := range inputSourceTypes(inputs, instance.Spec.PodLogs, instance.Spec.ScriptedInputs) {
+			props.add(sourceType, settings)
+		}
+	}
+	transforms := ""
+	if len(instance.Spec.PodLogs) > 0 && !instance.Spec.UseHeavyForwarder {
+		transforms = addPodLogsMetadata(props, true)
+	}
 
 	localCM := &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      "osd-monitored-logs-local",
+			Name:      localConfigMapName(role),
 			Namespace: namespacedName.Namespace,
 			Labels: map[string]string{
 				"app": namespacedName.Name,
@@ -84,28 +193,25 @@ export = system
 			},
 		},
 		Data: map[string]string{
-			"app.conf": `
-[install]
-state = enabled
-
-[package]
-check_for_updates = false
-
-[ui]
-is_visible = false
-is_manageable = false
-`,
+			"app.conf":    appConf,
 			"inputs.conf": inputsStr,
-			"props.conf": fmt.Sprintf(`
-[_json]
-TRUNCATE = %d
-`, MaxEventSize),
+			"props.conf":  props.String(),
 		},
 	}
 
-	ret = append(ret, localCM)
+	if transforms != "" {
+		localCM.Data["transforms.conf"] = transforms
+	}
 
-	return ret
+	addThroughputConfs(instance, localCM.Data)
+	addTrustedCABundleConfs(instance, localCM.Data)
+	managementPortConfs(localCM.Data)
+
+	if role != "" {
+		localCM.Labels[NodeRoleLabel] = role
+	}
+
+	return localCM
 }
 
 // GenerateInternalConfigMap generates a configmap that will be used to setup internal forwarding from the SUF to the SHF
@@ -130,14 +236,11 @@ export = system
 			"outputs.conf": `
 [tcpout]
 defaultGroup = internal
-
+` + tcpoutSettings(instance) + `
 [tcpout:internal]
 server = ` + instance.Name + `:9997
 `,
-			"limits.conf": `
-[thruput]
-maxKBps = 0
-`,
+			"limits.conf": limitsConf(instance),
 			"props.conf": fmt.Sprintf(`
 [_json]
 TRUNCATE = %d
@@ -163,30 +266,36 @@ route = has_key:_replicationBucketUUID:replicationQueue;has_key:_dstrx:typingQue
 [splunktcp://:9997]
 connection_host = dns
 `
+	// The Heavy Forwarder receives what the Universal Forwarders send, compressed or not
+	if queues := instance.Spec.Queues; queues != nil && queues.Compressed != nil {
+		data["inputs.conf"] += "compressed = " + strconv.FormatBool(*queues.Compressed) + "\n"
+	}
+	if size := persistentQueueSize(instance); size != "" {
+		data["inputs.conf"] += "persistentQueueSize = " + size + "\n"
+	}
 
-	data["limits.conf"] = `
-[thruput]
-maxKBps = 0
-`
-
-	data["props.conf"] = fmt.Sprintf(`
-[_json]
-TRUNCATE = %d
-`, MaxEventSize)
+	data["limits.conf"] = limitsConf(instance)
+	if server := serverConf(instance); server != "" {
+		data["server.conf"] = server
+	}
+	if settings := tcpoutSettings(instance); settings != "" {
+		data["outputs.conf"] = "\n[tcpout]\n" + settings
+	}
 
+	props := newPropsConf()
 	if len(instance.Spec.Filters) > 0 {
-		data["transforms.conf"] = ""
-
-		data["props.conf"] += "TRANSFORMS-null ="
-
-		for _, filter := range instance.Spec.Filters {
-			data["transforms.conf"] += "[filter_" + filter.Name + "]\n"
-			data["transforms.conf"] += "DEST_KEY = queue\n"
-			data["transforms.conf"] += "FORMAT = nullQueue\n"
-			data["transforms.conf"] += "REGEX = " + filter.Filter + "\n\n"
-			data["props.conf"] += "filter_" + filter.Name + " "
+		data["transforms.conf"] = addFilters(props, instance.Spec.Filters)
+	}
+	if instance.Spec.Masking != nil {
+		settings := maskingSettings(instance)
+		for _, sourceType := range inputSourceTypes(instance.Spec.SplunkInputs, instance.Spec.PodLogs, instance.Spec.ScriptedInputs) {
+			props.add(sourceType, settings)
 		}
 	}
+	if len(instance.Spec.PodLogs) > 0 {
+		data["transforms.conf"] += addPodLogsMetadata(props, false)
+	}
+	data["props.conf"] = props.String()
 
 	ret := &corev1.ConfigMap{
 		ObjectMeta: metav1.ObjectMeta{
@@ -3,6 +3,7 @@ package kube
 import (
 	"fmt"
 	"reflect"
+	"strings"
 	"testing"
 
 	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
@@ -44,7 +45,7 @@ func TestGenerateConfigMaps(t *testing.T) {
 	type args struct {
 		instance       *sfv1alpha1.SplunkForwarder
 		namespacedName types.NamespacedName
-		clusterid      string
+		metadata       ClusterMetadata
 	}
 	tests := []struct {
 		name string
@@ -56,7 +57,7 @@ func TestGenerateConfigMaps(t *testing.T) {
 			args: args{
 				instance:       testInstance,
 				namespacedName: types.NamespacedName{Namespace: instanceNamespace, Name: instanceName},
-				clusterid:      "test",
+				metadata:       ClusterMetadata{ClusterID: "test"},
 			},
 			want: []*corev1.ConfigMap{
 				{
@@ -122,6 +123,10 @@ disabled = false
 [_json]
 TRUNCATE = %d
 `, MaxEventSize),
+						"web.conf": `
+[settings]
+mgmtHostPort = 127.0.0.1:8089
+`,
 					},
 				},
 			},
@@ -129,13 +134,107 @@ TRUNCATE = %d
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := GenerateConfigMaps(tt.args.instance, tt.args.namespacedName, tt.args.clusterid); !reflect.DeepEqual(got, tt.want) {
+			if got := GenerateConfigMaps(tt.args.instance, tt.args.namespacedName, tt.args.metadata, nil); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("GenerateConfigMaps() = %v, want %v", got, tt.want)
 			}
 		})
 	}
 }
 
+func TestGenerateConfigMapsMetadataFields(t *testing.T) {
+	metadata := ClusterMetadata{
+		ClusterID:      "test",
+		ClusterVersion: "4.16.3",
+		ClusterUUID:    "5c5a7a7c-8a2b-4d5e-9b41-0f4a1f2b3c4d",
+		Platform:       "AWS",
+		Region:         "us-east-1",
+	}
+	tests := []struct {
+		name     string
+		fields   *sfv1alpha1.SplunkMetadataFields
+		metadata ClusterMetadata
+		wantMeta string
+	}{
+		{
+			name:     "No metadata fields",
+			metadata: metadata,
+			wantMeta: "_meta = clusterid::test\n",
+		},
+		{
+			name: "All metadata fields",
+			fields: &sfv1alpha1.SplunkMetadataFields{
+				ClusterVersion: true,
+				ClusterUUID:    true,
+				Platform:       true,
+				Region:         true,
+			},
+			metadata: metadata,
+			wantMeta: "_meta = clusterid::test clusterversion::4.16.3 clusteruuid::5c5a7a7c-8a2b-4d5e-9b41-0f4a1f2b3c4d platform::AWS region::us-east-1\n",
+		},
+		{
+			name:     "Platform without a region",
+			fields:   &sfv1alpha1.SplunkMetadataFields{Platform: true, Region: true},
+			metadata: ClusterMetadata{ClusterID: "test", Platform: "Azure"},
+			wantMeta: "_meta = clusterid::test platform::Azure\n",
+		},
+		{
+			name:     "Values with spaces are quoted",
+			fields:   &sfv1alpha1.SplunkMetadataFields{ClusterVersion: true},
+			metadata: ClusterMetadata{ClusterID: "test", ClusterVersion: "4.16 nightly"},
+			wantMeta: "_meta = clusterid::test clusterversion::\"4.16 nightly\"\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := splunkForwarderInstance(true)
+			instance.Spec.SplunkInputs = []sfv1alpha1.SplunkForwarderInputs{{Path: "/var/derp"}}
+			instance.Spec.MetadataFields = tt.fields
+
+			got := GenerateConfigMaps(instance, types.NamespacedName{Namespace: instanceNamespace, Name: instanceName}, tt.metadata, nil)
+			inputs := got[1].Data["inputs.conf"]
+			want := "[monitor:///var/derp]\nsourcetype = _json\nindex = main\n" + tt.wantMeta + "disabled = false\n\n"
+			if inputs != want {
+				t.Errorf("GenerateConfigMaps() inputs.conf = %q, want %q", inputs, want)
+			}
+		})
+	}
+}
+
+func TestGenerateConfigMapsNodeRoles(t *testing.T) {
+	instance := splunkForwarderInstance(true)
+	instance.Spec.SplunkInputs = []sfv1alpha1.SplunkForwarderInputs{
+		{Path: "/var/log/openshift-apiserver/audit.log", NodeRoles: []string{"master"}},
+		{Path: "/var/log/containers", NodeRoles: []string{"worker", "infra"}},
+		{Path: "/var/log/audit/audit.log"},
+	}
+	input := func(path, meta string) string {
+		return "[monitor://" + path + "]\nsourcetype = _json\nindex = main\n_meta = clusterid::test" + meta + "\ndisabled = false\n\n"
+	}
+	want := map[string]string{
+		"osd-monitored-logs-local": input("/var/log/audit/audit.log", ""),
+		"osd-monitored-logs-local-master": input("/var/log/openshift-apiserver/audit.log", " noderole::master") +
+			input("/var/log/audit/audit.log", " noderole::master"),
+		"osd-monitored-logs-local-worker": input("/var/log/containers", " noderole::worker") +
+			input("/var/log/audit/audit.log", " noderole::worker"),
+		"osd-monitored-logs-local-infra": input("/var/log/containers", " noderole::infra") +
+			input("/var/log/audit/audit.log", " noderole::infra"),
+	}
+
+	got := GenerateConfigMaps(instance, types.NamespacedName{Namespace: instanceNamespace, Name: instanceName}, ClusterMetadata{ClusterID: "test"}, nil)
+	if len(got) != 1+len(want) {
+		t.Fatalf("GenerateConfigMaps() returned %d ConfigMaps, want %d", len(got), 1+len(want))
+	}
+	for _, cm := range got[1:] {
+		if inputs := cm.Data["inputs.conf"]; inputs != want[cm.Name] {
+			t.Errorf("GenerateConfigMaps() %s inputs.conf = %q, want %q", cm.Name, inputs, want[cm.Name])
+		}
+		role := strings.TrimPrefix(strings.TrimPrefix(cm.Name, "osd-monitored-logs-local"), "-")
+		if cm.Labels[NodeRoleLabel] != role {
+			t.Errorf("GenerateConfigMaps() %s node role label = %q, want %q", cm.Name, cm.Labels[NodeRoleLabel], role)
+		}
+	}
+}
+
 func TestGenerateInternalConfigMap(t *testing.T) {
 	var testInstance = &sfv1alpha1.SplunkForwarder{
 		ObjectMeta: metav1.ObjectMeta{
@@ -229,6 +328,54 @@ func TestGenerateFilteringConfigMap(t *testing.T) {
 			},
 		},
 	}
+	var testInstanceFilterActions = &sfv1alpha1.SplunkForwarder{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:       instanceName,
+			Namespace:  instanceNamespace,
+			Generation: 10,
+		},
+		Spec: sfv1alpha1.SplunkForwarderSpec{
+			Filters: []sfv1alpha1.SplunkFilter{
+				{
+					Name:   "drop_debug",
+					Filter: `"level":"debug"`,
+					Action: sfv1alpha1.FilterActionDrop,
+				},
+				{
+					Name:       "keep_errors",
+					Filter:     `(?i)error`,
+					Action:     sfv1alpha1.FilterActionKeep,
+					SourceType: "linux_audit",
+				},
+				{
+					Name:   "security_index",
+					Filter: `"verb":"delete"`,
+					Action: sfv1alpha1.FilterActionSetIndex,
+					Value:  "openshift_security",
+				},
+				{
+					Name:   "oauth_sourcetype",
+					Filter: `oauth-server`,
+					Action: sfv1alpha1.FilterActionSetSourceType,
+					Value:  "oauth_json",
+					Source: "/host/var/log/oauth-apiserver/*.log",
+				},
+				{
+					Name:   "mask_tokens",
+					Filter: `"token":"[^"]*"`,
+					Action: sfv1alpha1.FilterActionMask,
+					Value:  `"token":"<masked/>"`,
+				},
+				{
+					Name:   "archive",
+					Filter: `.`,
+					Action: sfv1alpha1.FilterActionRoute,
+					Value:  "archive_group",
+					Host:   "ip-10-0-*",
+				},
+			},
+		},
+	}
 	type args struct {
 		instance       *sfv1alpha1.SplunkForwarder
 		namespacedName types.NamespacedName
@@ -316,12 +463,96 @@ maxKBps = 0
 					"props.conf": fmt.Sprintf(`
 [_json]
 TRUNCATE = %d
-TRANSFORMS-null =filter_ignore_chatty_system_users `, MaxEventSize),
+TRANSFORMS-filters = filter_ignore_chatty_system_users
+`, MaxEventSize),
 					"transforms.conf": `[filter_ignore_chatty_system_users]
 DEST_KEY = queue
 FORMAT = nullQueue
 REGEX = "user":{"username":"system:(?:kube-(?:controller-manager|scheduler|apiserver-cert-syncer)|apiserver|aggregator)"
 
+`,
+				},
+			},
+		},
+		{
+			name: "Filter actions",
+			args: args{
+				instance:       testInstanceFilterActions,
+				namespacedName: types.NamespacedName{Namespace: testInstance.Namespace, Name: testInstance.Name},
+			},
+			want: &corev1.ConfigMap{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      instanceName + "-hfconfig",
+					Namespace: instanceNamespace,
+					Labels: map[string]string{
+						"app": instanceName,
+					},
+					Annotations: map[string]string{
+						"genVersion": "10",
+					},
+				},
+				Data: map[string]string{
+					"local.meta": `
+[]
+access = read : [ * ], write : [ admin ]
+export = system
+`,
+					"inputs.conf": `
+[splunktcp]
+route = has_key:_replicationBucketUUID:replicationQueue;has_key:_dstrx:typingQueue;has_key:_linebreaker:typingQueue;absent_key:_linebreaker:parsingQueue
+
+[splunktcp://:9997]
+connection_host = dns
+`,
+					"limits.conf": `
+[thruput]
+maxKBps = 0
+`,
+					"props.conf": fmt.Sprintf(`
+[_json]
+TRUNCATE = %d
+TRANSFORMS-filters = filter_drop_debug, filter_security_index
+SEDCMD-filter_mask_tokens = s/"token":"[^"]*"/"token":"<masked\/>"/g
+
+[linux_audit]
+TRANSFORMS-filters = discard_filters, filter_keep_errors
+
+[source::/host/var/log/oauth-apiserver/*.log]
+TRANSFORMS-filters = filter_oauth_sourcetype
+
+[host::ip-10-0-*]
+TRANSFORMS-filters = filter_archive
+`, MaxEventSize),
+					"transforms.conf": `[filter_drop_debug]
+DEST_KEY = queue
+FORMAT = nullQueue
+REGEX = "level":"debug"
+
+[discard_filters]
+DEST_KEY = queue
+FORMAT = nullQueue
+REGEX = .
+
+[filter_keep_errors]
+DEST_KEY = queue
+FORMAT = indexQueue
+REGEX = (?i)error
+
+[filter_security_index]
+DEST_KEY = _MetaData:Index
+FORMAT = openshift_security
+REGEX = "verb":"delete"
+
+[filter_oauth_sourcetype]
+DEST_KEY = MetaData:Sourcetype
+FORMAT = sourcetype::oauth_json
+REGEX = oauth-server
+
+[filter_archive]
+DEST_KEY = _TCP_ROUTING
+FORMAT = archive_group
+REGEX = .
+
 `,
 				},
 			},
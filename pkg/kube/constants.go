@@ -2,4 +2,30 @@ package kube
 
 const (
 	MaxEventSize = 100 * 1024 // 100KB per k8s.io/kubernetes/apiserver/pkg/server/options/audit.go
+
+	// CertificateInstanceLabel is set on the Secret issued by cert-manager and names the owning SplunkForwarder
+	CertificateInstanceLabel = "splunkforwarder.managed.openshift.io/instance"
+	// CertificateHashAnnotation is set on the forwarder pod template so that certificate renewals roll the pods
+	CertificateHashAnnotation = "splunkforwarder.managed.openshift.io/certificate-hash"
+	// AuthHashAnnotation is set on the forwarder pod template so that changes to the splunk-auth or HEC token
+	// Secret roll the pods
+	AuthHashAnnotation = "splunkforwarder.managed.openshift.io/auth-hash"
+	// AppsHashAnnotation is set on the forwarder pod template so that changes to the extra apps roll the pods
+	AppsHashAnnotation = "splunkforwarder.managed.openshift.io/apps-hash"
+	// TrustedCABundleHashAnnotation is set on the forwarder pod template so that CA bundle rotations roll the pods
+	TrustedCABundleHashAnnotation = "splunkforwarder.managed.openshift.io/trusted-ca-bundle-hash"
+	// ConfigHashAnnotation is set on the pod templates of the forwarders and of the events collector so that
+	// changes to the configuration files splunkd only reads on start roll the pods
+	ConfigHashAnnotation = "splunkforwarder.managed.openshift.io/config-hash"
+	// TLSProfileHashAnnotation is set on the forwarder pod template so that TLS security profile changes roll the pods
+	TLSProfileHashAnnotation = "splunkforwarder.managed.openshift.io/tls-profile-hash"
+	// TemplateHashAnnotation is set on the forwarder DaemonSets and holds a hash of the generated pod template
+	TemplateHashAnnotation = "splunkforwarder.managed.openshift.io/template-hash"
+	// NodeRoleLabel is set on the ConfigMaps and DaemonSets generated for a node role and names the role
+	NodeRoleLabel = "splunkforwarder.managed.openshift.io/node-role"
+	// RolloutProgressAnnotation is set on the forwarder DaemonSets during a rollout and holds the updated and
+	// ready pod counts, and when they last changed
+	RolloutProgressAnnotation = "splunkforwarder.managed.openshift.io/rollout-progress"
+	// CanaryLabel is set on the ConfigMaps and DaemonSets generated for the canary nodes
+	CanaryLabel = "splunkforwarder.managed.openshift.io/canary"
 )
@@ -22,8 +22,32 @@ func forwarderPullSpec(instance *sfv1alpha1.SplunkForwarder) string {
 	return instance.Spec.Image + sep + suffix
 }
 
+// GenerateDaemonSets returns the forwarder daemonsets: the default daemonset, followed by one daemonset
+// per node role referenced by the inputs. The nodes with several of the roles are given their daemonsets
+// by BuildDaemonSets, from the roles of the nodes. The config-reload sidecar runs the given operator image, and is
+// left out when it is empty.
+func GenerateDaemonSets(instance *sfv1alpha1.SplunkForwarder, useHECToken bool, operatorImage string) []*appsv1.DaemonSet {
+	return generateDaemonSets(instance, useHECToken, nil, operatorImage)
+}
+
+// generateDaemonSets returns the default daemonset, followed by one daemonset per node role set
+func generateDaemonSets(instance *sfv1alpha1.SplunkForwarder, useHECToken bool, nodeRoles [][]string, operatorImage string) []*appsv1.DaemonSet {
+	ret := []*appsv1.DaemonSet{GenerateDaemonSet(instance, useHECToken, operatorImage)}
+	for _, roles := range nodeRoleSets(instance, nodeRoles) {
+		ret = append(ret, generateDaemonSet(instance, useHECToken, roles, operatorImage))
+	}
+	return ret
+}
+
 // GenerateDaemonSet returns a daemonset that can be created with the oc client
-func GenerateDaemonSet(instance *sfv1alpha1.SplunkForwarder, useHECToken bool) *appsv1.DaemonSet {
+func GenerateDaemonSet(instance *sfv1alpha1.SplunkForwarder, useHECToken bool, operatorImage string) *appsv1.DaemonSet {
+	return generateDaemonSet(instance, useHECToken, nil, operatorImage)
+}
+
+// generateDaemonSet returns the daemonset for the forwarders of the nodes with the given set of node roles.
+// No role is the default daemonset, which runs on the nodes without any of the roles referenced by the inputs.
+func generateDaemonSet(instance *sfv1alpha1.SplunkForwarder, useHECToken bool, roles []string, operatorImage string) *appsv1.DaemonSet {
+	role := nodeRoleSetKey(roles)
 
 	var (
 		runAsUID                      int64 = 0
@@ -50,11 +74,23 @@ func GenerateDaemonSet(instance *sfv1alpha1.SplunkForwarder, useHECToken bool) *
 		},
 	}
 
-	volumes := GetVolumes(true, true, useHECToken, instance.Name)
+	volumes := GetVolumes(instance, true, true, useHECToken)
+	for i := range volumes {
+		if volumes[i].Name == inputsVolumeName {
+			volumes[i].ConfigMap.Name = localConfigMapName(role)
+		}
+	}
+
+	name := instance.Name + "-ds"
+	podName := "splunk-forwarder"
+	if role != "" {
+		name += "-" + role
+		podName += "-" + role
+	}
 
 	daemonset := &appsv1.DaemonSet{
 		ObjectMeta: metav1.ObjectMeta{
-			Name:      instance.Name + "-ds",
+			Name:      name,
 			Namespace: instance.Namespace,
 			Labels: map[string]string{
 				"app": instance.Name,
@@ -66,15 +102,15 @@ func GenerateDaemonSet(instance *sfv1alpha1.SplunkForwarder, useHECToken bool) *
 		Spec: appsv1.DaemonSetSpec{
 			Selector: &metav1.LabelSelector{
 				MatchLabels: map[string]string{
-					"name": "splunk-forwarder",
+					"name": podName,
 				},
 			},
 			Template: corev1.PodTemplateSpec{
 				ObjectMeta: metav1.ObjectMeta{
-					Name:      "splunk-forwarder",
+					Name:      podName,
 					Namespace: instance.Namespace,
 					Labels: map[string]string{
-						"name": "splunk-forwarder",
+						"name": podName,
 					},
 				},
 				Spec: corev1.PodSpec{
@@ -83,8 +119,10 @@ func GenerateDaemonSet(instance *sfv1alpha1.SplunkForwarder, useHECToken bool) *
 					NodeSelector: map[string]string{
 						"kubernetes.io/os": "linux",
 					},
+					Affinity: nodeRoleAffinity(NodeRoles(instance), roles),
 
 					ServiceAccountName: "splunk-forwarder-operator",
+					ImagePullSecrets:   imagePullSecrets(instance),
 					Tolerations: []corev1.Toleration{
 						{
 							Operator: corev1.TolerationOpExists,
@@ -94,21 +132,15 @@ func GenerateDaemonSet(instance *sfv1alpha1.SplunkForwarder, useHECToken bool) *
 
 					Containers: []corev1.Container{
 						{
-							Name:            "splunk-uf",
-							ImagePullPolicy: corev1.PullAlways,
-							Image:           forwarderPullSpec(instance),
-							Ports: []corev1.ContainerPort{
-								{
-									ContainerPort: 8089,
-									Protocol:      corev1.ProtocolTCP,
-								},
-							},
+							Name:                   "splunk-uf",
+							ImagePullPolicy:        forwarderPullPolicy(instance),
+							Image:                  forwarderPullSpec(instance),
 							Resources:              corev1.ResourceRequirements{},
 							TerminationMessagePath: "/dev/termination-log",
 
 							Env: envVars,
 
-							VolumeMounts: GetVolumeMounts(instance, useHECToken),
+							VolumeMounts: append(GetVolumeMounts(instance, useHECToken), getAdminVolumeMount()),
 
 							SecurityContext: &corev1.SecurityContext{
 								Privileged: &isPrivContainer,
@@ -116,17 +148,31 @@ func GenerateDaemonSet(instance *sfv1alpha1.SplunkForwarder, useHECToken bool) *
 							},
 						},
 					},
-					Volumes: volumes,
+					Volumes: append(volumes, getAdminVolume(instance)),
 				},
 			},
 		},
 	}
 
+	if role != "" {
+		daemonset.Labels[NodeRoleLabel] = role
+	}
+	// Without the image of the operator there is no config-reload sidecar, and the inputs are only read on start
+	if operatorImage != "" {
+		daemonset.Spec.Template.Spec.Containers = append(daemonset.Spec.Template.Spec.Containers, getReloadContainer(instance, operatorImage))
+	}
+
 	if useHECToken {
 		daemonset.Spec.Template.Spec.InitContainers = []corev1.Container{
 			getInitContainer(),
 		}
 	}
+	if TrustedCABundleEnabled(instance) {
+		daemonset.Spec.Template.Spec.InitContainers = append(daemonset.Spec.Template.Spec.InitContainers,
+			getTrustedCABundleInitContainer(instance, useHECToken))
+	}
+
+	daemonset.Annotations[TemplateHashAnnotation] = TemplateHash(&daemonset.Spec.Template)
 
 	return daemonset
 }
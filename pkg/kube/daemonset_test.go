@@ -1,11 +1,13 @@
 package kube
 
 import (
+	"reflect"
 	"testing"
 
 	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
 	appsv1 "k8s.io/api/apps/v1"
 	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
@@ -23,13 +25,22 @@ func expectedDaemonSet(instance *sfv1alpha1.SplunkForwarder) *appsv1.DaemonSet {
 
 	useVolumeSecret := true
 	var sfImage string
+	pullPolicy := corev1.PullAlways
 	if instance.Spec.ImageDigest == "" {
 		sfImage = image + ":" + imageTag
 	} else {
 		sfImage = image + "@" + imageDigest
+		pullPolicy = corev1.PullIfNotPresent
+	}
+	if instance.Spec.ImagePullPolicy != "" {
+		pullPolicy = corev1.PullPolicy(instance.Spec.ImagePullPolicy)
+	}
+	var pullSecrets []corev1.LocalObjectReference
+	for _, name := range instance.Spec.ImagePullSecrets {
+		pullSecrets = append(pullSecrets, corev1.LocalObjectReference{Name: name})
 	}
 
-	return &appsv1.DaemonSet{
+	ds := &appsv1.DaemonSet{
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      instanceName + "-ds",
 			Namespace: instanceNamespace,
@@ -62,6 +73,7 @@ func expectedDaemonSet(instance *sfv1alpha1.SplunkForwarder) *appsv1.DaemonSet {
 					},
 
 					ServiceAccountName: "splunk-forwarder-operator",
+					ImagePullSecrets:   pullSecrets,
 					Tolerations: []corev1.Toleration{
 						{
 							Operator: corev1.TolerationOpExists,
@@ -71,15 +83,9 @@ func expectedDaemonSet(instance *sfv1alpha1.SplunkForwarder) *appsv1.DaemonSet {
 
 					Containers: []corev1.Container{
 						{
-							Name:            "splunk-uf",
-							ImagePullPolicy: corev1.PullAlways,
-							Image:           sfImage,
-							Ports: []corev1.ContainerPort{
-								{
-									ContainerPort: 8089,
-									Protocol:      corev1.ProtocolTCP,
-								},
-							},
+							Name:                   "splunk-uf",
+							ImagePullPolicy:        pullPolicy,
+							Image:                  sfImage,
 							Resources:              corev1.ResourceRequirements{},
 							TerminationMessagePath: "/dev/termination-log",
 							Env: []corev1.EnvVar{
@@ -97,19 +103,60 @@ func expectedDaemonSet(instance *sfv1alpha1.SplunkForwarder) *appsv1.DaemonSet {
 								},
 							},
 
-							VolumeMounts: GetVolumeMounts(instance, false),
+							VolumeMounts: append(GetVolumeMounts(instance, false), corev1.VolumeMount{
+								Name:      "splunk-admin",
+								MountPath: "/opt/splunkforwarder/etc/system/local/user-seed.conf",
+								SubPath:   "user-seed.conf",
+								ReadOnly:  true,
+							}),
 
 							SecurityContext: &corev1.SecurityContext{
 								Privileged: &expectedIsPrivContainer,
 								RunAsUser:  &expectedRunAsUID,
 							},
 						},
+						{
+							Name:    "config-reload",
+							Image:   operatorImage,
+							Command: []string{"splunk-forwarder-operator", "reload", "--dir", "/tmp/osd-monitored-logs-local", "--password-file", "/etc/splunk-admin/password"},
+							Resources: corev1.ResourceRequirements{
+								Requests: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse("5m"),
+									corev1.ResourceMemory: resource.MustParse("32Mi"),
+								},
+								Limits: corev1.ResourceList{
+									corev1.ResourceCPU:    resource.MustParse("50m"),
+									corev1.ResourceMemory: resource.MustParse("64Mi"),
+								},
+							},
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      "osd-monitored-logs-local",
+									MountPath: "/tmp/osd-monitored-logs-local",
+									ReadOnly:  true,
+								},
+								{
+									Name:      "splunk-admin",
+									MountPath: "/etc/splunk-admin",
+									ReadOnly:  true,
+								},
+							},
+						},
 					},
-					Volumes: GetVolumes(true, useVolumeSecret, false, instanceName),
+					Volumes: append(GetVolumes(instance, true, useVolumeSecret, false), corev1.Volume{
+						Name: "splunk-admin",
+						VolumeSource: corev1.VolumeSource{
+							Secret: &corev1.SecretVolumeSource{
+								SecretName: instanceName + "-admin",
+							},
+						},
+					}),
 				},
 			},
 		},
 	}
+	ds.Annotations[TemplateHashAnnotation] = TemplateHash(&ds.Spec.Template)
+	return ds
 }
 
 func TestGenerateDaemonSet(t *testing.T) {
@@ -118,9 +165,7 @@ func TestGenerateDaemonSet(t *testing.T) {
 		instance    *sfv1alpha1.SplunkForwarder
 		useHECToken bool
 	}{
-		// TODO: The following configurations should be invalid and produce a predictable error:
-		// - splunkForwarderInstance(false, false)
-		//   (Can't make sf pull spec when neither tag nor digest is present.)
+		// CRs without a tag or digest are refused by ValidateImage before any DaemonSet is generated
 		{
 			name:     "Test Daemonset with image digest",
 			instance: splunkForwarderInstance(true),
@@ -129,12 +174,89 @@ func TestGenerateDaemonSet(t *testing.T) {
 			name:     "Test Daemonset with tags",
 			instance: splunkForwarderInstance(false),
 		},
+		{
+			name: "Test Daemonset with pull policy and pull secrets",
+			instance: func() *sfv1alpha1.SplunkForwarder {
+				instance := splunkForwarderInstance(true)
+				instance.Spec.ImagePullPolicy = "Always"
+				instance.Spec.ImagePullSecrets = []string{"quay-pull", "mirror-pull"}
+				return instance
+			}(),
+		},
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			expected := expectedDaemonSet(tt.instance)
-			actual := GenerateDaemonSet(tt.instance, tt.useHECToken)
+			actual := GenerateDaemonSet(tt.instance, tt.useHECToken, operatorImage)
 			DeepEqualWithDiff(t, expected, actual)
 		})
 	}
 }
+
+func TestGenerateDaemonSetsNodeRoles(t *testing.T) {
+	instance := splunkForwarderInstance(true)
+	instance.Spec.SplunkInputs = []sfv1alpha1.SplunkForwarderInputs{
+		{Path: "/var/log/openshift-apiserver/audit.log", NodeRoles: []string{"master"}},
+		{Path: "/var/log/containers", NodeRoles: []string{"worker"}},
+	}
+
+	actual := GenerateDaemonSets(instance, false, operatorImage)
+	if len(actual) != 3 {
+		t.Fatalf("GenerateDaemonSets() returned %d DaemonSets, want 3", len(actual))
+	}
+
+	tests := []struct {
+		name          string
+		podName       string
+		configMapName string
+		expressions   []corev1.NodeSelectorRequirement
+	}{
+		{
+			name:          instanceName + "-ds",
+			podName:       "splunk-forwarder",
+			configMapName: "osd-monitored-logs-local",
+			expressions: []corev1.NodeSelectorRequirement{
+				{Key: "node-role.kubernetes.io/master", Operator: corev1.NodeSelectorOpDoesNotExist},
+				{Key: "node-role.kubernetes.io/worker", Operator: corev1.NodeSelectorOpDoesNotExist},
+			},
+		},
+		{
+			name:          instanceName + "-ds-master",
+			podName:       "splunk-forwarder-master",
+			configMapName: "osd-monitored-logs-local-master",
+			expressions: []corev1.NodeSelectorRequirement{
+				{Key: "node-role.kubernetes.io/master", Operator: corev1.NodeSelectorOpExists},
+				{Key: "node-role.kubernetes.io/worker", Operator: corev1.NodeSelectorOpDoesNotExist},
+			},
+		},
+		{
+			name:          instanceName + "-ds-worker",
+			podName:       "splunk-forwarder-worker",
+			configMapName: "osd-monitored-logs-local-worker",
+			expressions: []corev1.NodeSelectorRequirement{
+				{Key: "node-role.kubernetes.io/master", Operator: corev1.NodeSelectorOpDoesNotExist},
+				{Key: "node-role.kubernetes.io/worker", Operator: corev1.NodeSelectorOpExists},
+			},
+		},
+	}
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ds := actual[i]
+			if ds.Name != tt.name {
+				t.Fatalf("DaemonSet name = %s, want %s", ds.Name, tt.name)
+			}
+			if ds.Spec.Selector.MatchLabels["name"] != tt.podName || ds.Spec.Template.Labels["name"] != tt.podName {
+				t.Errorf("DaemonSet selector = %v, pod labels = %v, want name %s", ds.Spec.Selector.MatchLabels, ds.Spec.Template.Labels, tt.podName)
+			}
+			terms := ds.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+			if len(terms) != 1 || !reflect.DeepEqual(terms[0].MatchExpressions, tt.expressions) {
+				t.Errorf("DaemonSet node affinity = %v, want %v", terms, tt.expressions)
+			}
+			for _, volume := range ds.Spec.Template.Spec.Volumes {
+				if volume.Name == "osd-monitored-logs-local" && volume.ConfigMap.Name != tt.configMapName {
+					t.Errorf("DaemonSet inputs ConfigMap = %s, want %s", volume.ConfigMap.Name, tt.configMapName)
+				}
+			}
+		})
+	}
+}
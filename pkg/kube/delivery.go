@@ -0,0 +1,56 @@
+package kube
+
+import (
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// reliableMaxQueueSize is the output queue size of reliable delivery when the CR does not set one
+	reliableMaxQueueSize = "64MB"
+	// reliablePersistentQueueSize is the persistent queue size of reliable delivery when the CR does not
+	// set one
+	reliablePersistentQueueSize = "500MB"
+	// persistentQueuesDir is where splunkd keeps the persistent queues, in a directory per input type. It is
+	// not under var/lib, so the queues are mounted from the splunk-state hostPath one by one.
+	persistentQueuesDir = "/opt/splunkforwarder/var/run/splunk"
+	// scriptedInputsQueueType is the directory of the persistent queues of the scripted inputs
+	scriptedInputsQueueType = "exec"
+)
+
+// ReliableDeliveryEnabled returns whether the CR enables reliable delivery
+func ReliableDeliveryEnabled(instance *sfv1alpha1.SplunkForwarder) bool {
+	return instance.Spec.ReliableDelivery != nil && instance.Spec.ReliableDelivery.Enabled
+}
+
+// DeliveryMode returns the delivery guarantee of the forwarders. The acknowledgement is only rendered
+// for the tcpout receivers, the HEC token configuring its own in the splunk-hec-token Secret.
+func DeliveryMode(instance *sfv1alpha1.SplunkForwarder, useHECToken bool) string {
+	if ReliableDeliveryEnabled(instance) && !useHECToken {
+		return sfv1alpha1.DeliveryModeReliable
+	}
+	return sfv1alpha1.DeliveryModeBestEffort
+}
+
+// persistentQueueSize returns the persistent queue size of the inputs that support one, or "" without
+// reliable delivery. Splunk keeps the queues under var/run/splunk/<input type>, which
+// getPersistentQueueVolumeMount mounts from the splunk-state hostPath.
+func persistentQueueSize(instance *sfv1alpha1.SplunkForwarder) string {
+	if !ReliableDeliveryEnabled(instance) {
+		return ""
+	}
+	if size := instance.Spec.ReliableDelivery.PersistentQueueSize; size != "" {
+		return size
+	}
+	return reliablePersistentQueueSize
+}
+
+// getPersistentQueueVolumeMount returns the mount of the persistent queues of an input type, from a
+// subdirectory of the splunk-state hostPath, so that the queued events outlive the forwarder pods
+func getPersistentQueueVolumeMount(inputType string) corev1.VolumeMount {
+	return corev1.VolumeMount{
+		Name:      "splunk-state",
+		MountPath: persistentQueuesDir + "/" + inputType,
+		SubPath:   "persistent-queues/" + inputType,
+	}
+}
@@ -0,0 +1,68 @@
+package kube
+
+import (
+	"strings"
+	"testing"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestReliableDelivery(t *testing.T) {
+	useACK := false
+	instance := splunkForwarderInstance(true)
+	instance.Spec.ScriptedInputs = []sfv1alpha1.SplunkScriptedInput{
+		{Name: "node-health", ConfigMap: "node-checks", Key: "health.sh"},
+	}
+	instance.Spec.Queues = &sfv1alpha1.SplunkQueues{UseACK: &useACK}
+	namespacedName := types.NamespacedName{Namespace: instanceNamespace, Name: instanceName}
+
+	if mode := DeliveryMode(instance, false); mode != sfv1alpha1.DeliveryModeBestEffort {
+		t.Errorf("DeliveryMode() = %q, want %q", mode, sfv1alpha1.DeliveryModeBestEffort)
+	}
+	local := GenerateConfigMaps(instance, namespacedName, ClusterMetadata{ClusterID: "test"}, nil)[1]
+	if strings.Contains(local.Data["inputs.conf"], "persistentQueueSize") {
+		t.Errorf("inputs.conf has a persistent queue without reliable delivery")
+	}
+	for _, mount := range GetVolumeMounts(instance, false) {
+		if mount.MountPath == persistentQueuesDir+"/"+scriptedInputsQueueType {
+			t.Errorf("GetVolumeMounts() mounts a persistent queue without reliable delivery")
+		}
+	}
+
+	// Reliable delivery turns the acknowledgement on over the queues settings
+	instance.Spec.ReliableDelivery = &sfv1alpha1.SplunkReliableDelivery{Enabled: true}
+	if mode := DeliveryMode(instance, false); mode != sfv1alpha1.DeliveryModeReliable {
+		t.Errorf("DeliveryMode() = %q, want %q", mode, sfv1alpha1.DeliveryModeReliable)
+	}
+	if mode := DeliveryMode(instance, true); mode != sfv1alpha1.DeliveryModeBestEffort {
+		t.Errorf("DeliveryMode() with the HEC token = %q, want %q", mode, sfv1alpha1.DeliveryModeBestEffort)
+	}
+	local = GenerateConfigMaps(instance, namespacedName, ClusterMetadata{ClusterID: "test"}, nil)[1]
+	if got, want := local.Data["outputs.conf"], "\n[tcpout]\nmaxQueueSize = 64MB\nuseACK = true\n"; got != want {
+		t.Errorf("outputs.conf = %q, want %q", got, want)
+	}
+	if !strings.Contains(local.Data["inputs.conf"], "persistentQueueSize = 500MB\n") {
+		t.Errorf("inputs.conf = %q, want a 500MB persistent queue on the scripted input", local.Data["inputs.conf"])
+	}
+	// The queue of the scripted inputs lands on the splunk-state hostPath
+	want := corev1.VolumeMount{Name: "splunk-state", MountPath: "/opt/splunkforwarder/var/run/splunk/exec", SubPath: "persistent-queues/exec"}
+	found := false
+	for _, mount := range GetVolumeMounts(instance, false) {
+		found = found || mount == want
+	}
+	if !found {
+		t.Errorf("GetVolumeMounts() = %v, want the persistent queue mount %v", GetVolumeMounts(instance, false), want)
+	}
+
+	instance.Spec.ReliableDelivery.MaxQueueSize = "128MB"
+	instance.Spec.ReliableDelivery.PersistentQueueSize = "1GB"
+	if got, want := tcpoutSettings(instance), "maxQueueSize = 128MB\nuseACK = true\n"; got != want {
+		t.Errorf("tcpoutSettings() = %q, want %q", got, want)
+	}
+	filtering := GenerateFilteringConfigMap(instance, namespacedName)
+	if !strings.HasSuffix(filtering.Data["inputs.conf"], "[splunktcp://:9997]\nconnection_host = dns\npersistentQueueSize = 1GB\n") {
+		t.Errorf("filtering inputs.conf = %q, want a 1GB persistent queue on the receiving port", filtering.Data["inputs.conf"])
+	}
+}
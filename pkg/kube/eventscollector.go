@@ -0,0 +1,251 @@
+package kube
+
+import (
+	"strconv"
+	"strings"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	"github.com/openshift/splunk-forwarder-operator/config"
+	"github.com/openshift/splunk-forwarder-operator/pkg/events"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	rbacv1 "k8s.io/api/rbac/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// EventsCollectorClusterRole is the ClusterRole, shipped with the operator, that only allows reading Events
+// and the collectable resources. The operator binds it to the ServiceAccount of each events collector.
+const EventsCollectorClusterRole = "splunk-events-collector"
+
+// EventsCollectorEnabled returns whether the CR deploys the events collector
+func EventsCollectorEnabled(instance *sfv1alpha1.SplunkForwarder) bool {
+	return instance.Spec.EventsCollector != nil && instance.Spec.EventsCollector.Enabled
+}
+
+// EventsCollectorName returns the name of the events collector Deployment, of its inputs ConfigMap and of
+// its ServiceAccount
+func EventsCollectorName(instance *sfv1alpha1.SplunkForwarder) string {
+	return instance.Name + "-events-collector"
+}
+
+// EventsCollectorClusterRoleBindingName returns the name of the ClusterRoleBinding of the events collector
+// of the CR with the given namespace and name. The binding is cluster-scoped, so it is named after both.
+func EventsCollectorClusterRoleBindingName(namespacedName types.NamespacedName) string {
+	return EventsCollectorClusterRole + "-" + namespacedName.Namespace + "-" + namespacedName.Name
+}
+
+// GenerateEventsCollectorServiceAccount returns the ServiceAccount the events collector runs as, in the
+// namespace of the CR
+func GenerateEventsCollectorServiceAccount(instance *sfv1alpha1.SplunkForwarder) *corev1.ServiceAccount {
+	return &corev1.ServiceAccount{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      EventsCollectorName(instance),
+			Namespace: instance.Namespace,
+			Labels: map[string]string{
+				"app": instance.Name,
+			},
+		},
+	}
+}
+
+// GenerateEventsCollectorClusterRoleBinding returns the binding of EventsCollectorClusterRole to the
+// ServiceAccount of the events collector. The cluster-scoped binding cannot be owned by the CR, so it is
+// deleted by the operator along with the collector.
+func GenerateEventsCollectorClusterRoleBinding(instance *sfv1alpha1.SplunkForwarder) *rbacv1.ClusterRoleBinding {
+	return &rbacv1.ClusterRoleBinding{
+		ObjectMeta: metav1.ObjectMeta{
+			Name: EventsCollectorClusterRoleBindingName(types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}),
+			Labels: map[string]string{
+				"app": instance.Name,
+			},
+		},
+		Subjects: []rbacv1.Subject{
+			{
+				Kind:      rbacv1.ServiceAccountKind,
+				Name:      EventsCollectorName(instance),
+				Namespace: instance.Namespace,
+			},
+		},
+		RoleRef: rbacv1.RoleRef{
+			APIGroup: rbacv1.GroupName,
+			Kind:     "ClusterRole",
+			Name:     EventsCollectorClusterRole,
+		},
+	}
+}
+
+// eventsCollectorIndex returns the index of the collected events: the one of the collector, or the first
+// one set on the inputs of the CR
+func eventsCollectorIndex(instance *sfv1alpha1.SplunkForwarder) string {
+	if instance.Spec.EventsCollector.Index != "" {
+		return instance.Spec.EventsCollector.Index
+	}
+	for _, input := range instance.Spec.SplunkInputs {
+		if input.Index != "" {
+			return input.Index
+		}
+	}
+	return "main"
+}
+
+// generateEventsCollectorConfigMap generates the inputs of the forwarder of the events collector, which
+// monitors the files the collector writes
+func generateEventsCollectorConfigMap(instance *sfv1alpha1.SplunkForwarder, namespacedName types.NamespacedName, meta string) *corev1.ConfigMap {
+	inputsStr := ""
+	for _, file := range []string{events.EventsFile, events.ObjectsFile} {
+		inputsStr += "[monitor://" + events.DefaultDir + "/" + file + "]\n"
+		inputsStr += "sourcetype = _json\n"
+		inputsStr += "index = " + eventsCollectorIndex(instance) + "\n"
+		if meta != "" {
+			inputsStr += "_meta = " + meta + "\n"
+		}
+		inputsStr += "disabled = false\n"
+		inputsStr += "\n"
+	}
+
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      EventsCollectorName(instance),
+			Namespace: namespacedName.Namespace,
+			Labels: map[string]string{
+				"app": namespacedName.Name,
+			},
+			Annotations: map[string]string{
+				"genVersion": strconv.FormatInt(instance.Generation, 10),
+			},
+		},
+		Data: map[string]string{
+			"app.conf":    appConf,
+			"inputs.conf": inputsStr,
+		},
+	}
+}
+
+// GenerateEventsCollectorDeployment returns the Deployment of the events collector. The collector runs
+// the events subcommand of the given image, and writes the Events and objects to a volume shared with a
+// Universal Forwarder, which sends them with the same authentication as the forwarders of the nodes.
+func GenerateEventsCollectorDeployment(instance *sfv1alpha1.SplunkForwarder, useHECToken bool, image string) *appsv1.Deployment {
+	var (
+		replicas                      int32 = 1
+		terminationGracePeriodSeconds int64 = 10
+	)
+	collector := instance.Spec.EventsCollector
+	if collector.Image != "" {
+		image = collector.Image
+	}
+	command := []string{config.OperatorName, "events", "--dir", events.DefaultDir}
+	if len(collector.Namespaces) > 0 {
+		command = append(command, "--namespaces", strings.Join(collector.Namespaces, ","))
+	}
+	if len(collector.Resources) > 0 {
+		command = append(command, "--resources", strings.Join(collector.Resources, ","))
+	}
+
+	licenseAccepted := "no"
+	if instance.Spec.SplunkLicenseAccepted {
+		licenseAccepted = "yes"
+	}
+	podName := "splunk-events-collector"
+	deployment := &appsv1.Deployment{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      EventsCollectorName(instance),
+			Namespace: instance.Namespace,
+			Labels: map[string]string{
+				"app": instance.Name,
+			},
+			Annotations: map[string]string{
+				"genVersion": strconv.FormatInt(instance.Generation, 10),
+			},
+		},
+		Spec: appsv1.DeploymentSpec{
+			Replicas: &replicas,
+			// A single collector at a time, so that the events are not sent twice during a rollout
+			Strategy: appsv1.DeploymentStrategy{
+				Type: appsv1.RecreateDeploymentStrategyType,
+			},
+			Selector: &metav1.LabelSelector{
+				MatchLabels: map[string]string{
+					"name": podName,
+				},
+			},
+			Template: corev1.PodTemplateSpec{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      podName,
+					Namespace: instance.Namespace,
+					Labels: map[string]string{
+						"name": podName,
+					},
+				},
+				Spec: corev1.PodSpec{
+					ServiceAccountName:            EventsCollectorName(instance),
+					ImagePullSecrets:              imagePullSecrets(instance),
+					TerminationGracePeriodSeconds: &terminationGracePeriodSeconds,
+					Containers: []corev1.Container{
+						{
+							Name:    "events-collector",
+							Image:   image,
+							Command: command,
+							VolumeMounts: []corev1.VolumeMount{
+								{
+									Name:      "kube-events",
+									MountPath: events.DefaultDir,
+								},
+							},
+						},
+						{
+							Name:            "splunk-uf",
+							ImagePullPolicy: forwarderPullPolicy(instance),
+							Image:           forwarderPullSpec(instance),
+							Env: []corev1.EnvVar{
+								{
+									Name:  "SPLUNK_ACCEPT_LICENSE",
+									Value: licenseAccepted,
+								},
+							},
+							VolumeMounts: append(getAuthVolumeMounts(useHECToken),
+								corev1.VolumeMount{
+									Name:      "events-collector-inputs",
+									MountPath: "/opt/splunkforwarder/etc/apps/osd_kube_events/local",
+								},
+								corev1.VolumeMount{
+									Name:      "kube-events",
+									MountPath: events.DefaultDir,
+									ReadOnly:  true,
+								},
+							),
+						},
+					},
+					Volumes: append(getAuthVolumes(useHECToken),
+						corev1.Volume{
+							Name: "events-collector-inputs",
+							VolumeSource: corev1.VolumeSource{
+								ConfigMap: &corev1.ConfigMapVolumeSource{
+									LocalObjectReference: corev1.LocalObjectReference{
+										Name: EventsCollectorName(instance),
+									},
+								},
+							},
+						},
+						corev1.Volume{
+							Name: "kube-events",
+							VolumeSource: corev1.VolumeSource{
+								EmptyDir: &corev1.EmptyDirVolumeSource{},
+							},
+						},
+					),
+				},
+			},
+		},
+	}
+
+	if useHECToken {
+		deployment.Spec.Template.Spec.InitContainers = []corev1.Container{
+			getInitContainer(),
+		}
+	}
+
+	deployment.Annotations[TemplateHashAnnotation] = TemplateHash(&deployment.Spec.Template)
+
+	return deployment
+}
@@ -0,0 +1,141 @@
+package kube
+
+import (
+	"reflect"
+	"testing"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	"github.com/openshift/splunk-forwarder-operator/config"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestGenerateConfigMapsEventsCollector(t *testing.T) {
+	instance := splunkForwarderInstance(true)
+	instance.Spec.SplunkInputs = []sfv1alpha1.SplunkForwarderInputs{
+		{Path: "/var/derp"},
+		{Path: "/var/log/audit", Index: "openshift_managed_audit"},
+	}
+	namespacedName := types.NamespacedName{Namespace: instanceNamespace, Name: instanceName}
+
+	if got := GenerateConfigMaps(instance, namespacedName, ClusterMetadata{ClusterID: "test"}, nil); len(got) != 2 {
+		t.Fatalf("GenerateConfigMaps() returned %d ConfigMaps without the events collector, want 2", len(got))
+	}
+
+	instance.Spec.EventsCollector = &sfv1alpha1.SplunkEventsCollector{Enabled: true}
+	got := GenerateConfigMaps(instance, namespacedName, ClusterMetadata{ClusterID: "test"}, nil)
+	if len(got) != 3 || got[2].Name != "test-events-collector" {
+		t.Fatalf("GenerateConfigMaps() = %v, want the events collector ConfigMap last", got)
+	}
+	// The index of the inputs is reused
+	want := `[monitor:///var/log/kube-events/events.log]
+sourcetype = _json
+index = openshift_managed_audit
+_meta = clusterid::test
+disabled = false
+
+[monitor:///var/log/kube-events/objects.log]
+sourcetype = _json
+index = openshift_managed_audit
+_meta = clusterid::test
+disabled = false
+
+`
+	if inputs := got[2].Data["inputs.conf"]; inputs != want {
+		t.Errorf("events collector inputs.conf = %q, want %q", inputs, want)
+	}
+}
+
+func TestGenerateEventsCollectorDeployment(t *testing.T) {
+	tests := []struct {
+		name            string
+		collector       *sfv1alpha1.SplunkEventsCollector
+		useHECToken     bool
+		wantImage       string
+		wantCommand     []string
+		wantAuthVolume  string
+		wantInitialized bool
+	}{
+		{
+			name:           "Operator image and mTLS",
+			collector:      &sfv1alpha1.SplunkEventsCollector{Enabled: true},
+			wantImage:      "operator-image",
+			wantCommand:    []string{"splunk-forwarder-operator", "events", "--dir", "/var/log/kube-events"},
+			wantAuthVolume: config.SplunkAuthSecretName,
+		},
+		{
+			name: "Selected namespaces and resources with the HEC token",
+			collector: &sfv1alpha1.SplunkEventsCollector{
+				Enabled:    true,
+				Namespaces: []string{"app", "other"},
+				Resources:  []string{"Pods", "Nodes"},
+				Image:      "collector-image",
+			},
+			useHECToken: true,
+			wantImage:   "collector-image",
+			wantCommand: []string{
+				"splunk-forwarder-operator", "events", "--dir", "/var/log/kube-events",
+				"--namespaces", "app,other", "--resources", "Pods,Nodes",
+			},
+			wantAuthVolume:  config.SplunkHECTokenSecretName,
+			wantInitialized: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := splunkForwarderInstance(true)
+			instance.Spec.EventsCollector = tt.collector
+			deployment := GenerateEventsCollectorDeployment(instance, tt.useHECToken, "operator-image")
+
+			spec := deployment.Spec.Template.Spec
+			if spec.ServiceAccountName != EventsCollectorName(instance) {
+				t.Errorf("service account = %q, want %q", spec.ServiceAccountName, EventsCollectorName(instance))
+			}
+			collector := spec.Containers[0]
+			if collector.Image != tt.wantImage || !reflect.DeepEqual(collector.Command, tt.wantCommand) {
+				t.Errorf("collector = %s %v, want %s %v", collector.Image, collector.Command, tt.wantImage, tt.wantCommand)
+			}
+			if image := spec.Containers[1].Image; image != forwarderPullSpec(instance) {
+				t.Errorf("forwarder image = %q, want %q", image, forwarderPullSpec(instance))
+			}
+			found := false
+			for _, volume := range spec.Volumes {
+				if volume.Name == tt.wantAuthVolume {
+					found = true
+				}
+			}
+			if !found {
+				t.Errorf("volumes = %v, want the %s volume", spec.Volumes, tt.wantAuthVolume)
+			}
+			if initialized := len(spec.InitContainers) > 0; initialized != tt.wantInitialized {
+				t.Errorf("init containers = %v, want them %v", spec.InitContainers, tt.wantInitialized)
+			}
+			if deployment.Annotations[TemplateHashAnnotation] != TemplateHash(&deployment.Spec.Template) {
+				t.Errorf("template hash annotation is not the hash of the template")
+			}
+			for _, volume := range spec.Volumes {
+				if volume.HostPath != nil {
+					t.Errorf("volume %s mounts the host, want no host access", volume.Name)
+				}
+			}
+		})
+	}
+}
+
+func TestGenerateEventsCollectorClusterRoleBinding(t *testing.T) {
+	instance := splunkForwarderInstance(true)
+	instance.Namespace = "other-namespace"
+	serviceAccount := GenerateEventsCollectorServiceAccount(instance)
+	binding := GenerateEventsCollectorClusterRoleBinding(instance)
+
+	if binding.Name != EventsCollectorClusterRole+"-other-namespace-"+instance.Name {
+		t.Errorf("binding name = %q, want it named after the namespace and name of the CR", binding.Name)
+	}
+	want := []rbacv1.Subject{{Kind: "ServiceAccount", Name: serviceAccount.Name, Namespace: "other-namespace"}}
+	if !reflect.DeepEqual(binding.Subjects, want) {
+		t.Errorf("binding subjects = %v, want the ServiceAccount of the collector %v", binding.Subjects, want)
+	}
+	if binding.RoleRef.Name != EventsCollectorClusterRole {
+		t.Errorf("binding role = %q, want %q", binding.RoleRef.Name, EventsCollectorClusterRole)
+	}
+}
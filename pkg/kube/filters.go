@@ -0,0 +1,111 @@
+package kube
+
+import (
+	"strings"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+)
+
+const (
+	// defaultFilterScope is the props.conf stanza of the filters that do not select a sourcetype, source or host
+	defaultFilterScope = "_json"
+	// discardTransform sends every event to the nullQueue, ahead of the Keep filters of a scope putting the
+	// matching events back. It is named out of the filter_ prefix, so that it cannot collide with a filter.
+	discardTransform = "discard_filters"
+)
+
+// filterScope returns the props.conf stanza a filter is applied in
+func filterScope(filter sfv1alpha1.SplunkFilter) string {
+	switch {
+	case filter.SourceType != "":
+		return filter.SourceType
+	case filter.Source != "":
+		return "source::" + filter.Source
+	case filter.Host != "":
+		return "host::" + filter.Host
+	}
+	return defaultFilterScope
+}
+
+// filterTransform renders a transforms.conf stanza
+func filterTransform(name, destKey, format, regex string) string {
+	return "[" + name + "]\n" +
+		"DEST_KEY = " + destKey + "\n" +
+		"FORMAT = " + format + "\n" +
+		"REGEX = " + regex + "\n\n"
+}
+
+// sedEscape escapes the forward slashes of a SEDCMD expression part that are not escaped yet
+func sedEscape(s string) string {
+	var b strings.Builder
+	escaped := false
+	for _, r := range s {
+		if r == '/' && !escaped {
+			b.WriteRune('\\')
+		}
+		escaped = r == '\\' && !escaped
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// sedCommand renders the value of a SEDCMD setting that replaces every match of regex
+func sedCommand(regex, replacement string) string {
+	return "s/" + sedEscape(regex) + "/" + sedEscape(replacement) + "/g"
+}
+
+// addFilters adds the props.conf settings of the filters of the CR to props, and returns the transforms.conf
+// content. The Keep filters of a scope are applied first: every event is discarded, and those matching one
+// of them are put back. The other transforms of the scope follow in the order the filters are listed, so
+// that a Keep filter does not put back the events another filter drops.
+func addFilters(props *propsConf, filters []sfv1alpha1.SplunkFilter) (transforms string) {
+	scopes := []string{}
+	keeps := map[string][]string{}
+	classes := map[string][]string{}
+	seds := map[string]string{}
+
+	for _, filter := range filters {
+		scope := filterScope(filter)
+		if _, ok := classes[scope]; !ok {
+			scopes = append(scopes, scope)
+			classes[scope] = []string{}
+		}
+
+		name := "filter_" + filter.Name
+		switch filter.Action {
+		case sfv1alpha1.FilterActionKeep:
+			if len(keeps) == 0 {
+				transforms += filterTransform(discardTransform, "queue", "nullQueue", ".")
+			}
+			if len(keeps[scope]) == 0 {
+				keeps[scope] = []string{discardTransform}
+			}
+			transforms += filterTransform(name, "queue", "indexQueue", filter.Filter)
+			keeps[scope] = append(keeps[scope], name)
+		case sfv1alpha1.FilterActionSetIndex:
+			transforms += filterTransform(name, "_MetaData:Index", filter.Value, filter.Filter)
+			classes[scope] = append(classes[scope], name)
+		case sfv1alpha1.FilterActionSetSourceType:
+			transforms += filterTransform(name, "MetaData:Sourcetype", "sourcetype::"+filter.Value, filter.Filter)
+			classes[scope] = append(classes[scope], name)
+		case sfv1alpha1.FilterActionRoute:
+			transforms += filterTransform(name, "_TCP_ROUTING", filter.Value, filter.Filter)
+			classes[scope] = append(classes[scope], name)
+		case sfv1alpha1.FilterActionMask:
+			seds[scope] += "SEDCMD-" + name + " = " + sedCommand(filter.Filter, filter.Value) + "\n"
+		default:
+			transforms += filterTransform(name, "queue", "nullQueue", filter.Filter)
+			classes[scope] = append(classes[scope], name)
+		}
+	}
+
+	for _, scope := range scopes {
+		settings := ""
+		if scopeClasses := append(keeps[scope], classes[scope]...); len(scopeClasses) > 0 {
+			settings += "TRANSFORMS-filters = " + strings.Join(scopeClasses, ", ") + "\n"
+		}
+		props.add(scope, settings+seds[scope])
+	}
+
+	return transforms
+}
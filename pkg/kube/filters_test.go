@@ -0,0 +1,52 @@
+package kube
+
+import (
+	"strings"
+	"testing"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+)
+
+func TestSedEscape(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "No slashes", in: `"token":"[^"]*"`, want: `"token":"[^"]*"`},
+		{name: "Slash", in: `/var/log`, want: `\/var\/log`},
+		{name: "Escaped slash", in: `\/var\/log`, want: `\/var\/log`},
+		{name: "Escaped backslash", in: `a\\/b`, want: `a\\\/b`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sedEscape(tt.in); got != tt.want {
+				t.Errorf("sedEscape() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAddFiltersKeep(t *testing.T) {
+	props := newPropsConf()
+	transforms := addFilters(props, []sfv1alpha1.SplunkFilter{
+		{Name: "drop_debug", Filter: "debug", SourceType: "app"},
+		{Name: "keep_errors", Filter: "error", Action: sfv1alpha1.FilterActionKeep, SourceType: "app"},
+		{Name: "keep_warnings", Filter: "warning", Action: sfv1alpha1.FilterActionKeep, SourceType: "app"},
+		{Name: "keep_audit", Filter: "audit", Action: sfv1alpha1.FilterActionKeep, SourceType: "audit"},
+	})
+
+	// Every event is discarded once per scope before the keep-lists put back what matches any of them, and
+	// the drop filters come last so that they are not undone
+	for _, want := range []string{
+		"\n[app]\nTRANSFORMS-filters = discard_filters, filter_keep_errors, filter_keep_warnings, filter_drop_debug\n",
+		"\n[audit]\nTRANSFORMS-filters = discard_filters, filter_keep_audit\n",
+	} {
+		if !strings.Contains(props.String(), want) {
+			t.Errorf("props.conf = %q, want %q", props.String(), want)
+		}
+	}
+	if got := strings.Count(transforms, "["+discardTransform+"]"); got != 1 {
+		t.Errorf("transforms.conf has %d %s stanzas, want 1:\n%s", got, discardTransform, transforms)
+	}
+}
@@ -0,0 +1,136 @@
+package kube
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// fipsSSLVersions are the TLS versions of the forwarders in FIPS mode
+	fipsSSLVersions = "tls1.2"
+	// fipsCipherSuite is the cipher suite of the forwarders in FIPS mode, the AES-GCM suites with ECDHE
+	fipsCipherSuite = "ECDHE-ECDSA-AES256-GCM-SHA384:ECDHE-RSA-AES256-GCM-SHA384:ECDHE-ECDSA-AES128-GCM-SHA256:ECDHE-RSA-AES128-GCM-SHA256"
+)
+
+// fipsCiphers are the OpenSSL cipher names approved for FIPS mode
+var fipsCiphers = map[string]bool{
+	"ECDHE-ECDSA-AES256-GCM-SHA384": true,
+	"ECDHE-RSA-AES256-GCM-SHA384":   true,
+	"ECDHE-ECDSA-AES128-GCM-SHA256": true,
+	"ECDHE-RSA-AES128-GCM-SHA256":   true,
+	"ECDHE-ECDSA-AES256-SHA384":     true,
+	"ECDHE-RSA-AES256-SHA384":       true,
+	"ECDHE-ECDSA-AES128-SHA256":     true,
+	"ECDHE-RSA-AES128-SHA256":       true,
+	"AES256-GCM-SHA384":             true,
+	"AES128-GCM-SHA256":             true,
+	"TLS_AES_256_GCM_SHA384":        true,
+	"TLS_AES_128_GCM_SHA256":        true,
+}
+
+// fipsTLSVersions are the sslVersions values approved for FIPS mode
+var fipsTLSVersions = map[string]bool{
+	"tls1.2": true,
+	"tls1.3": true,
+}
+
+// FIPSEnabled returns whether the forwarders of the CR run in FIPS mode: as set in the CR, or in Auto mode
+// as the cluster is
+func FIPSEnabled(instance *sfv1alpha1.SplunkForwarder, clusterFIPS bool) bool {
+	switch instance.Spec.FIPS {
+	case sfv1alpha1.FIPSEnabled:
+		return true
+	case sfv1alpha1.FIPSDisabled:
+		return false
+	}
+	return clusterFIPS
+}
+
+// FIPSMode returns the FIPS mode reported in the status of the CR
+func FIPSMode(fips bool) string {
+	if fips {
+		return sfv1alpha1.FIPSEnabled
+	}
+	return sfv1alpha1.FIPSDisabled
+}
+
+// ApplyFIPSToPodTemplate sets SPLUNK_FIPS on the Splunk forwarder containers of a pod template, which
+// makes splunkd run its FIPS module. The pod template changes with the FIPS mode, rolling the forwarders.
+func ApplyFIPSToPodTemplate(template *corev1.PodTemplateSpec, fips bool) {
+	if !fips {
+		return
+	}
+	for i := range template.Spec.Containers {
+		if template.Spec.Containers[i].Name == "splunk-uf" {
+			template.Spec.Containers[i].Env = append(template.Spec.Containers[i].Env, corev1.EnvVar{Name: "SPLUNK_FIPS", Value: "1"})
+		}
+	}
+}
+
+// CheckFIPSConfigs returns an error naming the first cipher or TLS version that is not FIPS-approved in
+// the server.conf and outputs.conf of the generated ConfigMaps and of the splunk-auth Secret
+func CheckFIPSConfigs(configMaps []*corev1.ConfigMap, authSecret *corev1.Secret) error {
+	for _, cm := range configMaps {
+		for _, file := range []string{"server.conf", "outputs.conf"} {
+			if err := checkFIPSConf(cm.Data[file]); err != nil {
+				return fmt.Errorf("configmap %s %s: %w", cm.Name, file, err)
+			}
+		}
+	}
+	if authSecret != nil {
+		for _, file := range []string{"server.conf", "outputs.conf"} {
+			if err := checkFIPSConf(string(authSecret.Data[file])); err != nil {
+				return fmt.Errorf("secret %s %s: %w", authSecret.Name, file, err)
+			}
+		}
+	}
+	return nil
+}
+
+// checkFIPSConf checks the cipherSuite and sslVersions settings of every stanza of a .conf file
+func checkFIPSConf(conf string) error {
+	for _, line := range strings.Split(conf, "\n") {
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "cipherSuite":
+			for _, cipher := range strings.Split(value, ":") {
+				// Exclusions, such as !aNULL, do not add ciphers
+				if cipher == "" || strings.HasPrefix(cipher, "!") || strings.HasPrefix(cipher, "-") {
+					continue
+				}
+				if !fipsCiphers[cipher] {
+					return fmt.Errorf("cipher %s is not FIPS-approved, use one of %s", cipher, strings.Join(sortedKeys(fipsCiphers), ", "))
+				}
+			}
+		case "sslVersions":
+			for _, version := range strings.Split(value, ",") {
+				version = strings.TrimSpace(version)
+				if version == "" || strings.HasPrefix(version, "-") {
+					continue
+				}
+				if !fipsTLSVersions[version] {
+					return fmt.Errorf("TLS version %s is not FIPS-approved, use %s", version, strings.Join(sortedKeys(fipsTLSVersions), " or "))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// sortedKeys returns the keys of a set in order, for stable messages
+func sortedKeys(set map[string]bool) []string {
+	ret := make([]string, 0, len(set))
+	for key := range set {
+		ret = append(ret, key)
+	}
+	sort.Strings(ret)
+	return ret
+}
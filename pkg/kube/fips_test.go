@@ -0,0 +1,98 @@
+package kube
+
+import (
+	"strings"
+	"testing"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestFIPSEnabled(t *testing.T) {
+	instance := splunkForwarderInstance(true)
+	for _, tt := range []struct {
+		mode        string
+		clusterFIPS bool
+		want        bool
+	}{
+		{"", false, false},
+		{"", true, true},
+		{sfv1alpha1.FIPSAuto, true, true},
+		{sfv1alpha1.FIPSEnabled, false, true},
+		{sfv1alpha1.FIPSDisabled, true, false},
+	} {
+		instance.Spec.FIPS = tt.mode
+		if got := FIPSEnabled(instance, tt.clusterFIPS); got != tt.want {
+			t.Errorf("FIPSEnabled(%q, %v) = %v, want %v", tt.mode, tt.clusterFIPS, got, tt.want)
+		}
+	}
+}
+
+func TestApplyFIPS(t *testing.T) {
+	instance := splunkForwarderInstance(true)
+	instance.Spec.TrustedCABundle = true
+	namespacedName := types.NamespacedName{Namespace: instanceNamespace, Name: instanceName}
+
+	ds := GenerateDaemonSet(instance, true, operatorImage)
+	ApplyFIPSToPodTemplate(&ds.Spec.Template, true)
+	for _, container := range ds.Spec.Template.Spec.Containers {
+		hasFIPS := false
+		for _, v := range container.Env {
+			if v.Name == "SPLUNK_FIPS" && v.Value == "1" {
+				hasFIPS = true
+			}
+		}
+		if hasFIPS != (container.Name == "splunk-uf") {
+			t.Errorf("container %s has SPLUNK_FIPS = %v", container.Name, hasFIPS)
+		}
+	}
+
+	// The FIPS settings join the stanzas of the trusted CA bundle
+	configMaps := GenerateConfigMaps(instance, namespacedName, ClusterMetadata{ClusterID: "test"}, nil)
+	ApplyTLSToConfigMaps(configMaps, TLSProfile{}, true)
+	wantOutputs := `
+[tcpout]
+sslRootCAPath = /opt/splunkforwarder/etc/trusted-ca/ca-bundle.crt
+sslVersions = tls1.2
+cipherSuite = ` + fipsCipherSuite + `
+ecdhCurves = ` + ecdhCurves + `
+`
+	if got := configMaps[1].Data["outputs.conf"]; got != wantOutputs {
+		t.Errorf("outputs.conf = %q, want %q", got, wantOutputs)
+	}
+	if got := configMaps[1].Data["server.conf"]; strings.Count(got, "[sslConfig]") != 1 || !strings.Contains(got, "sslVersions = tls1.2\n") {
+		t.Errorf("server.conf = %q, want one [sslConfig] stanza with the FIPS settings", got)
+	}
+	if err := CheckFIPSConfigs(configMaps, nil); err != nil {
+		t.Errorf("CheckFIPSConfigs() error = %v for the FIPS configuration", err)
+	}
+}
+
+func TestCheckFIPSConfigs(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		conf    string
+		wantErr string
+	}{
+		{"no TLS settings", "\n[tcpout]\ndefaultGroup = splunk\n", ""},
+		{"approved", "[tcpout:splunk]\ncipherSuite = ECDHE-RSA-AES256-GCM-SHA384:!aNULL\nsslVersions = tls1.2, -tls1.1\n", ""},
+		{"non-FIPS cipher", "[tcpout:splunk]\ncipherSuite = ECDHE-RSA-AES256-GCM-SHA384:ECDHE-RSA-CHACHA20-POLY1305\n", "cipher ECDHE-RSA-CHACHA20-POLY1305 is not FIPS-approved"},
+		{"all TLS versions", "[sslConfig]\nsslVersions = tls\n", "TLS version tls is not FIPS-approved"},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			secret := &corev1.Secret{Data: map[string][]byte{"outputs.conf": []byte(tt.conf)}}
+			secret.Name = "splunk-auth"
+			err := CheckFIPSConfigs(nil, secret)
+			if tt.wantErr == "" {
+				if err != nil {
+					t.Errorf("CheckFIPSConfigs() error = %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("CheckFIPSConfigs() error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -16,6 +16,7 @@ const (
 	image             = "test-image"
 	imageTag          = "0.0.1"
 	imageDigest       = "sha256:2452a3f01e840661ee1194777ed5a9185ceaaa9ec7329ed364fa2f02be22a701"
+	operatorImage     = "test-operator-image"
 )
 
 // splunkForwarderInstance returns (a pointer to) a SplunkForwarder CR as input to
@@ -0,0 +1,137 @@
+package kube
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// internalRegistry is the OpenShift image registry, whose images are not pulled through the mirrors
+const internalRegistry = "image-registry.openshift-image-registry.svc"
+
+// latestTag is the tag that registries move to every new image
+const latestTag = "latest"
+
+var (
+	// tagPattern matches the tags the registries accept
+	tagPattern = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+	// digestPattern matches the digests of the forwarder image
+	digestPattern = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+)
+
+// ValidateImage returns an error when the forwarder image of the CR cannot be pulled, or is referenced by
+// the latest tag, which would run different images on the nodes as the tag moves
+func ValidateImage(instance *sfv1alpha1.SplunkForwarder) error {
+	spec := instance.Spec
+	switch {
+	case spec.Image == "":
+		return fmt.Errorf("image is not set")
+	case strings.ContainsAny(spec.Image, "@ ") || strings.Contains(spec.Image[strings.LastIndex(spec.Image, "/")+1:], ":"):
+		return fmt.Errorf("image %q must not include a tag or a digest, set imageTag or imageDigest instead", spec.Image)
+	case spec.ImageDigest != "":
+		if !digestPattern.MatchString(spec.ImageDigest) {
+			return fmt.Errorf("imageDigest %q is not a sha256 digest", spec.ImageDigest)
+		}
+	case spec.ImageTag == "":
+		return fmt.Errorf("neither imageTag nor imageDigest is set")
+	case !tagPattern.MatchString(spec.ImageTag):
+		return fmt.Errorf("imageTag %q is not a valid tag", spec.ImageTag)
+	case spec.ImageTag == latestTag:
+		return fmt.Errorf("imageTag %s is mutable, set a version tag or imageDigest", latestTag)
+	}
+	return nil
+}
+
+// TagReference returns the image:tag reference of the forwarder image, or "" when the CR pins a digest
+func TagReference(instance *sfv1alpha1.SplunkForwarder) string {
+	if instance.Spec.ImageDigest != "" {
+		return ""
+	}
+	return instance.Spec.Image + ":" + instance.Spec.ImageTag
+}
+
+// PinImageDigest pins the forwarder image of the CR to the digest recorded in the status, as long as it
+// was resolved from the current tag
+func PinImageDigest(instance *sfv1alpha1.SplunkForwarder, status sfv1alpha1.SplunkForwarderStatus) {
+	reference := TagReference(instance)
+	if reference == "" || status.ImageDigest == "" || status.ResolvedImage != reference {
+		return
+	}
+	instance.Spec.ImageDigest = status.ImageDigest
+}
+
+// forwarderPullPolicy returns the pull policy of the forwarder image: as set in the CR, or IfNotPresent
+// when the image is pinned by digest, so that restarting pods do not depend on the registry
+func forwarderPullPolicy(instance *sfv1alpha1.SplunkForwarder) corev1.PullPolicy {
+	if instance.Spec.ImagePullPolicy != "" {
+		return corev1.PullPolicy(instance.Spec.ImagePullPolicy)
+	}
+	if instance.Spec.ImageDigest != "" {
+		return corev1.PullIfNotPresent
+	}
+	return corev1.PullAlways
+}
+
+// imagePullSecrets returns the pull secrets of the forwarder pods
+func imagePullSecrets(instance *sfv1alpha1.SplunkForwarder) []corev1.LocalObjectReference {
+	if len(instance.Spec.ImagePullSecrets) == 0 {
+		return nil
+	}
+	ret := make([]corev1.LocalObjectReference, len(instance.Spec.ImagePullSecrets))
+	for i, name := range instance.Spec.ImagePullSecrets {
+		ret[i] = corev1.LocalObjectReference{Name: name}
+	}
+	return ret
+}
+
+// UnmirroredImages returns, in order, the images of the pod templates that the cluster image mirrors do
+// not cover: images referenced by tag, which digest mirrors do not apply to, and images outside of the
+// mirror sources. Images of the internal registry are left out.
+func UnmirroredImages(templates []*corev1.PodTemplateSpec, sources []string) []string {
+	images := map[string]bool{}
+	for _, template := range templates {
+		for _, container := range append(template.Spec.InitContainers, template.Spec.Containers...) {
+			images[container.Image] = true
+		}
+		for _, volume := range template.Spec.Volumes {
+			if volume.Image != nil {
+				images[volume.Image.Reference] = true
+			}
+		}
+	}
+
+	ret := []string{}
+	for image := range images {
+		if image == "" || strings.HasPrefix(image, internalRegistry) {
+			continue
+		}
+		repository, _, byDigest := strings.Cut(image, "@")
+		if byDigest && mirrored(repository, sources) {
+			continue
+		}
+		ret = append(ret, image)
+	}
+	sort.Strings(ret)
+	return ret
+}
+
+// mirrored returns whether a repository is under one of the mirror sources: the repository itself, one
+// of its parent namespaces or its registry, which may be a wildcard such as *.example.com
+func mirrored(repository string, sources []string) bool {
+	for _, source := range sources {
+		if repository == source || strings.HasPrefix(repository, source+"/") {
+			return true
+		}
+		if domain, ok := strings.CutPrefix(source, "*."); ok {
+			registry, _, _ := strings.Cut(repository, "/")
+			if strings.HasSuffix(registry, "."+domain) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,98 @@
+package kube
+
+import (
+	"reflect"
+	"testing"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestUnmirroredImages(t *testing.T) {
+	instance := splunkForwarderInstance(true)
+	appImage := "quay.io/example/ta-oci@" + imageDigest
+	instance.Spec.Apps = []sfv1alpha1.SplunkApp{
+		{Name: "TA-oci", Image: appImage},
+		{Name: "TA-tag", Image: "quay.io/example/ta-tag:1.0"},
+	}
+	ds := GenerateDaemonSet(instance, true, operatorImage)
+	forwarderImage := image + "@" + imageDigest
+	templates := []*corev1.PodTemplateSpec{&ds.Spec.Template}
+
+	for _, tt := range []struct {
+		name    string
+		sources []string
+		want    []string
+	}{
+		{"no source", nil, []string{appImage, "quay.io/example/ta-tag:1.0", forwarderImage, operatorImage}},
+		{"repository", []string{image}, []string{appImage, "quay.io/example/ta-tag:1.0", operatorImage}},
+		// Digest mirrors do not apply to images referenced by tag
+		{"parent namespace", []string{image, "quay.io/example"}, []string{"quay.io/example/ta-tag:1.0", operatorImage}},
+		{"other repository", []string{image + "-other", "quay.io/example/ta"}, []string{appImage, "quay.io/example/ta-tag:1.0", forwarderImage, operatorImage}},
+		{"wildcard registry", []string{image, "*.io"}, []string{"quay.io/example/ta-tag:1.0", operatorImage}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := UnmirroredImages(templates, tt.sources); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("UnmirroredImages() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestForwarderPullPolicy(t *testing.T) {
+	if got := forwarderPullPolicy(splunkForwarderInstance(true)); got != corev1.PullIfNotPresent {
+		t.Errorf("forwarderPullPolicy() = %s for an image pinned by digest, want IfNotPresent", got)
+	}
+	if got := forwarderPullPolicy(splunkForwarderInstance(false)); got != corev1.PullAlways {
+		t.Errorf("forwarderPullPolicy() = %s for an image tag, want Always", got)
+	}
+	instance := splunkForwarderInstance(false)
+	instance.Spec.ImagePullPolicy = "Never"
+	if got := forwarderPullPolicy(instance); got != corev1.PullNever {
+		t.Errorf("forwarderPullPolicy() = %s, want the policy of the CR", got)
+	}
+}
+
+func TestValidateImage(t *testing.T) {
+	for _, tt := range []struct {
+		name    string
+		update  func(*sfv1alpha1.SplunkForwarder)
+		wantErr bool
+	}{
+		{"tag", func(*sfv1alpha1.SplunkForwarder) {}, false},
+		{"digest", func(instance *sfv1alpha1.SplunkForwarder) { instance.Spec.ImageDigest = imageDigest }, false},
+		{"registry port", func(instance *sfv1alpha1.SplunkForwarder) { instance.Spec.Image = "localhost:5000/forwarder" }, false},
+		{"no image", func(instance *sfv1alpha1.SplunkForwarder) { instance.Spec.Image = "" }, true},
+		{"no tag nor digest", func(instance *sfv1alpha1.SplunkForwarder) { instance.Spec.ImageTag = "" }, true},
+		{"latest", func(instance *sfv1alpha1.SplunkForwarder) { instance.Spec.ImageTag = "latest" }, true},
+		{"invalid tag", func(instance *sfv1alpha1.SplunkForwarder) { instance.Spec.ImageTag = "1.0:x" }, true},
+		{"invalid digest", func(instance *sfv1alpha1.SplunkForwarder) { instance.Spec.ImageDigest = "sha256:abc" }, true},
+		{"tag in the image", func(instance *sfv1alpha1.SplunkForwarder) { instance.Spec.Image = "quay.io/forwarder:1.0" }, true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := splunkForwarderInstance(false)
+			tt.update(instance)
+			if err := ValidateImage(instance); (err != nil) != tt.wantErr {
+				t.Errorf("ValidateImage() error = %v, wantErr %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPinImageDigest(t *testing.T) {
+	status := sfv1alpha1.SplunkForwarderStatus{ResolvedImage: image + ":" + imageTag, ImageDigest: imageDigest}
+
+	instance := splunkForwarderInstance(false)
+	PinImageDigest(instance, status)
+	if got := GenerateDaemonSet(instance, true, operatorImage).Spec.Template.Spec.Containers[0].Image; got != image+"@"+imageDigest {
+		t.Errorf("forwarder image = %q, want the resolved digest", got)
+	}
+
+	// A new tag is not pinned to the digest of the previous one
+	instance = splunkForwarderInstance(false)
+	instance.Spec.ImageTag = "0.0.2"
+	PinImageDigest(instance, status)
+	if instance.Spec.ImageDigest != "" {
+		t.Errorf("imageDigest = %q, want the tag of the CR", instance.Spec.ImageDigest)
+	}
+}
@@ -0,0 +1,154 @@
+package kube
+
+import (
+	"strconv"
+	"strings"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// journaldSourceType is the sourcetype of the journald inputs that do not set one
+	journaldSourceType = "journald"
+	// journaldFields are the journal fields kept in the events besides the message
+	journaldFields = "PRIORITY,_SYSTEMD_UNIT,SYSLOG_IDENTIFIER"
+)
+
+// usesJournald returns whether the CR has journald inputs, which need the journal of the node mounted
+func usesJournald(instance *sfv1alpha1.SplunkForwarder) bool {
+	for _, input := range instance.Spec.SplunkInputs {
+		if input.Type == sfv1alpha1.InputTypeJournald {
+			return true
+		}
+	}
+	return false
+}
+
+// journaldStanzaName returns the name of the journald stanza of an input, made from its units and priority
+func journaldStanzaName(input sfv1alpha1.SplunkForwarderInputs) string {
+	name := "journal"
+	if len(input.Units) > 0 {
+		name = strings.Join(input.Units, "+")
+	}
+	if input.Priority != "" {
+		name += "-" + input.Priority
+	}
+	return name
+}
+
+// journaldInput renders the journald stanza of an input. The units are matched with journalctl's
+// "_SYSTEMD_UNIT=<unit>" matches, joined by "+" so that the entries of any of them are read.
+func journaldInput(input sfv1alpha1.SplunkForwarderInputs, name, meta string) string {
+	ret := "[journald://" + name + "]\n"
+	if len(input.Units) > 0 {
+		matches := []string{}
+		for _, unit := range input.Units {
+			matches = append(matches, "_SYSTEMD_UNIT="+unit)
+		}
+		ret += "journalctl-filter = " + strings.Join(matches, " + ") + "\n"
+	}
+	if input.Priority != "" {
+		ret += "journalctl-priority = " + input.Priority + "\n"
+	}
+	ret += "journalctl-include-fields = " + journaldFields + "\n"
+	ret += "sourcetype = " + inputSourceType(input) + "\n"
+	if input.Index != "" {
+		ret += "index = " + input.Index + "\n"
+	} else {
+		ret += "index = main\n"
+	}
+	if meta != "" {
+		ret += "_meta = " + meta + "\n"
+	}
+	ret += "disabled = false\n"
+	ret += "\n"
+	return ret
+}
+
+// journaldInputs renders the journald stanzas of the inputs, numbering the inputs that read the same
+// units with the same priority so that their stanzas stay apart
+func journaldInputs(inputs []sfv1alpha1.SplunkForwarderInputs, meta string) string {
+	ret := ""
+	seen := map[string]int{}
+	for _, input := range inputs {
+		if input.Type != sfv1alpha1.InputTypeJournald {
+			continue
+		}
+		name := journaldStanzaName(input)
+		seen[name]++
+		if seen[name] > 1 {
+			name += "_" + strconv.Itoa(seen[name])
+		}
+		ret += journaldInput(input, name, meta)
+	}
+	return ret
+}
+
+// inputSourceType returns the sourcetype of the events of an input
+func inputSourceType(input sfv1alpha1.SplunkForwarderInputs) string {
+	switch {
+	case input.SourceType != "":
+		return input.SourceType
+	case input.Type == sfv1alpha1.InputTypeJournald:
+		return journaldSourceType
+	}
+	return "_json"
+}
+
+// getJournalVolumes returns the host journal directories and machine ID read by the journald inputs.
+// journalctl finds the journal of the node under the directory named after its machine ID.
+func getJournalVolumes() []corev1.Volume {
+	hostPathDirectoryOrCreate := corev1.HostPathDirectoryOrCreate
+	hostPathFile := corev1.HostPathFile
+	return []corev1.Volume{
+		{
+			Name: "journal",
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{
+					Path: "/var/log/journal",
+					Type: &hostPathDirectoryOrCreate,
+				},
+			},
+		},
+		{
+			Name: "run-journal",
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{
+					Path: "/run/log/journal",
+					Type: &hostPathDirectoryOrCreate,
+				},
+			},
+		},
+		{
+			Name: "machine-id",
+			VolumeSource: corev1.VolumeSource{
+				HostPath: &corev1.HostPathVolumeSource{
+					Path: "/etc/machine-id",
+					Type: &hostPathFile,
+				},
+			},
+		},
+	}
+}
+
+// getJournalVolumeMounts mounts the host journal where journalctl looks for it in the forwarder container
+func getJournalVolumeMounts() []corev1.VolumeMount {
+	return []corev1.VolumeMount{
+		{
+			Name:      "journal",
+			MountPath: "/var/log/journal",
+			ReadOnly:  true,
+		},
+		{
+			Name:      "run-journal",
+			MountPath: "/run/log/journal",
+			ReadOnly:  true,
+		},
+		{
+			Name:      "machine-id",
+			MountPath: "/etc/machine-id",
+			ReadOnly:  true,
+		},
+	}
+}
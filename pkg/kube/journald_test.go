@@ -0,0 +1,96 @@
+package kube
+
+import (
+	"reflect"
+	"testing"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestGenerateConfigMapsJournald(t *testing.T) {
+	instance := splunkForwarderInstance(true)
+	instance.Spec.SplunkInputs = []sfv1alpha1.SplunkForwarderInputs{
+		{Path: "/var/derp"},
+		{
+			Type:     sfv1alpha1.InputTypeJournald,
+			Units:    []string{"kubelet.service", "crio.service"},
+			Priority: "info",
+			Index:    "openshift_managed_node",
+		},
+		{Type: sfv1alpha1.InputTypeJournald, SourceType: "linux:sshd", Units: []string{"sshd.service"}},
+		{Type: sfv1alpha1.InputTypeJournald, Units: []string{"sshd.service"}, Index: "security"},
+	}
+
+	got := GenerateConfigMaps(instance, types.NamespacedName{Namespace: instanceNamespace, Name: instanceName}, ClusterMetadata{ClusterID: "test"}, nil)
+	want := `[monitor:///var/derp]
+sourcetype = _json
+index = main
+_meta = clusterid::test
+disabled = false
+
+[journald://kubelet.service+crio.service-info]
+journalctl-filter = _SYSTEMD_UNIT=kubelet.service + _SYSTEMD_UNIT=crio.service
+journalctl-priority = info
+journalctl-include-fields = PRIORITY,_SYSTEMD_UNIT,SYSLOG_IDENTIFIER
+sourcetype = journald
+index = openshift_managed_node
+_meta = clusterid::test
+disabled = false
+
+[journald://sshd.service]
+journalctl-filter = _SYSTEMD_UNIT=sshd.service
+journalctl-include-fields = PRIORITY,_SYSTEMD_UNIT,SYSLOG_IDENTIFIER
+sourcetype = linux:sshd
+index = main
+_meta = clusterid::test
+disabled = false
+
+[journald://sshd.service_2]
+journalctl-filter = _SYSTEMD_UNIT=sshd.service
+journalctl-include-fields = PRIORITY,_SYSTEMD_UNIT,SYSLOG_IDENTIFIER
+sourcetype = journald
+index = security
+_meta = clusterid::test
+disabled = false
+
+`
+	if inputs := got[1].Data["inputs.conf"]; inputs != want {
+		t.Errorf("GenerateConfigMaps() inputs.conf = %q, want %q", inputs, want)
+	}
+
+	// The masking rules also apply to the journald sourcetypes
+	wantSourceTypes := []string{"_json", "journald", "linux:sshd"}
+	if sourceTypes := inputSourceTypes(instance.Spec.SplunkInputs, nil, nil); !reflect.DeepEqual(sourceTypes, wantSourceTypes) {
+		t.Errorf("inputSourceTypes() = %v, want %v", sourceTypes, wantSourceTypes)
+	}
+}
+
+func TestJournalMounts(t *testing.T) {
+	instance := splunkForwarderInstance(true)
+	hasVolume := func(volumes []corev1.Volume) bool {
+		for _, volume := range volumes {
+			if volume.Name == "machine-id" {
+				return true
+			}
+		}
+		return false
+	}
+	hasMount := func(mounts []corev1.VolumeMount) bool {
+		for _, mount := range mounts {
+			if mount.MountPath == "/var/log/journal" {
+				return true
+			}
+		}
+		return false
+	}
+
+	if hasVolume(GetVolumes(instance, true, true, false)) || hasMount(GetVolumeMounts(instance, false)) {
+		t.Errorf("the journal is mounted without journald inputs")
+	}
+	instance.Spec.SplunkInputs = append(instance.Spec.SplunkInputs, sfv1alpha1.SplunkForwarderInputs{Type: sfv1alpha1.InputTypeJournald})
+	if !hasVolume(GetVolumes(instance, true, true, false)) || !hasMount(GetVolumeMounts(instance, false)) {
+		t.Errorf("the journal is not mounted with a journald input")
+	}
+}
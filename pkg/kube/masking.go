@@ -0,0 +1,102 @@
+package kube
+
+import (
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+)
+
+// builtinMaskingRules are the masking rules applied unless the CR disables them.
+// The regexes are kept to the syntax shared by PCRE (Splunk) and RE2 so the unit tests can run them.
+var builtinMaskingRules = []sfv1alpha1.SplunkMaskingRule{
+	{
+		// Authorization headers and kubeconfig-style "Bearer <token>" values
+		Name:        "bearer_token",
+		Regex:       `(?i)(bearer\s+)[A-Za-z0-9._~+/-]+=*`,
+		Replacement: `\1<masked>`,
+	},
+	{
+		// OpenShift OAuth access and authorize tokens, which are also the names of their API objects
+		Name:        "openshift_token",
+		Regex:       `(sha256~)[A-Za-z0-9_-]{43}`,
+		Replacement: `\1<masked>`,
+	},
+	{
+		// Service account and OIDC tokens
+		Name:        "jwt",
+		Regex:       `eyJ[A-Za-z0-9_-]+\.eyJ[A-Za-z0-9_-]+\.[A-Za-z0-9_-]+`,
+		Replacement: `<masked>`,
+	},
+	{
+		Name:        "private_key",
+		Regex:       `(?s)-----BEGIN ([A-Z ]*)PRIVATE KEY-----.*?-----END [A-Z ]*PRIVATE KEY-----`,
+		Replacement: `-----BEGIN \1PRIVATE KEY-----<masked>-----END \1PRIVATE KEY-----`,
+	},
+	{
+		// JSON fields holding credentials, also when escaped inside another JSON string
+		Name:        "secret_field",
+		Regex:       `(?i)(\\?"(?:password|passwd|token|client_secret|client-key-data|secret_key|api_key)\\?"\s*:\s*\\?")[^"\\]*`,
+		Replacement: `\1<masked>`,
+	},
+	{
+		Name:        "aws_access_key",
+		Regex:       `\b((?:AKIA|ASIA)[A-Z0-9]{4})[A-Z0-9]{12}\b`,
+		Replacement: `\1<masked>`,
+	},
+}
+
+// maskingSettings renders the SEDCMD settings of the masking rules of the CR.
+// Splunk applies the SEDCMD classes of a stanza in lexical order, so the built-in rules are prefixed to
+// run before the rules of the CR.
+func maskingSettings(instance *sfv1alpha1.SplunkForwarder) string {
+	ret := ""
+	masking := instance.Spec.Masking
+	if masking == nil {
+		return ret
+	}
+	if !masking.DisableBuiltinRules {
+		for _, rule := range builtinMaskingRules {
+			ret += "SEDCMD-builtin_" + rule.Name + " = " + sedCommand(rule.Regex, rule.Replacement) + "\n"
+		}
+	}
+	for _, rule := range masking.Rules {
+		ret += "SEDCMD-mask_" + rule.Name + " = " + sedCommand(rule.Regex, rule.Replacement) + "\n"
+	}
+	return ret
+}
+
+// inputSourceTypes returns the sourcetypes the masking rules are added to: "_json" and the sourcetypes of
+// the inputs, pod logs inputs and scripted inputs, in the order they are first listed
+func inputSourceTypes(inputs []sfv1alpha1.SplunkForwarderInputs, podLogs []sfv1alpha1.SplunkPodLogsInput, scripted []sfv1alpha1.SplunkScriptedInput) []string {
+	sourceTypes := []string{"_json"}
+	seen := map[string]bool{"_json": true}
+	for _, input := range inputs {
+		if input.Path == "" && input.Type != sfv1alpha1.InputTypeJournald {
+			continue
+		}
+		sourceType := inputSourceType(input)
+		if seen[sourceType] {
+			continue
+		}
+		seen[sourceType] = true
+		sourceTypes = append(sourceTypes, sourceType)
+	}
+	for _, input := range podLogs {
+		sourceType := input.SourceType
+		if sourceType == "" {
+			sourceType = podLogsSourceType
+		}
+		if seen[sourceType] {
+			continue
+		}
+		seen[sourceType] = true
+		sourceTypes = append(sourceTypes, sourceType)
+	}
+	for _, input := range scripted {
+		sourceType := scriptedInputSourceType(input)
+		if seen[sourceType] {
+			continue
+		}
+		seen[sourceType] = true
+		sourceTypes = append(sourceTypes, sourceType)
+	}
+	return sourceTypes
+}
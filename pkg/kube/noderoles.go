@@ -0,0 +1,167 @@
+package kube
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sort"
+	"strings"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+// nodeRoleLabelPrefix is the prefix of the labels that carry the roles of a node
+const nodeRoleLabelPrefix = "node-role.kubernetes.io/"
+
+// maxNodeRoleSetKeyLength keeps the name label of the forwarder pods, "splunk-forwarder-<key>", within the
+// 63 characters of a label value
+const maxNodeRoleSetKeyLength = 63 - len("splunk-forwarder-")
+
+// NodeRoles returns the node roles referenced by the inputs of the CR, in the order they are first listed
+func NodeRoles(instance *sfv1alpha1.SplunkForwarder) []string {
+	roles := []string{}
+	seen := map[string]bool{}
+	for _, input := range instance.Spec.SplunkInputs {
+		for _, role := range input.NodeRoles {
+			if role == "" || seen[role] {
+				continue
+			}
+			seen[role] = true
+			roles = append(roles, role)
+		}
+	}
+	return roles
+}
+
+// NodeRoleLabels returns the roles of a node from its node-role.kubernetes.io/<role> labels, sorted
+func NodeRoleLabels(labels map[string]string) []string {
+	roles := []string{}
+	for label := range labels {
+		if role, ok := strings.CutPrefix(label, nodeRoleLabelPrefix); ok && role != "" {
+			roles = append(roles, role)
+		}
+	}
+	sort.Strings(roles)
+	return roles
+}
+
+// nodeRoleSets returns the sets of node roles the forwarders are generated for besides the default forwarders:
+// each role referenced by the inputs, followed by the combinations of these roles that nodes have, so that a
+// node with several roles monitors the inputs of each. The roles of a set are in the order of NodeRoles.
+func nodeRoleSets(instance *sfv1alpha1.SplunkForwarder, nodeRoles [][]string) [][]string {
+	roles := NodeRoles(instance)
+	sets := [][]string{}
+	for _, role := range roles {
+		sets = append(sets, []string{role})
+	}
+
+	combinations := map[string][]string{}
+	for _, labels := range nodeRoles {
+		has := map[string]bool{}
+		for _, role := range labels {
+			has[role] = true
+		}
+		set := []string{}
+		for _, role := range roles {
+			if has[role] {
+				set = append(set, role)
+			}
+		}
+		if len(set) > 1 {
+			combinations[nodeRoleSetKey(set)] = set
+		}
+	}
+	keys := make([]string, 0, len(combinations))
+	for key := range combinations {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		sets = append(sets, combinations[key])
+	}
+	return sets
+}
+
+// NodeRoleSetKeys returns the keys of the node role sets the forwarders are generated for, which name their
+// ConfigMaps and DaemonSets and are set in their NodeRoleLabel
+func NodeRoleSetKeys(instance *sfv1alpha1.SplunkForwarder, nodeRoles [][]string) []string {
+	keys := []string{}
+	for _, set := range nodeRoleSets(instance, nodeRoles) {
+		keys = append(keys, nodeRoleSetKey(set))
+	}
+	return keys
+}
+
+// nodeRoleSetKey returns the key of a set of node roles: the role itself for a single role, and for a
+// combination the roles joined with "-" followed by a hash of the set, as role names can contain "-".
+// The default forwarders (no role) have key "".
+func nodeRoleSetKey(roles []string) string {
+	if len(roles) < 2 {
+		return strings.Join(roles, "")
+	}
+	joined := strings.Join(roles, "-")
+	sum := sha256.Sum256([]byte(strings.Join(roles, ",")))
+	hash := hex.EncodeToString(sum[:])[:8]
+	if len(joined) > maxNodeRoleSetKeyLength-len(hash)-1 {
+		joined = strings.TrimRight(joined[:maxNodeRoleSetKeyLength-len(hash)-1], "-")
+	}
+	return joined + "-" + hash
+}
+
+// inputEnabledForRoles returns whether an input is monitored by the forwarders of the nodes with the given
+// set of roles. Inputs without roles are monitored everywhere; the default forwarders (no role) only monitor
+// those.
+func inputEnabledForRoles(input sfv1alpha1.SplunkForwarderInputs, roles []string) bool {
+	if len(input.NodeRoles) == 0 {
+		return true
+	}
+	for _, r := range input.NodeRoles {
+		for _, role := range roles {
+			if r == role {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// localConfigMapName returns the name of the ConfigMap holding the inputs of the node role set with the given key
+func localConfigMapName(key string) string {
+	if key == "" {
+		return "osd-monitored-logs-local"
+	}
+	return "osd-monitored-logs-local-" + key
+}
+
+// nodeRoleAffinity returns the node affinity that schedules the forwarders of a set of node roles onto the
+// nodes with exactly these roles out of the roles referenced by the CR. The default forwarders (no role) are
+// scheduled onto the nodes with none of the roles. It returns nil when the CR does not use node roles.
+func nodeRoleAffinity(allRoles []string, roles []string) *corev1.Affinity {
+	if len(allRoles) == 0 {
+		return nil
+	}
+
+	expressions := []corev1.NodeSelectorRequirement{}
+	for _, r := range allRoles {
+		operator := corev1.NodeSelectorOpDoesNotExist
+		for _, role := range roles {
+			if r == role {
+				operator = corev1.NodeSelectorOpExists
+			}
+		}
+		expressions = append(expressions, corev1.NodeSelectorRequirement{
+			Key:      nodeRoleLabelPrefix + r,
+			Operator: operator,
+		})
+	}
+
+	return &corev1.Affinity{
+		NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{
+				NodeSelectorTerms: []corev1.NodeSelectorTerm{
+					{MatchExpressions: expressions},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,152 @@
+package kube
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+func TestNodeRoles(t *testing.T) {
+	tests := []struct {
+		name   string
+		inputs []sfv1alpha1.SplunkForwarderInputs
+		want   []string
+	}{
+		{
+			name:   "No roles",
+			inputs: []sfv1alpha1.SplunkForwarderInputs{{Path: "/var/log/test"}},
+			want:   []string{},
+		},
+		{
+			name: "Roles in order of first listing",
+			inputs: []sfv1alpha1.SplunkForwarderInputs{
+				{Path: "/var/log/a", NodeRoles: []string{"worker"}},
+				{Path: "/var/log/b", NodeRoles: []string{"master", "worker"}},
+				{Path: "/var/log/c", NodeRoles: []string{"infra"}},
+			},
+			want: []string{"worker", "master", "infra"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			instance := splunkForwarderInstance(true)
+			instance.Spec.SplunkInputs = tt.inputs
+			if got := NodeRoles(instance); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("NodeRoles() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNodeRoleAffinityWithoutRoles(t *testing.T) {
+	if got := nodeRoleAffinity([]string{}, nil); got != nil {
+		t.Errorf("nodeRoleAffinity() = %v, want nil", got)
+	}
+}
+
+func TestNodeRoleSets(t *testing.T) {
+	instance := splunkForwarderInstance(true)
+	instance.Spec.SplunkInputs = []sfv1alpha1.SplunkForwarderInputs{
+		{Path: "/var/log/a", NodeRoles: []string{"master"}},
+		{Path: "/var/log/b", NodeRoles: []string{"infra", "worker"}},
+	}
+	nodeRoles := [][]string{
+		{"master"},
+		{"infra", "master"},
+		{"infra", "master", "control-plane"},
+		{"worker"},
+		{"infra", "worker"},
+		{},
+	}
+
+	want := [][]string{{"master"}, {"infra"}, {"worker"}, {"infra", "worker"}, {"master", "infra"}}
+	if got := nodeRoleSets(instance, nodeRoles); !reflect.DeepEqual(got, want) {
+		t.Errorf("nodeRoleSets() = %v, want %v", got, want)
+	}
+	wantKeys := []string{"master", "infra", "worker", nodeRoleSetKey([]string{"infra", "worker"}), nodeRoleSetKey([]string{"master", "infra"})}
+	if got := NodeRoleSetKeys(instance, nodeRoles); !reflect.DeepEqual(got, wantKeys) {
+		t.Errorf("NodeRoleSetKeys() = %v, want %v", got, wantKeys)
+	}
+}
+
+func TestNodeRoleSetKey(t *testing.T) {
+	if got := nodeRoleSetKey([]string{"master"}); got != "master" {
+		t.Errorf("nodeRoleSetKey() of a single role = %q, want %q", got, "master")
+	}
+	// A combination does not collide with a role of the same name
+	if got := nodeRoleSetKey([]string{"infra", "master"}); !strings.HasPrefix(got, "infra-master-") || len(got) != len("infra-master-")+8 {
+		t.Errorf("nodeRoleSetKey() of a combination = %q, want infra-master-<hash>", got)
+	}
+	long := []string{strings.Repeat("a", 40), strings.Repeat("b", 40)}
+	if got := nodeRoleSetKey(long); len(got) > maxNodeRoleSetKeyLength || strings.Contains(got, "--") {
+		t.Errorf("nodeRoleSetKey() of long roles = %q, want at most %d characters", got, maxNodeRoleSetKeyLength)
+	}
+}
+
+func TestBuildNodeRoleSets(t *testing.T) {
+	instance := splunkForwarderInstance(true)
+	instance.Spec.SplunkInputs = []sfv1alpha1.SplunkForwarderInputs{
+		{Path: "/var/log/openshift-apiserver/audit.log", NodeRoles: []string{"master"}},
+		{Path: "/var/log/containers", NodeRoles: []string{"infra"}},
+	}
+	state := ClusterState{
+		ClusterMetadata: ClusterMetadata{ClusterID: "test"},
+		NodeRoles:       [][]string{{"master"}, {"infra", "master"}, {"worker"}},
+	}
+	key := nodeRoleSetKey([]string{"master", "infra"})
+
+	// The nodes with both roles monitor the inputs of each
+	configMaps := BuildConfigMaps(instance, state)
+	var local *corev1.ConfigMap
+	for _, cm := range configMaps {
+		if cm.Name == localConfigMapName(key) {
+			local = cm
+		}
+	}
+	if local == nil {
+		t.Fatalf("BuildConfigMaps() has no ConfigMap for the nodes with both roles")
+	}
+	meta := "_meta = clusterid::test noderole::master noderole::infra\n"
+	for _, path := range []string{"/var/log/openshift-apiserver/audit.log", "/var/log/containers"} {
+		if !strings.Contains(local.Data["inputs.conf"], "[monitor://"+path+"]\n") {
+			t.Errorf("inputs.conf of the nodes with both roles = %q, want %s", local.Data["inputs.conf"], path)
+		}
+	}
+	if !strings.Contains(local.Data["inputs.conf"], meta) {
+		t.Errorf("inputs.conf of the nodes with both roles = %q, want %q", local.Data["inputs.conf"], meta)
+	}
+	if local.Labels[NodeRoleLabel] != key {
+		t.Errorf("node role label = %q, want %q", local.Labels[NodeRoleLabel], key)
+	}
+
+	// The forwarders of a role set only run on the nodes with exactly these roles
+	daemonSets := BuildDaemonSets(instance, state, operatorImage)
+	want := map[string][]corev1.NodeSelectorRequirement{
+		instanceName + "-ds-master": {
+			{Key: "node-role.kubernetes.io/master", Operator: corev1.NodeSelectorOpExists},
+			{Key: "node-role.kubernetes.io/infra", Operator: corev1.NodeSelectorOpDoesNotExist},
+		},
+		instanceName + "-ds-" + key: {
+			{Key: "node-role.kubernetes.io/master", Operator: corev1.NodeSelectorOpExists},
+			{Key: "node-role.kubernetes.io/infra", Operator: corev1.NodeSelectorOpExists},
+		},
+	}
+	found := 0
+	for _, ds := range daemonSets {
+		expressions, ok := want[ds.Name]
+		if !ok {
+			continue
+		}
+		found++
+		terms := ds.Spec.Template.Spec.Affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution.NodeSelectorTerms
+		if len(terms) != 1 || !reflect.DeepEqual(terms[0].MatchExpressions, expressions) {
+			t.Errorf("%s node affinity = %v, want %v", ds.Name, terms, expressions)
+		}
+	}
+	if found != len(want) || len(daemonSets) != 4 {
+		t.Errorf("BuildDaemonSets() returned %d DaemonSets, want the default, master, infra and master+infra ones", len(daemonSets))
+	}
+}
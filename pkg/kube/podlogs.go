@@ -0,0 +1,155 @@
+package kube
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// podLogsDir is where the node's CRI-O pod log directories are found in the forwarder container
+	podLogsDir = "/host/var/log/pods"
+	// podLogsSourceType is the sourcetype of the pod logs inputs that do not set one
+	podLogsSourceType = "kube:container"
+	// podLogsTransform is the transforms.conf stanza tagging the pod log events with their namespace, pod
+	// and container
+	podLogsTransform = "podlogs_metadata"
+	// podTemplateHashLabel is set by the Deployment controller on its pods, and ends the names of its ReplicaSets
+	podTemplateHashLabel = "pod-template-hash"
+)
+
+// PodLogTarget is a workload, or a pod without one, selected by a podLogs input. Its pods are matched by
+// name, /var/log/pods/<namespace>_<pod>_<uid> on the node, so that the monitored stanzas do not change as
+// the pods of the workload come and go.
+type PodLogTarget struct {
+	Input     sfv1alpha1.SplunkPodLogsInput
+	Namespace string
+	// Prefix is the start of the names of the pods: the name of the workload followed by "-", or the name
+	// of a pod without a workload
+	Prefix string
+	// Segments is the number of "-"-separated segments the workload controller appends to Prefix: two for a
+	// Deployment (the pod template hash and a random suffix), one for the other workloads, none for a pod
+	Segments int
+}
+
+// Path returns the monitored path of the target, with a wildcard for each generated segment of the pod
+// names and the pod UID
+func (t PodLogTarget) Path() string {
+	segments := make([]string, t.Segments)
+	for i := range segments {
+		segments[i] = "*"
+	}
+	return podLogsDir + "/" + t.Namespace + "_" + t.Prefix + strings.Join(segments, "-") + "_*"
+}
+
+// whitelist returns the regex of the log files of the selected containers of the target's pods
+func (t PodLogTarget) whitelist() string {
+	segments := make([]string, t.Segments)
+	for i := range segments {
+		segments[i] = "[a-z0-9]+"
+	}
+	containers := "[^/]+"
+	if len(t.Input.Containers) > 0 {
+		quoted := []string{}
+		for _, container := range t.Input.Containers {
+			quoted = append(quoted, regexp.QuoteMeta(container))
+		}
+		containers = "(" + strings.Join(quoted, "|") + ")"
+	}
+	return "^" + regexp.QuoteMeta(podLogsDir+"/"+t.Namespace+"_"+t.Prefix) + strings.Join(segments, "-") +
+		"_[^/]+/" + containers + `/[^/]+\.log$`
+}
+
+// podLogTarget returns the target matching the name of a pod and of the other pods of its workload
+func podLogTarget(input sfv1alpha1.SplunkPodLogsInput, pod metav1.ObjectMeta) PodLogTarget {
+	target := PodLogTarget{Input: input, Namespace: pod.Namespace, Prefix: pod.Name}
+	owner := metav1.GetControllerOf(&pod)
+	hash := pod.Labels[podTemplateHashLabel]
+	switch {
+	case owner != nil && owner.Kind == "StatefulSet":
+		// The pods of a StatefulSet are named after it, with their ordinal
+		target.Prefix, target.Segments = owner.Name+"-", 1
+	case owner != nil && owner.Kind == "ReplicaSet" && hash != "" && strings.HasSuffix(pod.GenerateName, "-"+hash+"-"):
+		// The ReplicaSets of a Deployment are named after it, with the pod template hash, so the pods of
+		// every revision of the Deployment are matched
+		target.Prefix, target.Segments = strings.TrimSuffix(pod.GenerateName, hash+"-"), 2
+	case pod.GenerateName != "":
+		target.Prefix, target.Segments = pod.GenerateName, 1
+	}
+	return target
+}
+
+// PodLogTargets returns the workloads, and pods without one, selected by a podLogs input, ordered by path.
+// The pods are expected to match the namespace and selector of the input.
+func PodLogTargets(input sfv1alpha1.SplunkPodLogsInput, pods []metav1.ObjectMeta) []PodLogTarget {
+	targets := []PodLogTarget{}
+	seen := map[string]bool{}
+	for _, pod := range pods {
+		target := podLogTarget(input, pod)
+		if seen[target.Path()] {
+			continue
+		}
+		seen[target.Path()] = true
+		targets = append(targets, target)
+	}
+	sort.SliceStable(targets, func(i, j int) bool {
+		return targets[i].Path() < targets[j].Path()
+	})
+	return targets
+}
+
+// podLogsInputs renders the monitor stanzas of the pod log targets. A workload selected by several inputs
+// is monitored by the first one.
+func podLogsInputs(targets []PodLogTarget, meta string) string {
+	ret := ""
+	seen := map[string]bool{}
+	for _, target := range targets {
+		if seen[target.Path()] {
+			continue
+		}
+		seen[target.Path()] = true
+		ret += "[monitor://" + target.Path() + "]\n"
+		if target.Input.SourceType != "" {
+			ret += "sourcetype = " + target.Input.SourceType + "\n"
+		} else {
+			ret += "sourcetype = " + podLogsSourceType + "\n"
+		}
+		if target.Input.Index != "" {
+			ret += "index = " + target.Input.Index + "\n"
+		} else {
+			ret += "index = main\n"
+		}
+		ret += "whitelist = " + target.whitelist() + "\n"
+		if meta != "" {
+			ret += "_meta = " + meta + "\n"
+		}
+		ret += "disabled = false\n"
+		ret += "\n"
+	}
+	return ret
+}
+
+// withoutPodLogsInputs returns the inputs.conf without the monitor stanzas of the pod log targets
+func withoutPodLogsInputs(inputs string) string {
+	_, rest := splitStanzas(inputs, monitorStanzaPrefix+podLogsDir+"/")
+	return rest
+}
+
+// addPodLogsMetadata adds the index-time transform tagging the pod log events with the namespace, pod and
+// container read from their source, and returns its transforms.conf stanza. It applies where the events
+// are parsed: on the Universal Forwarders when localProcessing is set, on the Heavy Forwarder otherwise.
+func addPodLogsMetadata(props *propsConf, localProcessing bool) (transforms string) {
+	settings := "TRANSFORMS-podlogs = " + podLogsTransform + "\n"
+	if localProcessing {
+		settings = "force_local_processing = true\n" + settings
+	}
+	props.add("source::"+podLogsDir+"/...", settings)
+	return "[" + podLogsTransform + "]\n" +
+		"SOURCE_KEY = MetaData:Source\n" +
+		"REGEX = ^source::" + podLogsDir + "/([^_/]+)_([^_/]+)_[^/]+/([^/]+)/\n" +
+		"FORMAT = namespace::$1 pod::$2 container::$3\n" +
+		"WRITE_META = true\n\n"
+}
@@ -0,0 +1,113 @@
+package kube
+
+import (
+	"reflect"
+	"regexp"
+	"strings"
+	"testing"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// testPodMeta returns the metadata of a pod created by the controller of a workload of the given kind, or of
+// a pod without a workload when kind is ""
+func testPodMeta(name, generateName, kind, owner string, labels map[string]string) metav1.ObjectMeta {
+	pod := metav1.ObjectMeta{Name: name, GenerateName: generateName, Namespace: "app", Labels: labels}
+	if kind != "" {
+		controller := true
+		pod.OwnerReferences = []metav1.OwnerReference{{Kind: kind, Name: owner, Controller: &controller}}
+	}
+	return pod
+}
+
+func TestPodLogTargets(t *testing.T) {
+	hash := func(h string) map[string]string { return map[string]string{podTemplateHashLabel: h} }
+	pods := []metav1.ObjectMeta{
+		testPodMeta("web-7d9f8b-abcde", "web-7d9f8b-", "ReplicaSet", "web-7d9f8b", hash("7d9f8b")),
+		testPodMeta("web-7d9f8b-fghij", "web-7d9f8b-", "ReplicaSet", "web-7d9f8b", hash("7d9f8b")),
+		testPodMeta("web-5c6d7e-klmno", "web-5c6d7e-", "ReplicaSet", "web-5c6d7e", hash("5c6d7e")),
+		testPodMeta("db-1", "", "StatefulSet", "db", nil),
+		testPodMeta("db-0", "", "StatefulSet", "db", nil),
+		testPodMeta("agent-x2k9p", "agent-", "DaemonSet", "agent", nil),
+		testPodMeta("debug", "", "", "", nil),
+	}
+	want := []string{
+		"/host/var/log/pods/app_agent-*_*",
+		"/host/var/log/pods/app_db-*_*",
+		"/host/var/log/pods/app_debug_*",
+		"/host/var/log/pods/app_web-*-*_*",
+	}
+
+	got := []string{}
+	for _, target := range PodLogTargets(sfv1alpha1.SplunkPodLogsInput{Name: "app", Namespace: "app"}, pods) {
+		got = append(got, target.Path())
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("PodLogTargets() = %v, want one target per workload %v", got, want)
+	}
+}
+
+func TestPodLogsInputs(t *testing.T) {
+	web := PodLogTarget{
+		Input:     sfv1alpha1.SplunkPodLogsInput{Name: "web", Namespace: "app", SourceType: "web:access", Containers: []string{"server"}},
+		Namespace: "app",
+		Prefix:    "web-",
+		Segments:  2,
+	}
+	other := web
+	other.Input = sfv1alpha1.SplunkPodLogsInput{Name: "other", Namespace: "app"}
+	want := `[monitor:///host/var/log/pods/app_web-*-*_*]
+sourcetype = web:access
+index = main
+whitelist = ^/host/var/log/pods/app_web-[a-z0-9]+-[a-z0-9]+_[^/]+/(server)/[^/]+\.log$
+_meta = clusterid::test
+disabled = false
+
+`
+	if got := podLogsInputs([]PodLogTarget{web, other}, "clusterid::test"); got != want {
+		t.Errorf("podLogsInputs() = %q, want the stanza of the first input %q", got, want)
+	}
+
+	whitelist := regexp.MustCompile(web.whitelist())
+	for path, match := range map[string]bool{
+		"/host/var/log/pods/app_web-7d9f8b-abcde_uid/server/0.log":     true,
+		"/host/var/log/pods/app_web-5c6d7e-klmno_uid/server/1.log":     true,
+		"/host/var/log/pods/app_web-7d9f8b-abcde_uid/proxy/0.log":      false,
+		"/host/var/log/pods/app_web-7d9f8b-abcde_uid/server/0.log.gz":  false,
+		"/host/var/log/pods/app_web-api-7d9f8b-abcde_uid/server/0.log": false,
+		"/host/var/log/pods/other_web-7d9f8b-abcde_uid/server/0.log":   false,
+	} {
+		if got := whitelist.MatchString(path); got != match {
+			t.Errorf("whitelist %q matches %q = %v, want %v", web.whitelist(), path, got, match)
+		}
+	}
+}
+
+func TestAddPodLogsMetadata(t *testing.T) {
+	props := newPropsConf()
+	transforms := addPodLogsMetadata(props, true)
+	if want := "\n[source::/host/var/log/pods/...]\nforce_local_processing = true\nTRANSFORMS-podlogs = podlogs_metadata\n"; !strings.HasSuffix(props.String(), want) {
+		t.Errorf("props.conf = %q, want the %q stanza", props.String(), want)
+	}
+
+	// The regex of the transform is kept to the syntax shared by PCRE and RE2, so it can be run here
+	var regex, format string
+	for _, line := range strings.Split(transforms, "\n") {
+		if value, ok := strings.CutPrefix(line, "REGEX = "); ok {
+			regex = value
+		}
+		if value, ok := strings.CutPrefix(line, "FORMAT = "); ok {
+			format = value
+		}
+	}
+	source := "source::/host/var/log/pods/app_web-7d9f8b-abcde_0f1e2d3c/server/0.log"
+	match := regexp.MustCompile(regex).FindStringSubmatch(source)
+	if match == nil {
+		t.Fatalf("transform regex %q does not match %q", regex, source)
+	}
+	got := strings.NewReplacer("$1", match[1], "$2", match[2], "$3", match[3]).Replace(format)
+	if want := "namespace::app pod::web-7d9f8b-abcde container::server"; got != want {
+		t.Errorf("transform fields = %q, want %q", got, want)
+	}
+}
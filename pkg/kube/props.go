@@ -0,0 +1,52 @@
+package kube
+
+import (
+	"fmt"
+	"strings"
+)
+
+// propsConf collects the settings of a props.conf, keeping the stanzas in the order they are first used
+type propsConf struct {
+	stanzas  []string
+	settings map[string]string
+}
+
+// newPropsConf returns a props.conf with the [_json] stanza every forwarder config starts with
+func newPropsConf() *propsConf {
+	p := &propsConf{settings: map[string]string{}}
+	p.add("_json", fmt.Sprintf("TRUNCATE = %d\n", MaxEventSize))
+	return p
+}
+
+// add appends settings, one per line, to a stanza
+func (p *propsConf) add(stanza, settings string) {
+	if _, ok := p.settings[stanza]; !ok {
+		p.stanzas = append(p.stanzas, stanza)
+	}
+	p.settings[stanza] += settings
+}
+
+func (p *propsConf) String() string {
+	ret := ""
+	for _, stanza := range p.stanzas {
+		ret += "\n[" + stanza + "]\n" + p.settings[stanza]
+	}
+	return ret
+}
+
+// addStanzaSettings adds settings, one per line, to a stanza of a generated .conf file, appending the
+// stanza when the file does not have it yet. The generated files separate their stanzas with a blank line.
+func addStanzaSettings(data map[string]string, file, stanza, settings string) {
+	conf := data[file]
+	header := "\n[" + stanza + "]\n"
+	i := strings.Index(conf, header)
+	if i < 0 {
+		data[file] = conf + header + settings
+		return
+	}
+	end := len(conf)
+	if next := strings.Index(conf[i+len(header):], "\n["); next >= 0 {
+		end = i + len(header) + next + 1
+	}
+	data[file] = conf[:end] + settings + conf[end:]
+}
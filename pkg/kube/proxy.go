@@ -0,0 +1,80 @@
+package kube
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ClusterProxy holds the egress proxy of the cluster, taken from the status of the cluster-wide Proxy
+type ClusterProxy struct {
+	HTTPProxy  string
+	HTTPSProxy string
+	NoProxy    string
+}
+
+// IsSet returns whether the cluster has an egress proxy
+func (p ClusterProxy) IsSet() bool {
+	return p.HTTPProxy != "" || p.HTTPSProxy != ""
+}
+
+// env returns the proxy environment variables, in both cases as tools read either
+func (p ClusterProxy) env() []corev1.EnvVar {
+	ret := []corev1.EnvVar{}
+	for _, v := range []struct{ name, value string }{
+		{"HTTP_PROXY", p.HTTPProxy},
+		{"HTTPS_PROXY", p.HTTPSProxy},
+		{"NO_PROXY", p.NoProxy},
+	} {
+		if v.value == "" {
+			continue
+		}
+		ret = append(ret,
+			corev1.EnvVar{Name: v.name, Value: v.value},
+			corev1.EnvVar{Name: strings.ToLower(v.name), Value: v.value},
+		)
+	}
+	return ret
+}
+
+// settings renders the [proxyConfig] settings splunkd sends its HTTP outputs through
+func (p ClusterProxy) settings() string {
+	ret := ""
+	if p.HTTPProxy != "" {
+		ret += "http_proxy = " + p.HTTPProxy + "\n"
+	}
+	if p.HTTPSProxy != "" {
+		ret += "https_proxy = " + p.HTTPSProxy + "\n"
+	}
+	if p.NoProxy != "" {
+		ret += "no_proxy = " + p.NoProxy + "\n"
+	}
+	return ret
+}
+
+// ApplyProxyToPodTemplate sets the proxy environment on the Splunk forwarder containers of a pod
+// template. The pod template changes with the proxy, so that a proxy change rolls the forwarders.
+func ApplyProxyToPodTemplate(template *corev1.PodTemplateSpec, proxy ClusterProxy) {
+	if !proxy.IsSet() {
+		return
+	}
+	for i := range template.Spec.Containers {
+		if template.Spec.Containers[i].Name == "splunk-uf" {
+			template.Spec.Containers[i].Env = append(template.Spec.Containers[i].Env, proxy.env()...)
+		}
+	}
+}
+
+// ApplyProxyToConfigMaps adds the [proxyConfig] stanza to the server.conf of the forwarder app
+// ConfigMaps, the ones holding inputs
+func ApplyProxyToConfigMaps(configMaps []*corev1.ConfigMap, proxy ClusterProxy) {
+	if !proxy.IsSet() {
+		return
+	}
+	for _, cm := range configMaps {
+		if _, ok := cm.Data["inputs.conf"]; !ok {
+			continue
+		}
+		addStanzaSettings(cm.Data, "server.conf", "proxyConfig", proxy.settings())
+	}
+}
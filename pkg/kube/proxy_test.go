@@ -0,0 +1,65 @@
+package kube
+
+import (
+	"reflect"
+	"testing"
+
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestApplyProxy(t *testing.T) {
+	instance := splunkForwarderInstance(true)
+	namespacedName := types.NamespacedName{Namespace: instanceNamespace, Name: instanceName}
+
+	// Without a proxy nothing changes
+	ds := GenerateDaemonSet(instance, true, operatorImage)
+	template := ds.Spec.Template.DeepCopy()
+	ApplyProxyToPodTemplate(template, ClusterProxy{})
+	if !reflect.DeepEqual(*template, ds.Spec.Template) {
+		t.Errorf("ApplyProxyToPodTemplate() changed the pod template without a proxy")
+	}
+
+	proxy := ClusterProxy{HTTPSProxy: "http://proxy.example.com:3128", NoProxy: ".svc"}
+	ApplyProxyToPodTemplate(template, proxy)
+	wantEnv := []corev1.EnvVar{
+		{Name: "HTTPS_PROXY", Value: "http://proxy.example.com:3128"},
+		{Name: "https_proxy", Value: "http://proxy.example.com:3128"},
+		{Name: "NO_PROXY", Value: ".svc"},
+		{Name: "no_proxy", Value: ".svc"},
+	}
+	for _, container := range template.Spec.Containers {
+		env := container.Env
+		if container.Name == "splunk-uf" {
+			env = env[len(env)-len(wantEnv):]
+			if !reflect.DeepEqual(env, wantEnv) {
+				t.Errorf("splunk-uf env = %v, want %v", env, wantEnv)
+			}
+			continue
+		}
+		for _, v := range env {
+			if v.Name == "HTTPS_PROXY" {
+				t.Errorf("container %s has the proxy environment", container.Name)
+			}
+		}
+	}
+	for _, container := range template.Spec.InitContainers {
+		if len(container.Env) > 0 {
+			t.Errorf("init container %s has the proxy environment", container.Name)
+		}
+	}
+
+	configMaps := GenerateConfigMaps(instance, namespacedName, ClusterMetadata{ClusterID: "test"}, nil)
+	ApplyProxyToConfigMaps(configMaps, proxy)
+	want := `
+[proxyConfig]
+https_proxy = http://proxy.example.com:3128
+no_proxy = .svc
+`
+	if _, ok := configMaps[0].Data["server.conf"]; ok {
+		t.Errorf("the metadata ConfigMap has a server.conf")
+	}
+	if got := configMaps[1].Data["server.conf"]; got != want {
+		t.Errorf("server.conf = %q, want %q", got, want)
+	}
+}
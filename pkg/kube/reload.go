@@ -0,0 +1,210 @@
+package kube
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	"github.com/openshift/splunk-forwarder-operator/config"
+	"github.com/openshift/splunk-forwarder-operator/pkg/reload"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// inputsVolumeName is the volume of the forwarder pods mounting their inputs ConfigMap, whatever the node
+	// role the ConfigMap is rendered for
+	inputsVolumeName = "osd-monitored-logs-local"
+	// reloadContainerName is the sidecar reloading the monitor inputs of the forwarders
+	reloadContainerName = "config-reload"
+	// monitorStanzaPrefix starts the headers of the monitor stanzas of inputs.conf, the inputs the
+	// config-reload sidecar reloads
+	monitorStanzaPrefix = "[monitor://"
+)
+
+// managementPortConfs makes the management port of splunkd listen on the loopback interface of the pod only,
+// for the config-reload sidecar to reload the inputs through it
+func managementPortConfs(data map[string]string) {
+	addStanzaSettings(data, "web.conf", "settings", "mgmtHostPort = 127.0.0.1:8089\n")
+}
+
+// AdminSecretName returns the name of the Secret holding the splunkd admin credentials managed by the operator
+func AdminSecretName(instance *sfv1alpha1.SplunkForwarder) string {
+	return instance.Name + "-admin"
+}
+
+// GenerateAdminSecret returns a Secret with a random splunkd admin password, which the config-reload
+// sidecar reads from its mount to authenticate against the management port. The forwarder seeds the
+// admin user from user-seed.conf on start.
+func GenerateAdminSecret(instance *sfv1alpha1.SplunkForwarder) (*corev1.Secret, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	password := hex.EncodeToString(buf)
+
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      AdminSecretName(instance),
+			Namespace: instance.Namespace,
+			Labels: map[string]string{
+				"app": instance.Name,
+			},
+			Annotations: map[string]string{
+				"genVersion": strconv.FormatInt(instance.Generation, 10),
+			},
+		},
+		Data: map[string][]byte{
+			"password": []byte(password),
+			"user-seed.conf": []byte(`[user_info]
+USERNAME = admin
+PASSWORD = ` + password + "\n"),
+		},
+	}, nil
+}
+
+// TemplateHash returns a stable hash of a pod template, used to update the DaemonSet only when the
+// pods need to be restarted
+func TemplateHash(template *corev1.PodTemplateSpec) string {
+	data, err := json.Marshal(template)
+	if err != nil {
+		// A PodTemplateSpec always marshals
+		panic(err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// splitStanzas splits a .conf file into the stanzas whose header starts with prefix, and the rest of the file
+func splitStanzas(conf, prefix string) (matching, rest string) {
+	match := false
+	for _, line := range strings.SplitAfter(conf, "\n") {
+		if strings.HasPrefix(line, "[") {
+			match = strings.HasPrefix(line, prefix)
+		}
+		if match {
+			matching += line
+		} else {
+			rest += line
+		}
+	}
+	return matching, rest
+}
+
+// reloadsInputs returns whether a pod template runs the config-reload sidecar
+func reloadsInputs(template *corev1.PodTemplateSpec) bool {
+	for _, container := range template.Spec.Containers {
+		if container.Name == reloadContainerName {
+			return true
+		}
+	}
+	return false
+}
+
+// reloadedInputs returns the stanzas of an inputs.conf the pods of a template reload without a restart: the
+// monitor stanzas when they run the config-reload sidecar, none otherwise
+func reloadedInputs(template *corev1.PodTemplateSpec, inputs string) string {
+	if !reloadsInputs(template) {
+		return ""
+	}
+	monitors, _ := splitStanzas(inputs, monitorStanzaPrefix)
+	return monitors
+}
+
+// restartedInputs returns the stanzas of an inputs.conf splunkd of the pods of a template only reads on start,
+// such as the journald and scripted inputs
+func restartedInputs(template *corev1.PodTemplateSpec, inputs string) string {
+	if !reloadsInputs(template) {
+		return inputs
+	}
+	_, rest := splitStanzas(inputs, monitorStanzaPrefix)
+	return rest
+}
+
+// ConfigHash returns a hash of the files of the ConfigMaps a pod template mounts, leaving out the monitor
+// stanzas of the inputs.conf of the inputs ConfigMap when the config-reload sidecar reloads them. splunkd only
+// reads the rest on start, so the hash is set on the pod template to roll the pods when it changes.
+func ConfigHash(template *corev1.PodTemplateSpec, configMaps []*corev1.ConfigMap) string {
+	byName := map[string]*corev1.ConfigMap{}
+	for _, cm := range configMaps {
+		byName[cm.Name] = cm
+	}
+	files := map[string]map[string]string{}
+	for _, volume := range template.Spec.Volumes {
+		if volume.ConfigMap == nil || byName[volume.ConfigMap.Name] == nil {
+			continue
+		}
+		data := map[string]string{}
+		for file, content := range byName[volume.ConfigMap.Name].Data {
+			if volume.Name == inputsVolumeName && file == "inputs.conf" {
+				content = restartedInputs(template, content)
+			}
+			data[file] = content
+		}
+		files[volume.Name] = data
+	}
+	// Maps marshal with sorted keys
+	data, err := json.Marshal(files)
+	if err != nil {
+		panic(err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// getReloadContainer returns the config-reload sidecar, which runs the reload subcommand of the operator image
+func getReloadContainer(instance *sfv1alpha1.SplunkForwarder, image string) corev1.Container {
+	return corev1.Container{
+		Name:    reloadContainerName,
+		Image:   image,
+		Command: []string{config.OperatorName, "reload", "--dir", reload.DefaultDir, "--password-file", reload.DefaultPasswordFile},
+		Resources: corev1.ResourceRequirements{
+			Requests: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("5m"),
+				corev1.ResourceMemory: resource.MustParse("32Mi"),
+			},
+			Limits: corev1.ResourceList{
+				corev1.ResourceCPU:    resource.MustParse("50m"),
+				corev1.ResourceMemory: resource.MustParse("64Mi"),
+			},
+		},
+		VolumeMounts: []corev1.VolumeMount{
+			{
+				Name:      inputsVolumeName,
+				MountPath: reload.DefaultDir,
+				ReadOnly:  true,
+			},
+			{
+				Name:      "splunk-admin",
+				MountPath: filepath.Dir(reload.DefaultPasswordFile),
+				ReadOnly:  true,
+			},
+		},
+	}
+}
+
+func getAdminVolume(instance *sfv1alpha1.SplunkForwarder) corev1.Volume {
+	return corev1.Volume{
+		Name: "splunk-admin",
+		VolumeSource: corev1.VolumeSource{
+			Secret: &corev1.SecretVolumeSource{
+				SecretName: AdminSecretName(instance),
+			},
+		},
+	}
+}
+
+func getAdminVolumeMount() corev1.VolumeMount {
+	return corev1.VolumeMount{
+		Name:      "splunk-admin",
+		MountPath: "/opt/splunkforwarder/etc/system/local/user-seed.conf",
+		SubPath:   "user-seed.conf",
+		ReadOnly:  true,
+	}
+}
@@ -0,0 +1,127 @@
+package kube
+
+import (
+	"bytes"
+	"testing"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+)
+
+func TestGenerateAdminSecret(t *testing.T) {
+	instance := splunkForwarderInstance(true)
+
+	secret, err := GenerateAdminSecret(instance)
+	if err != nil {
+		t.Fatalf("GenerateAdminSecret() error = %v", err)
+	}
+	if secret.Name != instanceName+"-admin" || secret.Namespace != instanceNamespace {
+		t.Errorf("GenerateAdminSecret() name = %s/%s", secret.Namespace, secret.Name)
+	}
+	password := secret.Data["password"]
+	if len(password) == 0 {
+		t.Fatalf("GenerateAdminSecret() password is empty")
+	}
+	if !bytes.Contains(secret.Data["user-seed.conf"], append([]byte("PASSWORD = "), password...)) {
+		t.Errorf("GenerateAdminSecret() user-seed.conf = %q does not seed the password", secret.Data["user-seed.conf"])
+	}
+
+	other, err := GenerateAdminSecret(instance)
+	if err != nil {
+		t.Fatalf("GenerateAdminSecret() error = %v", err)
+	}
+	if bytes.Equal(other.Data["password"], password) {
+		t.Errorf("GenerateAdminSecret() generated the same password twice")
+	}
+}
+
+func TestTemplateHash(t *testing.T) {
+	instance := splunkForwarderInstance(true)
+	ds := GenerateDaemonSet(instance, false, operatorImage)
+	hash := TemplateHash(&ds.Spec.Template)
+
+	if got := GenerateDaemonSet(instance, false, operatorImage).Annotations[TemplateHashAnnotation]; got != hash {
+		t.Errorf("TemplateHash() is not stable: %s != %s", got, hash)
+	}
+
+	instance.Spec.SplunkInputs = append(instance.Spec.SplunkInputs, splunkForwarderInstance(true).Spec.SplunkInputs...)
+	instance.Spec.ClusterID = "changed"
+	if got := GenerateDaemonSet(instance, false, operatorImage).Annotations[TemplateHashAnnotation]; got != hash {
+		t.Errorf("TemplateHash() changed for a config-only change")
+	}
+
+	instance.Spec.ImageDigest = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+	if got := GenerateDaemonSet(instance, false, operatorImage).Annotations[TemplateHashAnnotation]; got == hash {
+		t.Errorf("TemplateHash() did not change for a new image")
+	}
+}
+
+func TestConfigHash(t *testing.T) {
+	instance := splunkForwarderInstance(true)
+	instance.Spec.SplunkInputs = append(instance.Spec.SplunkInputs, sfv1alpha1.SplunkForwarderInputs{Path: "/var/log/a", NodeRoles: []string{"master"}})
+	hashes := func(instance *sfv1alpha1.SplunkForwarder, operatorImage string) []string {
+		ret := []string{}
+		for _, ds := range BuildDaemonSets(instance, ClusterState{}, operatorImage) {
+			ret = append(ret, ds.Spec.Template.Annotations[ConfigHashAnnotation])
+		}
+		return ret
+	}
+	base := hashes(instance, operatorImage)
+	if len(base) != 2 {
+		t.Fatalf("BuildDaemonSets() = %d DaemonSets, want the default and the master ones", len(base))
+	}
+
+	tests := []struct {
+		name        string
+		input       sfv1alpha1.SplunkForwarderInputs
+		scripted    []sfv1alpha1.SplunkScriptedInput
+		wantChanged []bool
+	}{
+		{
+			name:        "monitor input",
+			input:       sfv1alpha1.SplunkForwarderInputs{Path: "/var/log/new"},
+			wantChanged: []bool{false, false},
+		},
+		{
+			name:        "monitor input of a node role",
+			input:       sfv1alpha1.SplunkForwarderInputs{Path: "/var/log/b", NodeRoles: []string{"master"}},
+			wantChanged: []bool{false, false},
+		},
+		{
+			name:        "journald input",
+			input:       sfv1alpha1.SplunkForwarderInputs{Type: sfv1alpha1.InputTypeJournald, Units: []string{"sshd.service"}},
+			wantChanged: []bool{true, true},
+		},
+		{
+			name:        "scripted input",
+			scripted:    []sfv1alpha1.SplunkScriptedInput{{Name: "node-health", ConfigMap: "node-checks", Key: "health.sh", Interval: "300"}},
+			wantChanged: []bool{true, true},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed := instance.DeepCopy()
+			if tt.input.Path != "" || tt.input.Type != "" {
+				changed.Spec.SplunkInputs = append(changed.Spec.SplunkInputs, tt.input)
+			}
+			changed.Spec.ScriptedInputs = tt.scripted
+			for i, hash := range hashes(changed, operatorImage) {
+				if got := hash != base[i]; got != tt.wantChanged[i] {
+					t.Errorf("ConfigHash() of DaemonSet %d changed = %v, want %v", i, got, tt.wantChanged[i])
+				}
+			}
+		})
+	}
+
+	// Without the config-reload sidecar, the monitor inputs are only read on start
+	without := hashes(instance, "")
+	changed := instance.DeepCopy()
+	changed.Spec.SplunkInputs = append(changed.Spec.SplunkInputs, sfv1alpha1.SplunkForwarderInputs{Path: "/var/log/b", NodeRoles: []string{"master"}})
+	if got := hashes(changed, ""); got[0] != without[0] || got[1] == without[1] {
+		t.Errorf("ConfigHash() without the sidecar = %v, want only the hash of the master DaemonSet changed from %v", got, without)
+	}
+	for _, ds := range BuildDaemonSets(instance, ClusterState{}, "") {
+		if reloadsInputs(&ds.Spec.Template) {
+			t.Errorf("DaemonSet %s runs the config-reload sidecar without the operator image", ds.Name)
+		}
+	}
+}
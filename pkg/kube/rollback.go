@@ -0,0 +1,223 @@
+package kube
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"reflect"
+	"strings"
+	"time"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// defaultRollbackProgressDeadline is how long a rollout may make no progress before it is rolled back
+const defaultRollbackProgressDeadline = 10 * time.Minute
+
+// AutoRollbackEnabled returns whether failed rollouts of the forwarder DaemonSets are rolled back
+func AutoRollbackEnabled(instance *sfv1alpha1.SplunkForwarder) bool {
+	strategy := instance.Spec.RolloutStrategy
+	if strategy == nil || strategy.AutoRollback == nil || strategy.AutoRollback.Enabled == nil {
+		return true
+	}
+	return *strategy.AutoRollback.Enabled
+}
+
+// RollbackProgressDeadline returns how long a rollout may make no progress before it is rolled back
+func RollbackProgressDeadline(instance *sfv1alpha1.SplunkForwarder) time.Duration {
+	strategy := instance.Spec.RolloutStrategy
+	if strategy == nil || strategy.AutoRollback == nil || strategy.AutoRollback.ProgressDeadline == nil {
+		return defaultRollbackProgressDeadline
+	}
+	return strategy.AutoRollback.ProgressDeadline.Duration
+}
+
+// RolloutComplete returns whether every pod of a DaemonSet runs its current pod template and is ready
+func RolloutComplete(ds *appsv1.DaemonSet) bool {
+	status := ds.Status
+	return status.ObservedGeneration >= ds.Generation &&
+		status.UpdatedNumberScheduled == status.DesiredNumberScheduled && status.NumberReady == status.DesiredNumberScheduled
+}
+
+// lastGoodRevisionSuffix is appended to the name of a forwarder DaemonSet to name the ControllerRevision
+// recording its last good state
+const lastGoodRevisionSuffix = "-last-good"
+
+// rotatedHashAnnotations are the hashes of the pod template that follow the rotations of the credentials the
+// pods mount, which are rolled out even while the forwarders are kept on their last good state
+var rotatedHashAnnotations = []string{AuthHashAnnotation, CertificateHashAnnotation, TrustedCABundleHashAnnotation}
+
+// LastGoodState is what a forwarder DaemonSet is rolled back to: its last pod template whose rollout completed
+// with every pod ready, and the configuration files of the ConfigMaps the template mounts at the time. The
+// monitor stanzas the config-reload sidecar reloads without a restart are left out of the inputs.conf of the
+// inputs ConfigMap.
+type LastGoodState struct {
+	Template corev1.PodTemplateSpec `json:"template"`
+	// ConfigMaps holds the data of the mounted ConfigMaps by name
+	ConfigMaps map[string]map[string]string `json:"configMaps,omitempty"`
+}
+
+// LastGoodRevisionName returns the name of the ControllerRevision recording the last good state of a DaemonSet
+func LastGoodRevisionName(daemonSetName string) string {
+	return daemonSetName + lastGoodRevisionSuffix
+}
+
+// inputsConfigMapName returns the name of the inputs ConfigMap a pod template mounts
+func inputsConfigMapName(template *corev1.PodTemplateSpec) string {
+	for _, volume := range template.Spec.Volumes {
+		if volume.Name == inputsVolumeName && volume.ConfigMap != nil {
+			return volume.ConfigMap.Name
+		}
+	}
+	return ""
+}
+
+// GenerateLastGoodRevision returns the ControllerRevision recording the pod template of a DaemonSet whose
+// rollout completed, and the data of the ConfigMaps it mounts, as its last good state. revision numbers the
+// records of the DaemonSet. The template hash of the DaemonSet is kept in the annotations of the revision.
+func GenerateLastGoodRevision(ds *appsv1.DaemonSet, configMaps []*corev1.ConfigMap, revision int64) *appsv1.ControllerRevision {
+	state := LastGoodState{Template: ds.Spec.Template, ConfigMaps: map[string]map[string]string{}}
+	inputs := inputsConfigMapName(&ds.Spec.Template)
+	for _, volume := range ds.Spec.Template.Spec.Volumes {
+		if volume.ConfigMap == nil {
+			continue
+		}
+		for _, cm := range configMaps {
+			if cm.Name != volume.ConfigMap.Name {
+				continue
+			}
+			data := maps.Clone(cm.Data)
+			if data == nil {
+				data = map[string]string{}
+			}
+			if conf, ok := data["inputs.conf"]; ok && cm.Name == inputs {
+				data["inputs.conf"] = restartedInputs(&ds.Spec.Template, conf)
+			}
+			state.ConfigMaps[cm.Name] = data
+		}
+	}
+	data, err := json.Marshal(state)
+	if err != nil {
+		// Strings and a PodTemplateSpec always marshal
+		panic(err)
+	}
+	return &appsv1.ControllerRevision{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:        LastGoodRevisionName(ds.Name),
+			Namespace:   ds.Namespace,
+			Labels:      maps.Clone(ds.Labels),
+			Annotations: map[string]string{TemplateHashAnnotation: ds.Annotations[TemplateHashAnnotation]},
+		},
+		Data:     runtime.RawExtension{Raw: data},
+		Revision: revision,
+	}
+}
+
+// ParseLastGoodRevision returns the last good state recorded in a ControllerRevision
+func ParseLastGoodRevision(revision *appsv1.ControllerRevision) (LastGoodState, error) {
+	state := LastGoodState{}
+	if err := json.Unmarshal(revision.Data.Raw, &state); err != nil {
+		return state, fmt.Errorf("unable to parse the last good state of ControllerRevision %s: %w", revision.Name, err)
+	}
+	return state, nil
+}
+
+// EndRolloutTracking ends the tracking of the rollout progress of a DaemonSet whose rollout completed. It
+// returns whether the annotations changed.
+func EndRolloutTracking(ds *appsv1.DaemonSet) bool {
+	_, tracked := ds.Annotations[RolloutProgressAnnotation]
+	delete(ds.Annotations, RolloutProgressAnnotation)
+	return tracked
+}
+
+// RollBackDaemonSet switches a DaemonSet back to the pod template of its last good state, which it returns for
+// the ConfigMaps to be restored as well
+func RollBackDaemonSet(ds *appsv1.DaemonSet, revision *appsv1.ControllerRevision) (LastGoodState, error) {
+	state, err := ParseLastGoodRevision(revision)
+	if err != nil {
+		return state, err
+	}
+	ds.Spec.Template = *state.Template.DeepCopy()
+	ds.Annotations[TemplateHashAnnotation] = revision.Annotations[TemplateHashAnnotation]
+	delete(ds.Annotations, RolloutProgressAnnotation)
+	return state, nil
+}
+
+// RestoreConfigMap sets the data of a ConfigMap back to its last good state, keeping the monitor stanzas of the
+// inputs.conf of the inputs ConfigMap the sidecar reloads. It returns whether the data changed, and leaves the
+// ConfigMaps the state does not record as they are.
+func RestoreConfigMap(cm *corev1.ConfigMap, state LastGoodState) bool {
+	files, ok := state.ConfigMaps[cm.Name]
+	if !ok {
+		return false
+	}
+	data := maps.Clone(files)
+	if data == nil {
+		data = map[string]string{}
+	}
+	if inputs, ok := cm.Data["inputs.conf"]; ok && cm.Name == inputsConfigMapName(&state.Template) {
+		if recorded, ok := files["inputs.conf"]; ok {
+			data["inputs.conf"] = recorded + reloadedInputs(&state.Template, inputs)
+		} else {
+			// Recorded before the inputs.conf was
+			data["inputs.conf"] = inputs
+		}
+	}
+	if reflect.DeepEqual(cm.Data, data) {
+		return false
+	}
+	cm.Data = data
+	return true
+}
+
+// KeepLastGoodState replaces the generated pod template of a DaemonSet and the configuration files of the
+// generated ConfigMaps it mounts by its last good state, so that the forwarders stay on what they were rolled
+// back to. The hashes of the mounted credentials are taken from the generated pod template, so that their
+// rotations are still rolled out.
+func KeepLastGoodState(ds *appsv1.DaemonSet, configMaps []*corev1.ConfigMap, revision *appsv1.ControllerRevision) error {
+	state, err := ParseLastGoodRevision(revision)
+	if err != nil {
+		return err
+	}
+	template := *state.Template.DeepCopy()
+	hash := revision.Annotations[TemplateHashAnnotation]
+	for _, annotation := range rotatedHashAnnotations {
+		value, ok := ds.Spec.Template.Annotations[annotation]
+		if value == template.Annotations[annotation] {
+			continue
+		}
+		if template.Annotations == nil {
+			template.Annotations = map[string]string{}
+		}
+		if ok {
+			template.Annotations[annotation] = value
+		} else {
+			delete(template.Annotations, annotation)
+		}
+		hash = TemplateHash(&template)
+	}
+	ds.Spec.Template = template
+	ds.Annotations[TemplateHashAnnotation] = hash
+	for _, cm := range configMaps {
+		RestoreConfigMap(cm, state)
+	}
+	return nil
+}
+
+// TrackRolloutProgress records in the annotations of a DaemonSet when its rollout last progressed, that
+// is when the number of updated or ready pods changed. It returns whether the annotations changed, and
+// how long the rollout has made no progress.
+func TrackRolloutProgress(ds *appsv1.DaemonSet, now time.Time) (bool, time.Duration) {
+	progress := fmt.Sprintf("%d/%d", ds.Status.UpdatedNumberScheduled, ds.Status.NumberReady)
+	recorded, since, _ := strings.Cut(ds.Annotations[RolloutProgressAnnotation], "@")
+	last, err := time.Parse(time.RFC3339, since)
+	if recorded == progress && err == nil {
+		return false, now.Sub(last)
+	}
+	ds.Annotations[RolloutProgressAnnotation] = progress + "@" + now.UTC().Format(time.RFC3339)
+	return true, 0
+}
@@ -0,0 +1,138 @@
+package kube
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	appsv1 "k8s.io/api/apps/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestAutoRollback(t *testing.T) {
+	instance := splunkForwarderInstance(true)
+	if !AutoRollbackEnabled(instance) || RollbackProgressDeadline(instance) != defaultRollbackProgressDeadline {
+		t.Errorf("auto rollback is not enabled by default")
+	}
+	enabled := false
+	instance.Spec.RolloutStrategy = &sfv1alpha1.SplunkRolloutStrategy{AutoRollback: &sfv1alpha1.SplunkAutoRollback{
+		Enabled:          &enabled,
+		ProgressDeadline: &metav1.Duration{Duration: time.Minute},
+	}}
+	if AutoRollbackEnabled(instance) || RollbackProgressDeadline(instance) != time.Minute {
+		t.Errorf("auto rollback settings are ignored")
+	}
+}
+
+func TestRollBackDaemonSet(t *testing.T) {
+	instance := splunkForwarderInstance(true)
+	namespacedName := types.NamespacedName{Namespace: instanceNamespace, Name: instanceName}
+	configMaps := GenerateConfigMaps(instance, namespacedName, ClusterMetadata{ClusterID: "test"}, nil)
+	ds := BuildDaemonSets(instance, ClusterState{AuthHash: "auth"}, operatorImage)[0]
+	good := *ds.Spec.Template.DeepCopy()
+	goodHash := ds.Annotations[TemplateHashAnnotation]
+	ds.Status = appsv1.DaemonSetStatus{DesiredNumberScheduled: 2, UpdatedNumberScheduled: 2, NumberReady: 1}
+	if RolloutComplete(ds) {
+		t.Errorf("RolloutComplete() = true with a pod not ready")
+	}
+	ds.Status.NumberReady = 2
+	if !RolloutComplete(ds) {
+		t.Fatalf("RolloutComplete() = false with every pod updated and ready")
+	}
+	revision := GenerateLastGoodRevision(ds, configMaps, 1)
+	if revision.Name != ds.Name+"-last-good" || revision.Annotations[TemplateHashAnnotation] != goodHash {
+		t.Errorf("last good revision %s is not named after the DaemonSet or does not hold its template hash", revision.Name)
+	}
+	state, err := ParseLastGoodRevision(revision)
+	if err != nil {
+		t.Fatalf("ParseLastGoodRevision() error = %v", err)
+	}
+	// The monitor inputs are reloaded without a restart, they are not recorded
+	if inputs, ok := state.ConfigMaps["osd-monitored-logs-local"]["inputs.conf"]; !ok || inputs != "" {
+		t.Errorf("recorded ConfigMaps = %v, want the monitor inputs left out", state.ConfigMaps)
+	}
+	if _, ok := state.ConfigMaps["osd-monitored-logs-metadata"]; !ok {
+		t.Errorf("recorded ConfigMaps = %v, want the metadata ConfigMap", state.ConfigMaps)
+	}
+
+	// A new pod template is rolled back once its rollout stops progressing
+	ds.Spec.Template.Spec.Containers[0].Image = "test-image:0.0.2"
+	ds.Annotations[TemplateHashAnnotation] = TemplateHash(&ds.Spec.Template)
+	ds.Status.UpdatedNumberScheduled = 0
+	// The progress is recorded with a precision of a second
+	now := time.Now().Truncate(time.Second)
+	if changed, stalled := TrackRolloutProgress(ds, now); !changed || stalled != 0 {
+		t.Errorf("TrackRolloutProgress() = %v, %s for a new rollout", changed, stalled)
+	}
+	if changed, stalled := TrackRolloutProgress(ds, now.Add(time.Hour)); changed || stalled != time.Hour {
+		t.Errorf("TrackRolloutProgress() = %v, %s without progress, want an hour", changed, stalled)
+	}
+	ds.Status.UpdatedNumberScheduled = 1
+	if changed, stalled := TrackRolloutProgress(ds, now.Add(time.Hour)); !changed || stalled != 0 {
+		t.Errorf("TrackRolloutProgress() = %v, %s after progress", changed, stalled)
+	}
+
+	if _, err := RollBackDaemonSet(ds, revision); err != nil {
+		t.Fatalf("RollBackDaemonSet() error = %v", err)
+	}
+	if !reflect.DeepEqual(ds.Spec.Template, good) || ds.Annotations[TemplateHashAnnotation] != goodHash {
+		t.Errorf("pod template = %v, want the last good one", ds.Spec.Template)
+	}
+	if _, ok := ds.Annotations[RolloutProgressAnnotation]; ok {
+		t.Errorf("the rollout progress is still tracked")
+	}
+
+	// The configuration files and the inputs read on start are restored, the monitor inputs are kept
+	local := configMaps[1].DeepCopy()
+	local.Data["outputs.conf"] = "[tcpout]\nbad = true\n"
+	local.Data["inputs.conf"] += "[monitor:///var/log/new]\n"
+	inputs := local.Data["inputs.conf"]
+	local.Data["inputs.conf"] += "[journald://bad]\njournalctl-filter = _SYSTEMD_UNIT=bad.service\n"
+	if !RestoreConfigMap(local, state) {
+		t.Fatalf("RestoreConfigMap() = false for a changed ConfigMap")
+	}
+	if local.Data["outputs.conf"] != configMaps[1].Data["outputs.conf"] || local.Data["inputs.conf"] != inputs {
+		t.Errorf("restored data = %v, want the last good files and the current monitor inputs", local.Data)
+	}
+	if RestoreConfigMap(local, state) {
+		t.Errorf("RestoreConfigMap() = true for a restored ConfigMap")
+	}
+}
+
+func TestKeepLastGoodState(t *testing.T) {
+	instance := splunkForwarderInstance(true)
+	namespacedName := types.NamespacedName{Namespace: instanceNamespace, Name: instanceName}
+	configMaps := GenerateConfigMaps(instance, namespacedName, ClusterMetadata{ClusterID: "test"}, nil)
+	live := BuildDaemonSets(instance, ClusterState{AuthHash: "auth"}, operatorImage)[0]
+	revision := GenerateLastGoodRevision(live, configMaps, 1)
+
+	// The generated objects of a failed change are replaced by the last good state
+	instance.Spec.ImageDigest = "sha256:" + strings.Repeat("0", 64)
+	instance.Spec.Throughput = &sfv1alpha1.SplunkThroughput{MaxKBps: 512}
+	generated := GenerateConfigMaps(instance, namespacedName, ClusterMetadata{ClusterID: "test"}, nil)
+	ds := BuildDaemonSets(instance, ClusterState{AuthHash: "auth"}, operatorImage)[0]
+	if err := KeepLastGoodState(ds, generated, revision); err != nil {
+		t.Fatalf("KeepLastGoodState() error = %v", err)
+	}
+	if !reflect.DeepEqual(ds.Spec.Template, live.Spec.Template) || ds.Annotations[TemplateHashAnnotation] != live.Annotations[TemplateHashAnnotation] {
+		t.Errorf("pod template = %v, want the last good one", ds.Spec.Template)
+	}
+	if !reflect.DeepEqual(generated[1].Data, configMaps[1].Data) {
+		t.Errorf("ConfigMap data = %v, want the last good files", generated[1].Data)
+	}
+
+	// Credential rotations are still rolled out
+	ds = BuildDaemonSets(instance, ClusterState{AuthHash: "rotated"}, operatorImage)[0]
+	if err := KeepLastGoodState(ds, generated, revision); err != nil {
+		t.Fatalf("KeepLastGoodState() error = %v", err)
+	}
+	if ds.Spec.Template.Annotations[AuthHashAnnotation] != "rotated" || ds.Spec.Template.Spec.Containers[0].Image != live.Spec.Template.Spec.Containers[0].Image {
+		t.Errorf("pod template = %v, want the last good one with the rotated auth hash", ds.Spec.Template)
+	}
+	if got := ds.Annotations[TemplateHashAnnotation]; got != TemplateHash(&ds.Spec.Template) {
+		t.Errorf("template hash = %q, want the hash of the rotated pod template", got)
+	}
+}
@@ -0,0 +1,95 @@
+package kube
+
+import (
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+)
+
+const (
+	// scriptedInputsAppDir is the app whose bin directory holds the scripts of the scripted inputs.
+	// Splunk only runs scripts found in the bin directory of an app.
+	scriptedInputsAppDir = "/opt/splunkforwarder/etc/apps/osd_scripted_inputs"
+	// scriptedInputsInterval is the interval of the scripted inputs that do not set one
+	scriptedInputsInterval = "60"
+)
+
+// scriptedInputs renders the script stanzas of the scripted inputs. The scripts are always looked up in
+// the bin directory of the scripted inputs app, under the name of their input. Their output is queued on
+// the node when persistentQueueSize is set.
+func scriptedInputs(inputs []sfv1alpha1.SplunkScriptedInput, meta, persistentQueueSize string) string {
+	ret := ""
+	for _, input := range inputs {
+		ret += "[script://$SPLUNK_HOME/etc/apps/osd_scripted_inputs/bin/" + input.Name + "]\n"
+		if input.Interval != "" {
+			ret += "interval = " + input.Interval + "\n"
+		} else {
+			ret += "interval = " + scriptedInputsInterval + "\n"
+		}
+		ret += "sourcetype = " + scriptedInputSourceType(input) + "\n"
+		if input.Index != "" {
+			ret += "index = " + input.Index + "\n"
+		} else {
+			ret += "index = main\n"
+		}
+		if meta != "" {
+			ret += "_meta = " + meta + "\n"
+		}
+		if persistentQueueSize != "" {
+			ret += "persistentQueueSize = " + persistentQueueSize + "\n"
+		}
+		ret += "disabled = false\n"
+		ret += "\n"
+	}
+	return ret
+}
+
+// scriptedInputSourceType returns the sourcetype of a scripted input, defaulting to the name of its script
+func scriptedInputSourceType(input sfv1alpha1.SplunkScriptedInput) string {
+	if input.SourceType != "" {
+		return input.SourceType
+	}
+	return input.Name
+}
+
+// getScriptedInputsVolume projects the scripts of the scripted inputs, as executable files named after
+// their input. The ConfigMaps are optional, so that a missing script does not stop the forwarders.
+func getScriptedInputsVolume(instance *sfv1alpha1.SplunkForwarder) corev1.Volume {
+	var (
+		mode     int32 = 0o755
+		optional       = true
+	)
+	sources := []corev1.VolumeProjection{}
+	for _, input := range instance.Spec.ScriptedInputs {
+		sources = append(sources, corev1.VolumeProjection{
+			ConfigMap: &corev1.ConfigMapProjection{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: input.ConfigMap,
+				},
+				Items: []corev1.KeyToPath{
+					{
+						Key:  input.Key,
+						Path: input.Name,
+						Mode: &mode,
+					},
+				},
+				Optional: &optional,
+			},
+		})
+	}
+	return corev1.Volume{
+		Name: "scripted-inputs",
+		VolumeSource: corev1.VolumeSource{
+			Projected: &corev1.ProjectedVolumeSource{
+				Sources: sources,
+			},
+		},
+	}
+}
+
+func getScriptedInputsVolumeMount() corev1.VolumeMount {
+	return corev1.VolumeMount{
+		Name:      "scripted-inputs",
+		MountPath: scriptedInputsAppDir + "/bin",
+		ReadOnly:  true,
+	}
+}
@@ -0,0 +1,91 @@
+package kube
+
+import (
+	"reflect"
+	"testing"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestGenerateConfigMapsScripted(t *testing.T) {
+	instance := splunkForwarderInstance(true)
+	instance.Spec.SplunkInputs = []sfv1alpha1.SplunkForwarderInputs{{Path: "/var/derp"}}
+	instance.Spec.ScriptedInputs = []sfv1alpha1.SplunkScriptedInput{
+		{Name: "node-health", ConfigMap: "node-checks", Key: "health.sh", Interval: "300", Index: "openshift_managed_node", SourceType: "node:health"},
+		{Name: "disk-usage", ConfigMap: "node-checks", Key: "disk.sh"},
+	}
+
+	got := GenerateConfigMaps(instance, types.NamespacedName{Namespace: instanceNamespace, Name: instanceName}, ClusterMetadata{ClusterID: "test"}, nil)
+	want := `[monitor:///var/derp]
+sourcetype = _json
+index = main
+_meta = clusterid::test
+disabled = false
+
+[script://$SPLUNK_HOME/etc/apps/osd_scripted_inputs/bin/node-health]
+interval = 300
+sourcetype = node:health
+index = openshift_managed_node
+_meta = clusterid::test
+disabled = false
+
+[script://$SPLUNK_HOME/etc/apps/osd_scripted_inputs/bin/disk-usage]
+interval = 60
+sourcetype = disk-usage
+index = main
+_meta = clusterid::test
+disabled = false
+
+`
+	if inputs := got[1].Data["inputs.conf"]; inputs != want {
+		t.Errorf("GenerateConfigMaps() inputs.conf = %q, want %q", inputs, want)
+	}
+
+	// The masking rules also apply to the scripted sourcetypes
+	wantSourceTypes := []string{"_json", "node:health", "disk-usage"}
+	if sourceTypes := inputSourceTypes(instance.Spec.SplunkInputs, nil, instance.Spec.ScriptedInputs); !reflect.DeepEqual(sourceTypes, wantSourceTypes) {
+		t.Errorf("inputSourceTypes() = %v, want %v", sourceTypes, wantSourceTypes)
+	}
+}
+
+func TestScriptedInputsMounts(t *testing.T) {
+	instance := splunkForwarderInstance(true)
+	findVolume := func(volumes []corev1.Volume) *corev1.Volume {
+		for i := range volumes {
+			if volumes[i].Name == "scripted-inputs" {
+				return &volumes[i]
+			}
+		}
+		return nil
+	}
+	hasMount := func(mounts []corev1.VolumeMount) bool {
+		for _, mount := range mounts {
+			if mount.MountPath == scriptedInputsAppDir+"/bin" && mount.ReadOnly {
+				return true
+			}
+		}
+		return false
+	}
+
+	if findVolume(GetVolumes(instance, true, true, false)) != nil || hasMount(GetVolumeMounts(instance, false)) {
+		t.Errorf("the scripts are mounted without scripted inputs")
+	}
+
+	instance.Spec.ScriptedInputs = []sfv1alpha1.SplunkScriptedInput{
+		{Name: "node-health", ConfigMap: "node-checks", Key: "health.sh"},
+	}
+	volume := findVolume(GetVolumes(instance, true, true, false))
+	if volume == nil || !hasMount(GetVolumeMounts(instance, false)) {
+		t.Fatalf("the scripts are not mounted with a scripted input")
+	}
+	sources := volume.Projected.Sources
+	if len(sources) != 1 || sources[0].ConfigMap.Name != "node-checks" {
+		t.Fatalf("scripted-inputs sources = %+v, want the node-checks ConfigMap", sources)
+	}
+	item := sources[0].ConfigMap.Items[0]
+	if item.Key != "health.sh" || item.Path != "node-health" || *item.Mode != 0o755 {
+		t.Errorf("scripted-inputs item = %+v, want health.sh projected as an executable node-health", item)
+	}
+}
@@ -0,0 +1,80 @@
+package kube
+
+import (
+	"strconv"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+)
+
+// limitsConf renders the limits.conf of the forwarders, with an unlimited rate unless the CR sets one
+func limitsConf(instance *sfv1alpha1.SplunkForwarder) string {
+	maxKBps := int32(0)
+	if instance.Spec.Throughput != nil {
+		maxKBps = instance.Spec.Throughput.MaxKBps
+	}
+	return `
+[thruput]
+maxKBps = ` + strconv.Itoa(int(maxKBps)) + "\n"
+}
+
+// serverConf renders the server.conf of the forwarders with the pipelines and the parsing queue size,
+// or "" when the CR sets neither
+func serverConf(instance *sfv1alpha1.SplunkForwarder) string {
+	ret := ""
+	if throughput := instance.Spec.Throughput; throughput != nil && throughput.ParallelIngestionPipelines > 0 {
+		ret += "\n[general]\nparallelIngestionPipelines = " + strconv.Itoa(int(throughput.ParallelIngestionPipelines)) + "\n"
+	}
+	if queues := instance.Spec.Queues; queues != nil && queues.ParsingQueueSize != "" {
+		ret += "\n[queue=parsingQueue]\nmaxSize = " + queues.ParsingQueueSize + "\n"
+	}
+	return ret
+}
+
+// tcpoutSettings renders the [tcpout] settings of the queues of the CR. They are the defaults of every
+// receiver group, overridden by the settings of a group. Reliable delivery turns the acknowledgement on
+// and sizes the output queue.
+func tcpoutSettings(instance *sfv1alpha1.SplunkForwarder) string {
+	queues := instance.Spec.Queues
+	if queues == nil {
+		queues = &sfv1alpha1.SplunkQueues{}
+	}
+	maxQueueSize, useACK := queues.MaxQueueSize, queues.UseACK
+	if ReliableDeliveryEnabled(instance) {
+		if size := instance.Spec.ReliableDelivery.MaxQueueSize; size != "" {
+			maxQueueSize = size
+		} else if maxQueueSize == "" {
+			maxQueueSize = reliableMaxQueueSize
+		}
+		enabled := true
+		useACK = &enabled
+	}
+	ret := ""
+	if maxQueueSize != "" {
+		ret += "maxQueueSize = " + maxQueueSize + "\n"
+	}
+	if useACK != nil {
+		ret += "useACK = " + strconv.FormatBool(*useACK) + "\n"
+	}
+	if queues.AutoLBFrequency > 0 {
+		ret += "autoLBFrequency = " + strconv.Itoa(int(queues.AutoLBFrequency)) + "\n"
+	}
+	if queues.Compressed != nil {
+		ret += "compressed = " + strconv.FormatBool(*queues.Compressed) + "\n"
+	}
+	return ret
+}
+
+// addThroughputConfs adds the limits.conf, server.conf and outputs.conf of the throughput and queues of
+// the CR to the app of the Universal Forwarders. The app takes precedence over the splunkauth app, so
+// its settings apply to the receivers configured there.
+func addThroughputConfs(instance *sfv1alpha1.SplunkForwarder, data map[string]string) {
+	if instance.Spec.Throughput != nil {
+		data["limits.conf"] = limitsConf(instance)
+	}
+	if server := serverConf(instance); server != "" {
+		data["server.conf"] = server
+	}
+	if settings := tcpoutSettings(instance); settings != "" {
+		addStanzaSettings(data, "outputs.conf", "tcpout", settings)
+	}
+}
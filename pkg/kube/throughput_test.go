@@ -0,0 +1,92 @@
+package kube
+
+import (
+	"testing"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestThroughputConfs(t *testing.T) {
+	useACK, compressed := true, true
+	instance := splunkForwarderInstance(true)
+	namespacedName := types.NamespacedName{Namespace: instanceNamespace, Name: instanceName}
+
+	// Without throughput and queues the Universal Forwarders keep the settings of the splunkauth app
+	local := GenerateConfigMaps(instance, namespacedName, ClusterMetadata{ClusterID: "test"}, nil)[1]
+	for _, file := range []string{"limits.conf", "outputs.conf", "server.conf"} {
+		if _, ok := local.Data[file]; ok {
+			t.Errorf("GenerateConfigMaps() renders %s without throughput and queues", file)
+		}
+	}
+
+	instance.Spec.Throughput = &sfv1alpha1.SplunkThroughput{MaxKBps: 512, ParallelIngestionPipelines: 2}
+	instance.Spec.Queues = &sfv1alpha1.SplunkQueues{
+		MaxQueueSize:     "64MB",
+		ParsingQueueSize: "10MB",
+		UseACK:           &useACK,
+		AutoLBFrequency:  30,
+		Compressed:       &compressed,
+	}
+	wantLimits := `
+[thruput]
+maxKBps = 512
+`
+	wantServer := `
+[general]
+parallelIngestionPipelines = 2
+
+[queue=parsingQueue]
+maxSize = 10MB
+`
+	wantTcpout := `maxQueueSize = 64MB
+useACK = true
+autoLBFrequency = 30
+compressed = true
+`
+
+	local = GenerateConfigMaps(instance, namespacedName, ClusterMetadata{ClusterID: "test"}, nil)[1]
+	if got := local.Data["limits.conf"]; got != wantLimits {
+		t.Errorf("local limits.conf = %q, want %q", got, wantLimits)
+	}
+	if got, want := local.Data["server.conf"], wantServer; got != want {
+		t.Errorf("local server.conf = %q, want %q", got, want)
+	}
+	if got, want := local.Data["outputs.conf"], "\n[tcpout]\n"+wantTcpout; got != want {
+		t.Errorf("local outputs.conf = %q, want %q", got, want)
+	}
+
+	internal := GenerateInternalConfigMap(instance, namespacedName)
+	if got := internal.Data["limits.conf"]; got != wantLimits {
+		t.Errorf("internal limits.conf = %q, want %q", got, wantLimits)
+	}
+	wantInternalOutputs := `
+[tcpout]
+defaultGroup = internal
+` + wantTcpout + `
+[tcpout:internal]
+server = test:9997
+`
+	if got := internal.Data["outputs.conf"]; got != wantInternalOutputs {
+		t.Errorf("internal outputs.conf = %q, want %q", got, wantInternalOutputs)
+	}
+
+	filtering := GenerateFilteringConfigMap(instance, namespacedName)
+	if got := filtering.Data["limits.conf"]; got != wantLimits {
+		t.Errorf("filtering limits.conf = %q, want %q", got, wantLimits)
+	}
+	if got := filtering.Data["server.conf"]; got != wantServer {
+		t.Errorf("filtering server.conf = %q, want %q", got, wantServer)
+	}
+	wantInputs := `
+[splunktcp]
+route = has_key:_replicationBucketUUID:replicationQueue;has_key:_dstrx:typingQueue;has_key:_linebreaker:typingQueue;absent_key:_linebreaker:parsingQueue
+
+[splunktcp://:9997]
+connection_host = dns
+compressed = true
+`
+	if got := filtering.Data["inputs.conf"]; got != wantInputs {
+		t.Errorf("filtering inputs.conf = %q, want %q", got, wantInputs)
+	}
+}
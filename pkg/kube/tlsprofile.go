@@ -0,0 +1,118 @@
+package kube
+
+import (
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+)
+
+// ecdhCurves are the curves of the key exchange, whenever the TLS settings of the forwarders are set
+const ecdhCurves = "prime256v1, secp384r1, secp521r1"
+
+// tlsVersions maps the TLS versions of the cluster TLS security profiles to the sslVersions values, in order
+var tlsVersions = []struct{ profile, splunk string }{
+	{"VersionTLS10", "tls1.0"},
+	{"VersionTLS11", "tls1.1"},
+	{"VersionTLS12", "tls1.2"},
+	{"VersionTLS13", "tls1.3"},
+}
+
+// TLSProfile holds the TLS security profile of the cluster, taken from the spec of the cluster-wide APIServer
+type TLSProfile struct {
+	// MinTLSVersion is the minimum TLS version, such as VersionTLS12
+	MinTLSVersion string
+	// Ciphers are the OpenSSL names of the allowed ciphers, including the TLS 1.3 suites
+	Ciphers []string
+}
+
+// IsSet returns whether the cluster has a TLS security profile
+func (p TLSProfile) IsSet() bool {
+	return p.MinTLSVersion != "" || len(p.Ciphers) > 0
+}
+
+// Hash returns a hash of the profile, which changes whenever the rendered TLS settings do
+func (p TLSProfile) Hash() string {
+	return DataHash(map[string][]byte{
+		"minTLSVersion": []byte(p.MinTLSVersion),
+		"ciphers":       []byte(strings.Join(p.Ciphers, ":")),
+	})
+}
+
+// sslVersions renders the minimum TLS version of the profile as the list of the versions it allows
+func (p TLSProfile) sslVersions(fips bool) string {
+	versions := []string{}
+	allowed := p.MinTLSVersion == ""
+	for _, version := range tlsVersions {
+		if version.profile == p.MinTLSVersion {
+			allowed = true
+		}
+		if allowed && (!fips || fipsTLSVersions[version.splunk]) {
+			versions = append(versions, version.splunk)
+		}
+	}
+	return strings.Join(versions, ", ")
+}
+
+// cipherSuite renders the ciphers of the profile as an OpenSSL cipher list. The TLS 1.3 suites are left
+// out, as they are not set through the cipher list.
+func (p TLSProfile) cipherSuite(fips bool) string {
+	ciphers := []string{}
+	for _, cipher := range p.Ciphers {
+		if strings.HasPrefix(cipher, "TLS_") || (fips && !fipsCiphers[cipher]) {
+			continue
+		}
+		ciphers = append(ciphers, cipher)
+	}
+	return strings.Join(ciphers, ":")
+}
+
+// tlsSettings renders the sslVersions, cipherSuite and ecdhCurves settings of the forwarders. They follow
+// the TLS security profile of the cluster, restricted in FIPS mode to the FIPS-approved versions and
+// ciphers. Without a profile, FIPS mode uses its own defaults, and the Splunk defaults are kept otherwise.
+func tlsSettings(profile TLSProfile, fips bool) string {
+	if !profile.IsSet() {
+		if !fips {
+			return ""
+		}
+		return "sslVersions = " + fipsSSLVersions + "\ncipherSuite = " + fipsCipherSuite + "\necdhCurves = " + ecdhCurves + "\n"
+	}
+
+	ret := "sslVersions = " + profile.sslVersions(fips) + "\n"
+	cipherSuite := profile.cipherSuite(fips)
+	if cipherSuite == "" && fips {
+		cipherSuite = fipsCipherSuite
+	}
+	if cipherSuite != "" {
+		ret += "cipherSuite = " + cipherSuite + "\n"
+	}
+	return ret + "ecdhCurves = " + ecdhCurves + "\n"
+}
+
+// ApplyTLSProfileToPodTemplate sets the hash of the TLS security profile on a pod template, so that a
+// profile change rolls the forwarders: splunkd only reads its TLS settings on start
+func ApplyTLSProfileToPodTemplate(template *corev1.PodTemplateSpec, profile TLSProfile) {
+	if !profile.IsSet() {
+		return
+	}
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations[TLSProfileHashAnnotation] = profile.Hash()
+}
+
+// ApplyTLSToConfigMaps sets the TLS settings of the forwarders, following the TLS security profile of the
+// cluster and the FIPS mode, in the forwarder app ConfigMaps, the ones holding inputs: for splunkd in
+// [sslConfig] of server.conf, and for the receivers in [tcpout] of outputs.conf
+func ApplyTLSToConfigMaps(configMaps []*corev1.ConfigMap, profile TLSProfile, fips bool) {
+	settings := tlsSettings(profile, fips)
+	if settings == "" {
+		return
+	}
+	for _, cm := range configMaps {
+		if _, ok := cm.Data["inputs.conf"]; !ok {
+			continue
+		}
+		addStanzaSettings(cm.Data, "server.conf", "sslConfig", settings)
+		addStanzaSettings(cm.Data, "outputs.conf", "tcpout", settings)
+	}
+}
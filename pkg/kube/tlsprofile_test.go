@@ -0,0 +1,99 @@
+package kube
+
+import (
+	"reflect"
+	"testing"
+
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestTLSSettings(t *testing.T) {
+	intermediate := TLSProfile{
+		MinTLSVersion: "VersionTLS12",
+		Ciphers:       []string{"TLS_AES_128_GCM_SHA256", "ECDHE-ECDSA-AES128-GCM-SHA256", "ECDHE-RSA-CHACHA20-POLY1305"},
+	}
+	for _, tt := range []struct {
+		name    string
+		profile TLSProfile
+		fips    bool
+		want    string
+	}{
+		{"no profile", TLSProfile{}, false, ""},
+		{
+			"intermediate",
+			intermediate,
+			false,
+			"sslVersions = tls1.2, tls1.3\ncipherSuite = ECDHE-ECDSA-AES128-GCM-SHA256:ECDHE-RSA-CHACHA20-POLY1305\necdhCurves = " + ecdhCurves + "\n",
+		},
+		{
+			"intermediate in FIPS mode",
+			intermediate,
+			true,
+			"sslVersions = tls1.2, tls1.3\ncipherSuite = ECDHE-ECDSA-AES128-GCM-SHA256\necdhCurves = " + ecdhCurves + "\n",
+		},
+		{
+			"old in FIPS mode",
+			TLSProfile{MinTLSVersion: "VersionTLS10", Ciphers: []string{"AES128-SHA"}},
+			true,
+			"sslVersions = tls1.2, tls1.3\ncipherSuite = " + fipsCipherSuite + "\necdhCurves = " + ecdhCurves + "\n",
+		},
+		{
+			"modern",
+			TLSProfile{MinTLSVersion: "VersionTLS13", Ciphers: []string{"TLS_AES_128_GCM_SHA256"}},
+			false,
+			"sslVersions = tls1.3\necdhCurves = " + ecdhCurves + "\n",
+		},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tlsSettings(tt.profile, tt.fips); got != tt.want {
+				t.Errorf("tlsSettings() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestApplyTLSProfile(t *testing.T) {
+	instance := splunkForwarderInstance(true)
+	namespacedName := types.NamespacedName{Namespace: instanceNamespace, Name: instanceName}
+	profile := TLSProfile{MinTLSVersion: "VersionTLS12", Ciphers: []string{"ECDHE-RSA-AES128-GCM-SHA256"}}
+
+	// Without a profile nothing changes
+	ds := GenerateDaemonSet(instance, true, operatorImage)
+	template := ds.Spec.Template.DeepCopy()
+	ApplyTLSProfileToPodTemplate(template, TLSProfile{})
+	if !reflect.DeepEqual(*template, ds.Spec.Template) {
+		t.Errorf("ApplyTLSProfileToPodTemplate() changed the pod template without a profile")
+	}
+	configMaps := GenerateConfigMaps(instance, namespacedName, ClusterMetadata{ClusterID: "test"}, nil)
+	ApplyTLSToConfigMaps(configMaps, TLSProfile{}, false)
+	if got, ok := configMaps[1].Data["server.conf"]; ok {
+		t.Errorf("server.conf = %q without a profile", got)
+	}
+
+	ApplyTLSProfileToPodTemplate(template, profile)
+	hash := template.Annotations[TLSProfileHashAnnotation]
+	if hash == "" {
+		t.Fatalf("pod template annotations = %v, want the profile hash", template.Annotations)
+	}
+	profile.MinTLSVersion = "VersionTLS13"
+	if profile.Hash() == hash {
+		t.Errorf("the profile hash does not change with the minimum TLS version")
+	}
+
+	ApplyTLSToConfigMaps(configMaps, profile, false)
+	for _, cm := range configMaps {
+		_, hasInputs := cm.Data["inputs.conf"]
+		for _, file := range []string{"server.conf", "outputs.conf"} {
+			if _, ok := cm.Data[file]; ok != hasInputs {
+				t.Errorf("ConfigMap %s has %s = %v", cm.Name, file, ok)
+			}
+		}
+	}
+	want := "\n[tcpout]\nsslVersions = tls1.3\ncipherSuite = ECDHE-RSA-AES128-GCM-SHA256\necdhCurves = " + ecdhCurves + "\n"
+	if got := configMaps[1].Data["outputs.conf"]; got != want {
+		t.Errorf("outputs.conf = %q, want %q", got, want)
+	}
+	if got := configMaps[1].Data["server.conf"]; got != "\n[sslConfig]"+want[len("\n[tcpout]"):] {
+		t.Errorf("server.conf = %q, want the [sslConfig] settings", got)
+	}
+}
@@ -0,0 +1,157 @@
+package kube
+
+import (
+	"strconv"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	"github.com/openshift/splunk-forwarder-operator/config"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// TrustedCABundleInjectLabel asks the cluster network operator to inject the trusted CA bundle of the
+	// cluster into a ConfigMap
+	TrustedCABundleInjectLabel = "config.openshift.io/inject-trusted-cabundle"
+	// TrustedCABundleKey is the key the bundle is injected under
+	TrustedCABundleKey = "ca-bundle.crt"
+	// trustedCABundleDir is where the combined bundle is mounted in the forwarder container
+	trustedCABundleDir = "/opt/splunkforwarder/etc/trusted-ca"
+	// trustedCABundleCombinedVolume is the volume the init container writes the combined bundle to, along
+	// with a copy of the splunkauth app whose CA is the combined bundle
+	trustedCABundleCombinedVolume = "trusted-ca-combined"
+	// splunkAuthCAFile is the CA of the receivers in the splunkauth app
+	splunkAuthCAFile = "cacert.pem"
+)
+
+// trustedCABundleScript concatenates the CA of splunk-auth, when it is mounted, and the injected bundle into
+// the combined bundle, and copies the splunkauth app with the combined bundle as its CA. The receiver groups
+// of splunk-auth that set their own sslRootCAPath to the splunkauth CA thus trust both, without losing the
+// CA of their mTLS.
+const trustedCABundleScript = `set -e
+out=/tmp/trusted-ca-combined
+: > $out/` + TrustedCABundleKey + `
+for ca in /tmp/splunk-auth/` + splunkAuthCAFile + ` /tmp/trusted-ca/` + TrustedCABundleKey + `; do
+  if [ -f $ca ]; then
+    cat $ca >> $out/` + TrustedCABundleKey + `
+    echo >> $out/` + TrustedCABundleKey + `
+  fi
+done
+if [ -d /tmp/splunk-auth ]; then
+  mkdir -p $out/splunkauth
+  cp -L /tmp/splunk-auth/* $out/splunkauth/
+  cp $out/` + TrustedCABundleKey + ` $out/splunkauth/` + splunkAuthCAFile + `
+fi`
+
+// TrustedCABundleEnabled returns whether the forwarders of the CR trust the CA bundle of the cluster
+func TrustedCABundleEnabled(instance *sfv1alpha1.SplunkForwarder) bool {
+	return instance.Spec.TrustedCABundle
+}
+
+// TrustedCABundleName returns the name of the ConfigMap the trusted CA bundle is injected into
+func TrustedCABundleName(instance *sfv1alpha1.SplunkForwarder) string {
+	return instance.Name + "-trusted-ca-bundle"
+}
+
+// GenerateTrustedCABundleConfigMap returns the ConfigMap the trusted CA bundle is injected into. It has no
+// data: the injected bundle is owned by the cluster network operator and must not be overwritten.
+func GenerateTrustedCABundleConfigMap(instance *sfv1alpha1.SplunkForwarder) *corev1.ConfigMap {
+	return &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      TrustedCABundleName(instance),
+			Namespace: instance.Namespace,
+			Labels: map[string]string{
+				"app":                      instance.Name,
+				TrustedCABundleInjectLabel: "true",
+			},
+			Annotations: map[string]string{
+				"genVersion": strconv.FormatInt(instance.Generation, 10),
+			},
+		},
+	}
+}
+
+// getTrustedCABundleVolumes returns the volume of the injected trusted CA bundle, and the volume of the
+// combined bundle. The injected bundle is optional, so that the forwarders start before it is injected.
+func getTrustedCABundleVolumes(instance *sfv1alpha1.SplunkForwarder) []corev1.Volume {
+	optional := true
+	return []corev1.Volume{{
+		Name: "trusted-ca-bundle",
+		VolumeSource: corev1.VolumeSource{
+			ConfigMap: &corev1.ConfigMapVolumeSource{
+				LocalObjectReference: corev1.LocalObjectReference{
+					Name: TrustedCABundleName(instance),
+				},
+				Items: []corev1.KeyToPath{
+					{
+						Key:  TrustedCABundleKey,
+						Path: TrustedCABundleKey,
+					},
+				},
+				Optional: &optional,
+			},
+		},
+	}, {
+		Name: trustedCABundleCombinedVolume,
+		VolumeSource: corev1.VolumeSource{
+			EmptyDir: &corev1.EmptyDirVolumeSource{},
+		},
+	}}
+}
+
+// getTrustedCABundleVolumeMounts returns the mount of the combined bundle, and replaces the mounts of the
+// splunk-auth Secret with the copy of the splunkauth app whose CA is the combined bundle
+func getTrustedCABundleVolumeMounts(volumeMounts []corev1.VolumeMount) []corev1.VolumeMount {
+	for i := range volumeMounts {
+		if volumeMounts[i].Name == config.SplunkAuthSecretName {
+			volumeMounts[i].Name = trustedCABundleCombinedVolume
+			volumeMounts[i].SubPath = "splunkauth"
+		}
+	}
+	return append(volumeMounts, corev1.VolumeMount{
+		Name:      trustedCABundleCombinedVolume,
+		MountPath: trustedCABundleDir,
+		ReadOnly:  true,
+	})
+}
+
+// getTrustedCABundleInitContainer returns the init container writing the combined bundle. It runs the
+// forwarder image, as the forwarder container does.
+func getTrustedCABundleInitContainer(instance *sfv1alpha1.SplunkForwarder, useHECToken bool) corev1.Container {
+	volumeMounts := []corev1.VolumeMount{
+		{
+			Name:      "trusted-ca-bundle",
+			MountPath: "/tmp/trusted-ca",
+			ReadOnly:  true,
+		},
+		{
+			Name:      trustedCABundleCombinedVolume,
+			MountPath: "/tmp/trusted-ca-combined",
+		},
+	}
+	if !useHECToken {
+		volumeMounts = append(volumeMounts, corev1.VolumeMount{
+			Name:      config.SplunkAuthSecretName,
+			MountPath: "/tmp/splunk-auth",
+			ReadOnly:  true,
+		})
+	}
+	return corev1.Container{
+		Name:            "trusted-ca-bundle",
+		ImagePullPolicy: forwarderPullPolicy(instance),
+		Image:           forwarderPullSpec(instance),
+		Command:         []string{"/bin/bash", "-c", trustedCABundleScript},
+		VolumeMounts:    volumeMounts,
+	}
+}
+
+// addTrustedCABundleConfs points the forwarders at the combined bundle: as the server.conf CA, and as
+// the [tcpout] default of the receiver groups
+func addTrustedCABundleConfs(instance *sfv1alpha1.SplunkForwarder, data map[string]string) {
+	if !TrustedCABundleEnabled(instance) {
+		return
+	}
+	caPath := trustedCABundleDir + "/" + TrustedCABundleKey
+	addStanzaSettings(data, "outputs.conf", "tcpout", "sslRootCAPath = "+caPath+"\n")
+	addStanzaSettings(data, "server.conf", "sslConfig", "sslRootCAPath = "+caPath+"\n")
+}
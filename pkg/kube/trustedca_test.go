@@ -0,0 +1,82 @@
+package kube
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/openshift/splunk-forwarder-operator/config"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+func TestTrustedCABundle(t *testing.T) {
+	instance := splunkForwarderInstance(true)
+	namespacedName := types.NamespacedName{Namespace: instanceNamespace, Name: instanceName}
+	hasVolume := func(volumes []corev1.Volume) bool {
+		for _, volume := range volumes {
+			if volume.Name == "trusted-ca-bundle" {
+				return volume.ConfigMap.Name == instanceName+"-trusted-ca-bundle" && *volume.ConfigMap.Optional
+			}
+		}
+		return false
+	}
+	hasMount := func(mounts []corev1.VolumeMount) bool {
+		for _, mount := range mounts {
+			if mount.Name == "trusted-ca-combined" && mount.SubPath == "" {
+				return mount.MountPath == "/opt/splunkforwarder/etc/trusted-ca" && mount.ReadOnly
+			}
+		}
+		return false
+	}
+
+	if hasVolume(GetVolumes(instance, true, true, false)) || hasMount(GetVolumeMounts(instance, false)) {
+		t.Errorf("the trusted CA bundle is mounted without trustedCABundle")
+	}
+	if local := GenerateConfigMaps(instance, namespacedName, ClusterMetadata{ClusterID: "test"}, nil)[1]; local.Data["outputs.conf"] != "" {
+		t.Errorf("outputs.conf = %q, want none without trustedCABundle", local.Data["outputs.conf"])
+	}
+
+	instance.Spec.TrustedCABundle = true
+	if !hasVolume(GetVolumes(instance, true, true, false)) || !hasMount(GetVolumeMounts(instance, false)) {
+		t.Errorf("the trusted CA bundle is not mounted with trustedCABundle")
+	}
+	// The splunkauth app is mounted from the copy whose CA is the combined bundle
+	for _, mount := range GetVolumeMounts(instance, false) {
+		if mount.Name == config.SplunkAuthSecretName {
+			t.Errorf("splunk-auth is mounted at %s, want the copy of the splunkauth app", mount.MountPath)
+		}
+		if strings.HasPrefix(mount.MountPath, "/opt/splunkforwarder/etc/apps/splunkauth/") && (mount.Name != "trusted-ca-combined" || mount.SubPath != "splunkauth") {
+			t.Errorf("splunkauth app mount = %+v, want the splunkauth directory of the combined bundle volume", mount)
+		}
+	}
+	for _, useHECToken := range []bool{false, true} {
+		initContainers := GenerateDaemonSet(instance, useHECToken, operatorImage).Spec.Template.Spec.InitContainers
+		initContainer := initContainers[len(initContainers)-1]
+		if initContainer.Name != "trusted-ca-bundle" || initContainer.Image != forwarderPullSpec(instance) {
+			t.Fatalf("init containers = %v, want the trusted-ca-bundle init container last", initContainers)
+		}
+		mountsAuth := false
+		for _, mount := range initContainer.VolumeMounts {
+			mountsAuth = mountsAuth || mount.Name == config.SplunkAuthSecretName
+		}
+		if mountsAuth == useHECToken {
+			t.Errorf("init container mounts splunk-auth = %v with the HEC token = %v", mountsAuth, useHECToken)
+		}
+	}
+
+	local := GenerateConfigMaps(instance, namespacedName, ClusterMetadata{ClusterID: "test"}, nil)[1]
+	if got, want := local.Data["outputs.conf"], "\n[tcpout]\nsslRootCAPath = /opt/splunkforwarder/etc/trusted-ca/ca-bundle.crt\n"; got != want {
+		t.Errorf("outputs.conf = %q, want %q", got, want)
+	}
+	if got, want := local.Data["server.conf"], "\n[sslConfig]\nsslRootCAPath = /opt/splunkforwarder/etc/trusted-ca/ca-bundle.crt\n"; got != want {
+		t.Errorf("server.conf = %q, want %q", got, want)
+	}
+
+	cm := GenerateTrustedCABundleConfigMap(instance)
+	if cm.Labels[TrustedCABundleInjectLabel] != "true" {
+		t.Errorf("ConfigMap labels = %v, want the injection label", cm.Labels)
+	}
+	if cm.Data != nil {
+		t.Errorf("ConfigMap data = %v, want none so that the injected bundle is kept", cm.Data)
+	}
+}
@@ -11,6 +11,51 @@ import (
 func GetVolumeMounts(instance *sfv1alpha1.SplunkForwarder, useHECToken bool) []corev1.VolumeMount {
 	mountPropagationMode := corev1.MountPropagationHostToContainer
 
+	volumeMounts := getAuthVolumeMounts(useHECToken)
+	defaultMounts := []corev1.VolumeMount{
+		// Inputs Mount
+		{
+			Name:      inputsVolumeName,
+			MountPath: "/opt/splunkforwarder/etc/apps/osd_monitored_logs/local",
+		},
+		{
+			Name:      "osd-monitored-logs-metadata",
+			MountPath: "/opt/splunkforwarder/etc/apps/osd_monitored_logs/metadata",
+		},
+
+		// State Mount
+		{
+			Name:      "splunk-state",
+			MountPath: "/opt/splunkforwarder/var/lib",
+		},
+
+		// Host Mount
+		{
+			Name:             "host",
+			MountPath:        "/host",
+			MountPropagation: &mountPropagationMode,
+			ReadOnly:         true,
+		},
+	}
+	volumeMounts = append(volumeMounts, defaultMounts...)
+	if usesJournald(instance) {
+		volumeMounts = append(volumeMounts, getJournalVolumeMounts()...)
+	}
+	if len(instance.Spec.ScriptedInputs) > 0 {
+		volumeMounts = append(volumeMounts, getScriptedInputsVolumeMount())
+		if persistentQueueSize(instance) != "" {
+			volumeMounts = append(volumeMounts, getPersistentQueueVolumeMount(scriptedInputsQueueType))
+		}
+	}
+	volumeMounts = append(volumeMounts, getAppVolumeMounts(instance)...)
+	if TrustedCABundleEnabled(instance) {
+		volumeMounts = getTrustedCABundleVolumeMounts(volumeMounts)
+	}
+	return volumeMounts
+}
+
+// getAuthVolumeMounts returns where the authentication volumes are mounted in the forwarder container
+func getAuthVolumeMounts(useHECToken bool) []corev1.VolumeMount {
 	volumeMounts := []corev1.VolumeMount{}
 	if useHECToken {
 		hecConfigMount := corev1.VolumeMount{
@@ -37,32 +82,6 @@ func GetVolumeMounts(instance *sfv1alpha1.SplunkForwarder, useHECToken bool) []c
 		}
 		volumeMounts = append(volumeMounts, splunkConfigMounts...)
 	}
-	defaultMounts := []corev1.VolumeMount{
-		// Inputs Mount
-		{
-			Name:      "osd-monitored-logs-local",
-			MountPath: "/opt/splunkforwarder/etc/apps/osd_monitored_logs/local",
-		},
-		{
-			Name:      "osd-monitored-logs-metadata",
-			MountPath: "/opt/splunkforwarder/etc/apps/osd_monitored_logs/metadata",
-		},
-
-		// State Mount
-		{
-			Name:      "splunk-state",
-			MountPath: "/opt/splunkforwarder/var/lib",
-		},
-
-		// Host Mount
-		{
-			Name:             "host",
-			MountPath:        "/host",
-			MountPropagation: &mountPropagationMode,
-			ReadOnly:         true,
-		},
-	}
-	volumeMounts = append(volumeMounts, defaultMounts...)
 	return volumeMounts
 }
 
@@ -1,22 +1,25 @@
 package kube
 
 import (
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
 	"github.com/openshift/splunk-forwarder-operator/config"
 	corev1 "k8s.io/api/core/v1"
 )
 
 // GetVolumes Returns an array of corev1.Volumes we want to attach
-// It contains configmaps, secrets, and the host mount
-func GetVolumes(mountHost, mountSecret, mountHECToken bool, instanceName string) []corev1.Volume {
+// It contains configmaps, secrets, and the host mount, plus the host journal of the journald inputs,
+// the scripts of the scripted inputs, the extra apps and the trusted CA bundle
+func GetVolumes(instance *sfv1alpha1.SplunkForwarder, mountHost, mountSecret, mountHECToken bool) []corev1.Volume {
+	instanceName := instance.Name
 	var hostPathDirectoryTypeForPtr = corev1.HostPathDirectory
 
 	volumes := []corev1.Volume{
 		{
-			Name: "osd-monitored-logs-local",
+			Name: inputsVolumeName,
 			VolumeSource: corev1.VolumeSource{
 				ConfigMap: &corev1.ConfigMapVolumeSource{
 					LocalObjectReference: corev1.LocalObjectReference{
-						Name: "osd-monitored-logs-local",
+						Name: localConfigMapName(""),
 					},
 				},
 			},
@@ -53,6 +56,16 @@ func GetVolumes(mountHost, mountSecret, mountHECToken bool, instanceName string)
 					},
 				},
 			})
+		if usesJournald(instance) {
+			volumes = append(volumes, getJournalVolumes()...)
+		}
+		if len(instance.Spec.ScriptedInputs) > 0 {
+			volumes = append(volumes, getScriptedInputsVolume(instance))
+		}
+		volumes = append(volumes, getAppVolumes(instance)...)
+		if TrustedCABundleEnabled(instance) {
+			volumes = append(volumes, getTrustedCABundleVolumes(instance)...)
+		}
 	} else {
 		// if we aren't mounting the host dir, we're the hf
 		var hfName = instanceName + "-hfconfig"
@@ -69,8 +82,32 @@ func GetVolumes(mountHost, mountSecret, mountHECToken bool, instanceName string)
 			})
 	}
 
+	if mountHECToken || mountSecret {
+		volumes = append(volumes, getAuthVolumes(mountHECToken)...)
+	} else {
+		// if we aren't mounting the secret, we're fwding to the splunk hf
+		var internalName = instanceName + "-internalsplunk"
+		volumes = append(volumes,
+			corev1.Volume{
+				Name: internalName,
+				VolumeSource: corev1.VolumeSource{
+					ConfigMap: &corev1.ConfigMapVolumeSource{
+						LocalObjectReference: corev1.LocalObjectReference{
+							Name: internalName,
+						},
+					},
+				},
+			})
+	}
+
+	return volumes
+}
+
+// getAuthVolumes returns the volumes the forwarders authenticate against Splunk with: the HEC token
+// Secret and the emptyDir its outputs.conf is copied to, or the splunk-auth Secret for mTLS
+func getAuthVolumes(mountHECToken bool) []corev1.Volume {
 	if mountHECToken {
-		hecVolumes := []corev1.Volume{
+		return []corev1.Volume{
 			{
 				Name: config.SplunkHECTokenSecretName,
 				VolumeSource: corev1.VolumeSource{
@@ -86,32 +123,15 @@ func GetVolumes(mountHost, mountSecret, mountHECToken bool, instanceName string)
 				},
 			},
 		}
-		volumes = append(volumes, hecVolumes...)
-	} else if mountSecret {
-		volumes = append(volumes,
-			corev1.Volume{
-				Name: config.SplunkAuthSecretName,
-				VolumeSource: corev1.VolumeSource{
-					Secret: &corev1.SecretVolumeSource{
-						SecretName: config.SplunkAuthSecretName,
-					},
-				},
-			})
-	} else {
-		// if we aren't mounting the secret, we're fwding to the splunk hf
-		var internalName = instanceName + "-internalsplunk"
-		volumes = append(volumes,
-			corev1.Volume{
-				Name: internalName,
-				VolumeSource: corev1.VolumeSource{
-					ConfigMap: &corev1.ConfigMapVolumeSource{
-						LocalObjectReference: corev1.LocalObjectReference{
-							Name: internalName,
-						},
-					},
+	}
+	return []corev1.Volume{
+		{
+			Name: config.SplunkAuthSecretName,
+			VolumeSource: corev1.VolumeSource{
+				Secret: &corev1.SecretVolumeSource{
+					SecretName: config.SplunkAuthSecretName,
 				},
-			})
+			},
+		},
 	}
-
-	return volumes
 }
@@ -4,10 +4,14 @@ import (
 	"reflect"
 	"testing"
 
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
 	"github.com/openshift/splunk-forwarder-operator/config"
 	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 )
 
+var testVolumesInstance = &sfv1alpha1.SplunkForwarder{ObjectMeta: metav1.ObjectMeta{Name: "test"}}
+
 func TestGetVolumes(t *testing.T) {
 	var hostPathDirectoryTypeForPtr = corev1.HostPathDirectory
 
@@ -15,7 +19,7 @@ func TestGetVolumes(t *testing.T) {
 		mountHost     bool
 		mountSecret   bool
 		mountHECToken bool
-		instanceName  string
+		instance      *sfv1alpha1.SplunkForwarder
 	}
 	tests := []struct {
 		name string
@@ -25,9 +29,9 @@ func TestGetVolumes(t *testing.T) {
 		{
 			name: "Host-true secret-false",
 			args: args{
-				mountHost:    true,
-				mountSecret:  false,
-				instanceName: "test",
+				mountHost:   true,
+				mountSecret: false,
+				instance:    testVolumesInstance,
 			},
 			want: []corev1.Volume{
 				{
@@ -83,9 +87,9 @@ func TestGetVolumes(t *testing.T) {
 		{
 			name: "Host-true secret-true",
 			args: args{
-				mountHost:    true,
-				mountSecret:  true,
-				instanceName: "test",
+				mountHost:   true,
+				mountSecret: true,
+				instance:    testVolumesInstance,
 			},
 			want: []corev1.Volume{
 				{
@@ -139,9 +143,9 @@ func TestGetVolumes(t *testing.T) {
 		{
 			name: "Host-false secret-false",
 			args: args{
-				mountHost:    false,
-				mountSecret:  false,
-				instanceName: "test",
+				mountHost:   false,
+				mountSecret: false,
+				instance:    testVolumesInstance,
 			},
 			want: []corev1.Volume{
 				{
@@ -198,9 +202,9 @@ func TestGetVolumes(t *testing.T) {
 		{
 			name: "Host-false secret-true",
 			args: args{
-				mountHost:    false,
-				mountSecret:  true,
-				instanceName: "test",
+				mountHost:   false,
+				mountSecret: true,
+				instance:    testVolumesInstance,
 			},
 			want: []corev1.Volume{
 				{
@@ -258,7 +262,7 @@ func TestGetVolumes(t *testing.T) {
 				mountHost:     true,
 				mountSecret:   true,
 				mountHECToken: true,
-				instanceName:  "test",
+				instance:      testVolumesInstance,
 			},
 			want: []corev1.Volume{
 				{
@@ -318,7 +322,7 @@ func TestGetVolumes(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := GetVolumes(tt.args.mountHost, tt.args.mountSecret, tt.args.mountHECToken, tt.args.instanceName); !reflect.DeepEqual(got, tt.want) {
+			if got := GetVolumes(tt.args.instance, tt.args.mountHost, tt.args.mountSecret, tt.args.mountHECToken); !reflect.DeepEqual(got, tt.want) {
 				t.Errorf("GetVolumes() = %v, want %v", got, tt.want)
 			}
 		})
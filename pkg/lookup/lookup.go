@@ -0,0 +1,442 @@
+// Package lookup reads the cluster state the forwarder objects of a SplunkForwarder CR are generated from.
+// It is shared by the operator and the diff subcommand so that both generate the same objects.
+package lookup
+
+import (
+	"context"
+	goerr "errors"
+	"fmt"
+	"strings"
+
+	configv1 "github.com/openshift/api/config/v1"
+	operatorv1alpha1 "github.com/openshift/api/operator/v1alpha1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/yaml"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	"github.com/openshift/splunk-forwarder-operator/config"
+	"github.com/openshift/splunk-forwarder-operator/pkg/kube"
+)
+
+var (
+	// ErrInfrastructureUnavailable is returned when the Infrastructure resource is needed for the cluster
+	// metadata but cannot be read
+	ErrInfrastructureUnavailable = goerr.New("infrastructure unavailable")
+	// ErrClusterVersionUnavailable is returned, along with the metadata without the clusterVersion and
+	// clusterUUID fields, when the CR selects them but there is no ClusterVersion resource
+	ErrClusterVersionUnavailable = goerr.New("cluster version unavailable")
+)
+
+const (
+	// installConfigName is the ConfigMap holding the install-config of the cluster, which sets the FIPS mode
+	installConfigName = "cluster-config-v1"
+	// installConfigNamespace is the namespace of the install-config ConfigMap
+	installConfigNamespace = "kube-system"
+)
+
+// ClusterState looks up everything the forwarder objects of the CR are generated from besides the CR.
+// The trusted CA bundle and the issued certificate are read as they are, the operator creates their
+// objects before looking them up. Without a ClusterVersion, the state is returned along with
+// ErrClusterVersionUnavailable.
+func ClusterState(ctx context.Context, c client.Reader, instance *sfv1alpha1.SplunkForwarder) (kube.ClusterState, error) {
+	state := kube.ClusterState{}
+	var err error
+	state.ClusterMetadata, err = ClusterMetadata(ctx, c, instance)
+	degraded := err
+	if err != nil && !goerr.Is(err, ErrClusterVersionUnavailable) {
+		return state, err
+	}
+	state.PodLogs, err = PodLogTargets(ctx, c, instance)
+	if err != nil {
+		return state, err
+	}
+	state.NodeRoles, err = NodeRoles(ctx, c, instance)
+	if err != nil {
+		return state, err
+	}
+	state.UseHECToken, err = HECTokenPresent(ctx, c, instance.Namespace)
+	if err != nil {
+		return state, err
+	}
+	state.Proxy, err = ClusterProxy(ctx, c)
+	if err != nil {
+		return state, err
+	}
+	state.FIPS, err = ClusterFIPS(ctx, c)
+	if err != nil {
+		return state, err
+	}
+	state.TLSProfile, err = TLSProfile(ctx, c)
+	if err != nil {
+		return state, err
+	}
+	state.AuthHash, err = AuthHash(ctx, c, instance, state.UseHECToken)
+	if err != nil {
+		return state, err
+	}
+	state.CertificateHash, err = CertificateHash(ctx, c, instance)
+	if err != nil {
+		return state, err
+	}
+	state.AppsHash, err = AppsHash(ctx, c, instance)
+	if err != nil {
+		return state, err
+	}
+	state.TrustedCABundleHash, err = TrustedCABundleHash(ctx, c, instance)
+	if err != nil {
+		return state, err
+	}
+	return state, degraded
+}
+
+// ClusterMetadata returns the cluster metadata added to every event. The cluster ID is taken from
+// the CR, or looked up from the Infrastructure resource. The other fields are only looked up when the CR
+// selects them. It returns ErrInfrastructureUnavailable when the Infrastructure resource is needed but
+// cannot be read, and ErrClusterVersionUnavailable with the other fields when the ClusterVersion is.
+func ClusterMetadata(ctx context.Context, c client.Reader, instance *sfv1alpha1.SplunkForwarder) (kube.ClusterMetadata, error) {
+	metadata := kube.ClusterMetadata{ClusterID: instance.Spec.ClusterID}
+	selected := instance.Spec.MetadataFields
+	if selected == nil {
+		selected = &sfv1alpha1.SplunkMetadataFields{}
+	}
+
+	if metadata.ClusterID == "" || selected.Platform || selected.Region {
+		configFound := &configv1.Infrastructure{}
+		err := c.Get(ctx, types.NamespacedName{Name: "cluster"}, configFound)
+		if err != nil {
+			return metadata, fmt.Errorf("%w: %v", ErrInfrastructureUnavailable, err)
+		}
+		if metadata.ClusterID == "" {
+			metadata.ClusterID = configFound.Status.InfrastructureName
+			if metadata.ClusterID == "" {
+				return metadata, fmt.Errorf("%w: infrastructure name is not set", ErrInfrastructureUnavailable)
+			}
+		}
+		metadata.Platform, metadata.Region = platformAndRegion(configFound)
+	}
+
+	if selected.ClusterVersion || selected.ClusterUUID {
+		versionFound := &configv1.ClusterVersion{}
+		err := c.Get(ctx, types.NamespacedName{Name: "version"}, versionFound)
+		if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+			return metadata, fmt.Errorf("%w: %v", ErrClusterVersionUnavailable, err)
+		} else if err != nil {
+			return metadata, err
+		}
+		metadata.ClusterVersion = versionFound.Status.Desired.Version
+		metadata.ClusterUUID = string(versionFound.Spec.ClusterID)
+	}
+
+	return metadata, nil
+}
+
+// platformAndRegion returns the platform type and, for the platforms that report one, the region
+// of the cluster.
+func platformAndRegion(infra *configv1.Infrastructure) (string, string) {
+	status := infra.Status.PlatformStatus
+	if status == nil {
+		return string(infra.Status.Platform), ""
+	}
+
+	region := ""
+	switch {
+	case status.AWS != nil:
+		region = status.AWS.Region
+	case status.GCP != nil:
+		region = status.GCP.Region
+	}
+	return string(status.Type), region
+}
+
+// NodeRoles returns the distinct sets of roles of the nodes, or none when the inputs of the CR are not
+// limited to node roles. Only the metadata of the nodes is read.
+func NodeRoles(ctx context.Context, c client.Reader, instance *sfv1alpha1.SplunkForwarder) ([][]string, error) {
+	if len(kube.NodeRoles(instance)) == 0 {
+		return nil, nil
+	}
+	nodeList := &metav1.PartialObjectMetadataList{}
+	nodeList.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("NodeList"))
+	err := c.List(ctx, nodeList)
+	if err != nil {
+		return nil, err
+	}
+	seen := map[string]bool{}
+	nodeRoles := [][]string{}
+	for _, node := range nodeList.Items {
+		roles := kube.NodeRoleLabels(node.Labels)
+		key := strings.Join(roles, ",")
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		nodeRoles = append(nodeRoles, roles)
+	}
+	return nodeRoles, nil
+}
+
+// HECTokenPresent returns whether the splunk-hec-token Secret exists in the namespace, which selects the
+// HEC mode of the forwarders
+func HECTokenPresent(ctx context.Context, c client.Reader, namespace string) (bool, error) {
+	err := c.Get(ctx, types.NamespacedName{Name: config.SplunkHECTokenSecretName, Namespace: namespace}, &corev1.Secret{})
+	if errors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ClusterProxy returns the egress proxy of the cluster from the status of the cluster-wide Proxy,
+// which adds the cluster networks to noProxy. Clusters without the Proxy resource have no proxy.
+func ClusterProxy(ctx context.Context, c client.Reader) (kube.ClusterProxy, error) {
+	proxyFound := &configv1.Proxy{}
+	err := c.Get(ctx, types.NamespacedName{Name: "cluster"}, proxyFound)
+	if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		return kube.ClusterProxy{}, nil
+	} else if err != nil {
+		return kube.ClusterProxy{}, err
+	}
+	return kube.ClusterProxy{
+		HTTPProxy:  proxyFound.Status.HTTPProxy,
+		HTTPSProxy: proxyFound.Status.HTTPSProxy,
+		NoProxy:    proxyFound.Status.NoProxy,
+	}, nil
+}
+
+// TLSProfile returns the TLS security profile of the cluster from the spec of the cluster-wide
+// APIServer. Clusters without the APIServer resource or a profile keep the Splunk TLS defaults.
+func TLSProfile(ctx context.Context, c client.Reader) (kube.TLSProfile, error) {
+	apiServerFound := &configv1.APIServer{}
+	err := c.Get(ctx, types.NamespacedName{Name: "cluster"}, apiServerFound)
+	if errors.IsNotFound(err) || meta.IsNoMatchError(err) {
+		return kube.TLSProfile{}, nil
+	} else if err != nil {
+		return kube.TLSProfile{}, err
+	}
+	return TLSProfileFromSecurityProfile(apiServerFound.Spec.TLSSecurityProfile), nil
+}
+
+// TLSProfileFromSecurityProfile resolves the predefined profiles of a TLS security profile, or takes the
+// custom one, to its minimum TLS version and ciphers
+func TLSProfileFromSecurityProfile(profile *configv1.TLSSecurityProfile) kube.TLSProfile {
+	if profile == nil {
+		return kube.TLSProfile{}
+	}
+	spec := configv1.TLSProfiles[profile.Type]
+	if profile.Type == configv1.TLSProfileCustomType {
+		spec = nil
+		if profile.Custom != nil {
+			spec = &profile.Custom.TLSProfileSpec
+		}
+	}
+	if spec == nil {
+		return kube.TLSProfile{}
+	}
+	return kube.TLSProfile{
+		MinTLSVersion: string(spec.MinTLSVersion),
+		Ciphers:       append([]string{}, spec.Ciphers...),
+	}
+}
+
+// ClusterFIPS returns whether the cluster is installed in FIPS mode, as set in its install-config.
+// Clusters without an install-config are not in FIPS mode.
+func ClusterFIPS(ctx context.Context, c client.Reader) (bool, error) {
+	cmFound := &corev1.ConfigMap{}
+	err := c.Get(ctx, types.NamespacedName{Name: installConfigName, Namespace: installConfigNamespace}, cmFound)
+	if errors.IsNotFound(err) {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	installConfig := struct {
+		FIPS bool `json:"fips"`
+	}{}
+	err = yaml.Unmarshal([]byte(cmFound.Data["install-config"]), &installConfig)
+	if err != nil {
+		return false, fmt.Errorf("unable to parse the install-config: %w", err)
+	}
+	return installConfig.FIPS, nil
+}
+
+// PodLogTargets returns the workloads, and pods without one, selected by the podLogs inputs of the CR. Only
+// the metadata of the pods is read, which the operator watches instead of the whole pods.
+func PodLogTargets(ctx context.Context, c client.Reader, instance *sfv1alpha1.SplunkForwarder) ([]kube.PodLogTarget, error) {
+	targets := []kube.PodLogTarget{}
+	for _, input := range instance.Spec.PodLogs {
+		selector, err := PodLogsSelector(input)
+		if err != nil {
+			return nil, fmt.Errorf("invalid selector of podLogs input %s: %w", input.Name, err)
+		}
+		podList := &metav1.PartialObjectMetadataList{}
+		podList.SetGroupVersionKind(corev1.SchemeGroupVersion.WithKind("PodList"))
+		err = c.List(ctx, podList, client.InNamespace(input.Namespace), client.MatchingLabelsSelector{Selector: selector})
+		if err != nil {
+			return nil, err
+		}
+		pods := make([]metav1.ObjectMeta, 0, len(podList.Items))
+		for _, pod := range podList.Items {
+			pods = append(pods, pod.ObjectMeta)
+		}
+		targets = append(targets, kube.PodLogTargets(input, pods)...)
+	}
+	return targets, nil
+}
+
+// PodLogsSelector returns the label selector of a podLogs input; an input without one selects every pod
+// of its namespace
+func PodLogsSelector(input sfv1alpha1.SplunkPodLogsInput) (labels.Selector, error) {
+	if input.Selector == nil {
+		return labels.Everything(), nil
+	}
+	return metav1.LabelSelectorAsSelector(input.Selector)
+}
+
+// AuthHash returns the hash of the Secret the forwarders authenticate with, splunk-hec-token in HEC mode and
+// splunk-auth otherwise, or "" when the Secret is missing. The operator writes the key pair issued by
+// cert-manager to splunk-auth itself and hashes it with CertificateHash, so "" is returned when the CR
+// references an issuer.
+func AuthHash(ctx context.Context, c client.Reader, instance *sfv1alpha1.SplunkForwarder, useHECToken bool) (string, error) {
+	if instance.Spec.CertificateIssuerRef != nil {
+		return "", nil
+	}
+	name := config.SplunkAuthSecretName
+	if useHECToken {
+		name = config.SplunkHECTokenSecretName
+	}
+	authSecret := &corev1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{Name: name, Namespace: instance.Namespace}, authSecret)
+	if errors.IsNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return kube.DataHash(authSecret.Data), nil
+}
+
+// CertificateHash returns the hash of the key pair cert-manager issued for the CR, or "" when the CR does
+// not reference an issuer or the certificate is not issued yet
+func CertificateHash(ctx context.Context, c client.Reader, instance *sfv1alpha1.SplunkForwarder) (string, error) {
+	if instance.Spec.CertificateIssuerRef == nil {
+		return "", nil
+	}
+	tlsSecret := &corev1.Secret{}
+	err := c.Get(ctx, types.NamespacedName{Name: kube.CertificateSecretName(instance), Namespace: instance.Namespace}, tlsSecret)
+	if errors.IsNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	trustedCABundle, err := TrustedCABundle(ctx, c, instance)
+	if err != nil {
+		return "", err
+	}
+	authData, err := kube.GenerateSplunkAuthData(tlsSecret, trustedCABundle)
+	if err != nil {
+		return "", err
+	}
+	return kube.DataHash(authData), nil
+}
+
+// TrustedCABundle returns the trusted CA bundle injected for the CR, or "" when the CR does not trust it
+// or the bundle is not injected yet
+func TrustedCABundle(ctx context.Context, c client.Reader, instance *sfv1alpha1.SplunkForwarder) (string, error) {
+	if !kube.TrustedCABundleEnabled(instance) {
+		return "", nil
+	}
+	cmFound := &corev1.ConfigMap{}
+	err := c.Get(ctx, types.NamespacedName{Name: kube.TrustedCABundleName(instance), Namespace: instance.Namespace}, cmFound)
+	if errors.IsNotFound(err) {
+		return "", nil
+	} else if err != nil {
+		return "", err
+	}
+	return cmFound.Data[kube.TrustedCABundleKey], nil
+}
+
+// TrustedCABundleHash returns the hash of the trusted CA bundle injected for the CR, or "" when the
+// CR does not trust it or the bundle is not injected yet. The ConfigMap is owned by the CR, so an
+// injection rolls the forwarders.
+func TrustedCABundleHash(ctx context.Context, c client.Reader, instance *sfv1alpha1.SplunkForwarder) (string, error) {
+	bundle, err := TrustedCABundle(ctx, c, instance)
+	if bundle == "" || err != nil {
+		return "", err
+	}
+	return kube.DataHash(map[string][]byte{kube.TrustedCABundleKey: []byte(bundle)}), nil
+}
+
+// AppsHash returns the hash of the content of the ConfigMaps and Secrets of the extra apps, or ""
+// when the CR has none. A missing ConfigMap or Secret is hashed as empty: the pods wait for it to be
+// created, which then rolls them again.
+func AppsHash(ctx context.Context, c client.Reader, instance *sfv1alpha1.SplunkForwarder) (string, error) {
+	data := map[string]map[string][]byte{}
+	for _, app := range instance.Spec.Apps {
+		files := map[string][]byte{}
+		switch {
+		case app.ConfigMap != "":
+			cm := &corev1.ConfigMap{}
+			err := c.Get(ctx, types.NamespacedName{Name: app.ConfigMap, Namespace: instance.Namespace}, cm)
+			if err != nil && !errors.IsNotFound(err) {
+				return "", err
+			}
+			for key, value := range cm.Data {
+				files[key] = []byte(value)
+			}
+			for key, value := range cm.BinaryData {
+				files[key] = value
+			}
+		case app.Secret != "":
+			secret := &corev1.Secret{}
+			err := c.Get(ctx, types.NamespacedName{Name: app.Secret, Namespace: instance.Namespace}, secret)
+			if err != nil && !errors.IsNotFound(err) {
+				return "", err
+			}
+			files = secret.Data
+		default:
+			// The image reference is part of the pod template
+			continue
+		}
+		data[app.Name] = files
+	}
+	if len(data) == 0 {
+		return "", nil
+	}
+	return kube.AppsHash(data), nil
+}
+
+// ImageMirrorSources returns the source repositories of the ImageDigestMirrorSets and
+// ImageContentSourcePolicies of the cluster. Clusters without the resources have no mirrors.
+func ImageMirrorSources(ctx context.Context, c client.Reader) ([]string, error) {
+	sources := []string{}
+	idmsList := &configv1.ImageDigestMirrorSetList{}
+	err := c.List(ctx, idmsList)
+	if err != nil && !meta.IsNoMatchError(err) {
+		return nil, err
+	}
+	for _, idms := range idmsList.Items {
+		for _, mirrors := range idms.Spec.ImageDigestMirrors {
+			if len(mirrors.Mirrors) > 0 {
+				sources = append(sources, mirrors.Source)
+			}
+		}
+	}
+
+	icspList := &operatorv1alpha1.ImageContentSourcePolicyList{}
+	err = c.List(ctx, icspList)
+	if err != nil && !meta.IsNoMatchError(err) {
+		return nil, err
+	}
+	for _, icsp := range icspList.Items {
+		for _, mirrors := range icsp.Spec.RepositoryDigestMirrors {
+			if len(mirrors.Mirrors) > 0 {
+				sources = append(sources, mirrors.Source)
+			}
+		}
+	}
+	return sources, nil
+}
@@ -0,0 +1,84 @@
+package lookup
+
+import (
+	"context"
+	goerr "errors"
+	"reflect"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	"github.com/openshift/splunk-forwarder-operator/config"
+	"github.com/openshift/splunk-forwarder-operator/pkg/kube"
+)
+
+func TestClusterState(t *testing.T) {
+	s := runtime.NewScheme()
+	utilruntime.Must(clientgoscheme.AddToScheme(s))
+	utilruntime.Must(configv1.Install(s))
+	utilruntime.Must(sfv1alpha1.AddToScheme(s))
+
+	instance := &sfv1alpha1.SplunkForwarder{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "openshift-security"},
+	}
+	infrastructure := &configv1.Infrastructure{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Status: configv1.InfrastructureStatus{
+			InfrastructureName: "test-cluster",
+			PlatformStatus:     &configv1.PlatformStatus{Type: configv1.AWSPlatformType, AWS: &configv1.AWSPlatformStatus{Region: "us-east-1"}},
+		},
+	}
+	objs := []runtime.Object{
+		infrastructure,
+		&configv1.Proxy{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+			Status:     configv1.ProxyStatus{HTTPSProxy: "http://proxy:3128", NoProxy: ".cluster.local"},
+		},
+		&configv1.APIServer{
+			ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+			Spec: configv1.APIServerSpec{
+				TLSSecurityProfile: &configv1.TLSSecurityProfile{Type: configv1.TLSProfileModernType},
+			},
+		},
+		&corev1.ConfigMap{
+			ObjectMeta: metav1.ObjectMeta{Name: installConfigName, Namespace: installConfigNamespace},
+			Data:       map[string]string{"install-config": "fips: true\n"},
+		},
+		&corev1.Secret{
+			ObjectMeta: metav1.ObjectMeta{Name: config.SplunkHECTokenSecretName, Namespace: instance.Namespace},
+			Data:       map[string][]byte{"token": []byte("hec")},
+		},
+	}
+
+	c := fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(objs...).Build()
+	state, err := ClusterState(context.TODO(), c, instance)
+	if err != nil {
+		t.Fatalf("ClusterState() error = %v", err)
+	}
+	want := kube.ClusterState{
+		ClusterMetadata: kube.ClusterMetadata{ClusterID: "test-cluster", Platform: "AWS", Region: "us-east-1"},
+		PodLogs:         []kube.PodLogTarget{},
+		UseHECToken:     true,
+		AuthHash:        kube.DataHash(map[string][]byte{"token": []byte("hec")}),
+		Proxy:           kube.ClusterProxy{HTTPSProxy: "http://proxy:3128", NoProxy: ".cluster.local"},
+		FIPS:            true,
+		TLSProfile:      TLSProfileFromSecurityProfile(&configv1.TLSSecurityProfile{Type: configv1.TLSProfileModernType}),
+	}
+	if !reflect.DeepEqual(state, want) {
+		t.Errorf("ClusterState() = %+v, want %+v", state, want)
+	}
+
+	// Without the Infrastructure resource, the cluster ID is not known
+	c = fake.NewClientBuilder().WithScheme(s).WithRuntimeObjects(objs[1:]...).Build()
+	_, err = ClusterState(context.TODO(), c, instance)
+	if !goerr.Is(err, ErrInfrastructureUnavailable) {
+		t.Errorf("ClusterState() error = %v, want %v", err, ErrInfrastructureUnavailable)
+	}
+}
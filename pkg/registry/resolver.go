@@ -0,0 +1,236 @@
+// Package registry resolves image tags to manifest digests through the registry HTTP API (v2).
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const (
+	// dockerHub is the registry of images without a registry host
+	dockerHub = "docker.io"
+	// dockerHubAPI is the host serving the registry API of Docker Hub
+	dockerHubAPI = "registry-1.docker.io"
+	// requestTimeout bounds each request to a registry
+	requestTimeout = 30 * time.Second
+)
+
+// manifestTypes are the media types of the manifests a tag may point to. Manifest lists and indexes come
+// first, so that the digest is the same on every node architecture.
+var manifestTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+// digestPattern matches the manifest digests the resolver accepts
+var digestPattern = regexp.MustCompile(`^sha256:[a-f0-9]{64}$`)
+
+// challengePattern matches the parameters of a WWW-Authenticate challenge
+var challengePattern = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// Resolver resolves the tag of an image to the digest of its manifest
+type Resolver interface {
+	// Resolve returns the digest, such as sha256:..., of the manifest the tag of the repository points
+	// to. The credentials are used for the registries they have an entry for.
+	Resolve(ctx context.Context, repository, tag string, credentials Credentials) (string, error)
+}
+
+// Credentials are the registry credentials of pull secrets, by registry host
+type Credentials map[string]Auth
+
+// Auth is the user name and password of a registry
+type Auth struct {
+	Username string
+	Password string
+}
+
+// dockerConfig is the content of a kubernetes.io/dockerconfigjson Secret
+type dockerConfig struct {
+	Auths map[string]struct {
+		Auth     string `json:"auth"`
+		Username string `json:"username"`
+		Password string `json:"password"`
+	} `json:"auths"`
+}
+
+// AddDockerConfigJSON adds the credentials of the .dockerconfigjson of a pull secret. Entries already
+// present take precedence, like the first matching pull secret of a pod.
+func (c Credentials) AddDockerConfigJSON(data []byte) error {
+	config := dockerConfig{}
+	if err := json.Unmarshal(data, &config); err != nil {
+		return fmt.Errorf("unable to parse the docker config: %w", err)
+	}
+	for host, entry := range config.Auths {
+		host = registryHost(host)
+		if _, ok := c[host]; ok {
+			continue
+		}
+		auth := Auth{Username: entry.Username, Password: entry.Password}
+		if entry.Auth != "" {
+			decoded, err := base64.StdEncoding.DecodeString(entry.Auth)
+			if err != nil {
+				return fmt.Errorf("unable to decode the credentials of %s: %w", host, err)
+			}
+			auth.Username, auth.Password, _ = strings.Cut(string(decoded), ":")
+		}
+		c[host] = auth
+	}
+	return nil
+}
+
+// registryHost returns the registry host of a docker config entry, which may be a URL
+func registryHost(entry string) string {
+	entry = strings.TrimPrefix(strings.TrimPrefix(entry, "https://"), "http://")
+	host, _, _ := strings.Cut(entry, "/")
+	if host == "index.docker.io" {
+		return dockerHub
+	}
+	return host
+}
+
+// HTTPResolver resolves tags through the registry HTTP API, with anonymous or basic credentials
+// exchanged for a bearer token when the registry asks for one
+type HTTPResolver struct {
+	Client *http.Client
+}
+
+// NewResolver returns a resolver using the proxy of the environment
+func NewResolver() *HTTPResolver {
+	return &HTTPResolver{Client: &http.Client{Timeout: requestTimeout}}
+}
+
+// Resolve implements Resolver
+func (r *HTTPResolver) Resolve(ctx context.Context, repository, tag string, credentials Credentials) (string, error) {
+	host, path := splitRepository(repository)
+	auth, hasAuth := credentials[host]
+	apiHost := host
+	if host == dockerHub {
+		apiHost = dockerHubAPI
+	}
+	manifestURL := "https://" + apiHost + "/v2/" + path + "/manifests/" + url.PathEscape(tag)
+
+	resp, err := r.headManifest(ctx, manifestURL, "")
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		scheme, params := parseChallenge(resp.Header.Get("WWW-Authenticate"))
+		authorization := ""
+		switch {
+		case strings.EqualFold(scheme, "bearer"):
+			token, err := r.token(ctx, params, path, auth, hasAuth)
+			if err != nil {
+				return "", err
+			}
+			authorization = "Bearer " + token
+		case strings.EqualFold(scheme, "basic") && hasAuth:
+			authorization = "Basic " + base64.StdEncoding.EncodeToString([]byte(auth.Username+":"+auth.Password))
+		default:
+			return "", fmt.Errorf("%s:%s: unauthorized", repository, tag)
+		}
+		resp, err = r.headManifest(ctx, manifestURL, authorization)
+		if err != nil {
+			return "", err
+		}
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%s:%s: unexpected registry response %s", repository, tag, resp.Status)
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if !digestPattern.MatchString(digest) {
+		return "", fmt.Errorf("%s:%s: registry returned the invalid digest %q", repository, tag, digest)
+	}
+	return digest, nil
+}
+
+// headManifest requests the headers of a manifest. The body of a HEAD response is empty.
+func (r *HTTPResolver) headManifest(ctx context.Context, manifestURL, authorization string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, manifestURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", strings.Join(manifestTypes, ", "))
+	if authorization != "" {
+		req.Header.Set("Authorization", authorization)
+	}
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body.Close()
+	return resp, nil
+}
+
+// token requests a pull token from the token service of a bearer challenge
+func (r *HTTPResolver) token(ctx context.Context, params map[string]string, path string, auth Auth, hasAuth bool) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Scheme != "https" {
+		return "", fmt.Errorf("invalid token realm %q", params["realm"])
+	}
+	query := realm.Query()
+	if params["service"] != "" {
+		query.Set("service", params["service"])
+	}
+	query.Set("scope", "repository:"+path+":pull")
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if hasAuth {
+		req.SetBasicAuth(auth.Username, auth.Password)
+	}
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token request to %s failed: %s", realm.Host, resp.Status)
+	}
+	body := struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}{}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("unable to parse the token response of %s: %w", realm.Host, err)
+	}
+	if body.Token != "" {
+		return body.Token, nil
+	}
+	return body.AccessToken, nil
+}
+
+// splitRepository returns the registry host and the path of a repository. Repositories without a
+// registry host are on Docker Hub, where official images are under library/.
+func splitRepository(repository string) (string, string) {
+	first, rest, found := strings.Cut(repository, "/")
+	if found && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		return first, rest
+	}
+	if !found {
+		return dockerHub, "library/" + repository
+	}
+	return dockerHub, repository
+}
+
+// parseChallenge returns the scheme and the parameters of a WWW-Authenticate header
+func parseChallenge(header string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	params := map[string]string{}
+	for _, match := range challengePattern.FindAllStringSubmatch(rest, -1) {
+		params[strings.ToLower(match[1])] = match[2]
+	}
+	return scheme, params
+}
@@ -0,0 +1,103 @@
+package registry
+
+import (
+	"context"
+	"encoding/base64"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testDigest = "sha256:2452a3f01e840661ee1194777ed5a9185ceaaa9ec7329ed364fa2f02be22a701"
+
+func TestSplitRepository(t *testing.T) {
+	for _, tt := range []struct {
+		repository, host, path string
+	}{
+		{"quay.io/app-sre/splunk-forwarder", "quay.io", "app-sre/splunk-forwarder"},
+		{"localhost:5000/forwarder", "localhost:5000", "forwarder"},
+		{"splunk/universalforwarder", dockerHub, "splunk/universalforwarder"},
+		{"busybox", dockerHub, "library/busybox"},
+	} {
+		host, path := splitRepository(tt.repository)
+		if host != tt.host || path != tt.path {
+			t.Errorf("splitRepository(%q) = %q, %q, want %q, %q", tt.repository, host, path, tt.host, tt.path)
+		}
+	}
+}
+
+func TestAddDockerConfigJSON(t *testing.T) {
+	credentials := Credentials{"quay.io": {Username: "first", Password: "secret"}}
+	auth := base64.StdEncoding.EncodeToString([]byte("robot:token"))
+	err := credentials.AddDockerConfigJSON([]byte(`{"auths": {
+		"quay.io": {"auth": "` + auth + `"},
+		"https://index.docker.io/v1/": {"username": "user", "password": "pass"}
+	}}`))
+	if err != nil {
+		t.Fatalf("AddDockerConfigJSON() error = %v", err)
+	}
+	want := Credentials{
+		"quay.io":   {Username: "first", Password: "secret"},
+		"docker.io": {Username: "user", Password: "pass"},
+	}
+	if len(credentials) != len(want) {
+		t.Fatalf("credentials = %v, want %v", credentials, want)
+	}
+	for host, auth := range want {
+		if credentials[host] != auth {
+			t.Errorf("credentials[%q] = %v, want %v", host, credentials[host], auth)
+		}
+	}
+	if err := credentials.AddDockerConfigJSON([]byte("{")); err == nil {
+		t.Errorf("AddDockerConfigJSON() accepted an invalid docker config")
+	}
+}
+
+func TestHTTPResolver(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/token":
+			user, password, ok := r.BasicAuth()
+			if !ok || user != "robot" || password != "token" || r.URL.Query().Get("scope") != "repository:app-sre/forwarder:pull" {
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			_, _ = w.Write([]byte(`{"token": "pull-token"}`))
+		case "/v2/app-sre/forwarder/manifests/1.0.0":
+			if r.Method != http.MethodHead || !strings.Contains(r.Header.Get("Accept"), "manifest.list") {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			if r.Header.Get("Authorization") != "Bearer pull-token" {
+				w.Header().Set("WWW-Authenticate", `Bearer realm="`+server.URL+`/token",service="registry"`)
+				w.WriteHeader(http.StatusUnauthorized)
+				return
+			}
+			w.Header().Set("Docker-Content-Digest", testDigest)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	host := strings.TrimPrefix(server.URL, "https://")
+	resolver := &HTTPResolver{Client: server.Client()}
+	credentials := Credentials{host: {Username: "robot", Password: "token"}}
+
+	digest, err := resolver.Resolve(context.TODO(), host+"/app-sre/forwarder", "1.0.0", credentials)
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if digest != testDigest {
+		t.Errorf("Resolve() = %q, want %q", digest, testDigest)
+	}
+
+	if _, err := resolver.Resolve(context.TODO(), host+"/app-sre/forwarder", "1.0.0", nil); err == nil {
+		t.Errorf("Resolve() succeeded without the credentials")
+	}
+	if _, err := resolver.Resolve(context.TODO(), host+"/app-sre/forwarder", "2.0.0", credentials); err == nil {
+		t.Errorf("Resolve() succeeded for a missing tag")
+	}
+}
@@ -0,0 +1,152 @@
+package reload
+
+import (
+	"context"
+	"crypto/tls"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+)
+
+const (
+	// DefaultDir is where the inputs ConfigMap of the forwarder is mounted in the sidecar
+	DefaultDir = "/tmp/osd-monitored-logs-local"
+	// DefaultPasswordFile is where the admin password of splunkd is mounted in the sidecar
+	DefaultPasswordFile = "/etc/splunk-admin/password"
+	// DefaultURL is the endpoint of the management port that reloads the monitor inputs. The management port
+	// only listens on the loopback interface of the pod.
+	DefaultURL = "https://127.0.0.1:8089/services/data/inputs/monitor/_reload"
+)
+
+// Reloader reloads the monitor inputs of splunkd when the inputs ConfigMap changes
+type Reloader struct {
+	// Dir is the mounted ConfigMap. The kubelet swaps its ..data symlink when the ConfigMap changes.
+	Dir string
+	// PasswordFile holds the password of the splunkd admin user
+	PasswordFile string
+	// URL is the reload endpoint of the management port
+	URL string
+	// Client sends the reload requests
+	Client *http.Client
+}
+
+// revision returns the target of the ..data symlink of the mounted ConfigMap
+func (r *Reloader) revision() (string, error) {
+	return os.Readlink(filepath.Join(r.Dir, "..data"))
+}
+
+// Reload asks splunkd to reload the monitor inputs
+func (r *Reloader) Reload(ctx context.Context) error {
+	password, err := os.ReadFile(r.PasswordFile)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.URL, nil)
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth("admin", strings.TrimSpace(string(password)))
+	resp, err := r.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, _ = io.Copy(io.Discard, resp.Body)
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("reload returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Watch checks the mounted ConfigMap every interval, and reloads the monitor inputs when it changed. A failed
+// reload is tried again at the next check. It returns when the context is done.
+func (r *Reloader) Watch(ctx context.Context, interval time.Duration, stdout io.Writer) error {
+	last, err := r.revision()
+	if err != nil {
+		return err
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		current, err := r.revision()
+		if err != nil {
+			fmt.Fprintln(stdout, err)
+			continue
+		}
+		if current == last {
+			continue
+		}
+		fmt.Fprintln(stdout, "Inputs changed, reloading")
+		if err := r.Reload(ctx); err != nil {
+			fmt.Fprintln(stdout, err)
+			continue
+		}
+		last = current
+	}
+}
+
+// NewClient returns the client sending the reload requests to endpoint. splunkd serves the management port
+// with a certificate of its own, which is only trusted on the loopback interface of the pod.
+func NewClient(endpoint string) (*http.Client, error) {
+	parsed, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, err
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if ip := net.ParseIP(parsed.Hostname()); ip != nil && ip.IsLoopback() {
+		// The connection does not leave the pod
+		transport.TLSClientConfig = &tls.Config{InsecureSkipVerify: true}
+	}
+	return &http.Client{Timeout: 30 * time.Second, Transport: transport}, nil
+}
+
+// Run implements the reload subcommand. It returns the exit code of the command.
+func Run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("reload", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: reload [flags]\n\nReloads the monitor inputs of the forwarder when its inputs ConfigMap changes.\n\n")
+		fs.PrintDefaults()
+	}
+	reloader := &Reloader{}
+	var interval time.Duration
+	fs.StringVar(&reloader.Dir, "dir", DefaultDir, "Directory the inputs ConfigMap is mounted in")
+	fs.StringVar(&reloader.PasswordFile, "password-file", DefaultPasswordFile, "File holding the password of the splunkd admin user")
+	fs.StringVar(&reloader.URL, "url", DefaultURL, "Reload endpoint of the splunkd management port")
+	fs.DurationVar(&interval, "interval", 10*time.Second, "Interval at which the ConfigMap is checked")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+	client, err := NewClient(reloader.URL)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 2
+	}
+	reloader.Client = client
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := reloader.Watch(ctx, interval, stdout); err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,112 @@
+package reload
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// swapData points the ..data symlink of a mounted ConfigMap at a new directory, as the kubelet does
+func swapData(t *testing.T, dir, target string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, target), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	tmp := filepath.Join(dir, "..data_tmp")
+	if err := os.Symlink(target, tmp); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, filepath.Join(dir, "..data")); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestReloader(t *testing.T) {
+	var reloads atomic.Int32
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if user, password, ok := req.BasicAuth(); req.Method != http.MethodPost || !ok || user != "admin" || password != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		reloads.Add(1)
+	}))
+	defer server.Close()
+
+	dir := t.TempDir()
+	swapData(t, dir, "..2024_01_01_00_00_00.1")
+	passwordFile := filepath.Join(t.TempDir(), "password")
+	if err := os.WriteFile(passwordFile, []byte("secret"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	// The management port serves a certificate of splunkd, trusted on the loopback interface
+	client, err := NewClient(server.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloader := &Reloader{Dir: dir, PasswordFile: passwordFile, URL: server.URL, Client: client}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error)
+	go func() { done <- reloader.Watch(ctx, 10*time.Millisecond, io.Discard) }()
+
+	// Nothing is reloaded until the ConfigMap changes
+	time.Sleep(50 * time.Millisecond)
+	if got := reloads.Load(); got != 0 {
+		t.Errorf("reloads = %d before a change, want 0", got)
+	}
+
+	swapData(t, dir, "..2024_01_01_00_00_00.2")
+	for i := 0; i < 50 && reloads.Load() == 0; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	time.Sleep(50 * time.Millisecond)
+	if got := reloads.Load(); got != 1 {
+		t.Errorf("reloads = %d after a change, want 1", got)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Watch() error = %v", err)
+	}
+
+	// A rejected reload is an error
+	reloader.PasswordFile = filepath.Join(t.TempDir(), "missing")
+	if err := reloader.Reload(context.Background()); err == nil {
+		t.Error("Reload() without a password file did not fail")
+	}
+	if err := os.WriteFile(passwordFile, []byte("wrong"), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	reloader.PasswordFile = passwordFile
+	if err := reloader.Reload(context.Background()); err == nil {
+		t.Error("Reload() with a wrong password did not fail")
+	}
+}
+
+func TestNewClient(t *testing.T) {
+	tests := []struct {
+		url          string
+		wantInsecure bool
+	}{
+		{url: DefaultURL, wantInsecure: true},
+		{url: "https://[::1]:8089/services/data/inputs/monitor/_reload", wantInsecure: true},
+		{url: "https://splunk.example.com:8089/services/data/inputs/monitor/_reload"},
+		{url: "https://10.0.0.1:8089/services/data/inputs/monitor/_reload"},
+	}
+	for _, tt := range tests {
+		client, err := NewClient(tt.url)
+		if err != nil {
+			t.Fatalf("NewClient(%q) error = %v", tt.url, err)
+		}
+		tlsConfig := client.Transport.(*http.Transport).TLSClientConfig
+		if insecure := tlsConfig != nil && tlsConfig.InsecureSkipVerify; insecure != tt.wantInsecure {
+			t.Errorf("NewClient(%q) skips the verification = %v, want %v", tt.url, insecure, tt.wantInsecure)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+package render
+
+import (
+	"strings"
+)
+
+// confStanza is a stanza of a Splunk .conf file with its settings in file order
+type confStanza struct {
+	name   string
+	keys   []string
+	values map[string]string
+}
+
+// parseConf splits a .conf file into its stanzas. Settings before the first stanza header are put in
+// a stanza named "default", like Splunk does; comments and blank lines are ignored.
+func parseConf(data string) []*confStanza {
+	stanzas := []*confStanza{}
+	byName := map[string]*confStanza{}
+	current := (*confStanza)(nil)
+	use := func(name string) {
+		if stanza, ok := byName[name]; ok {
+			current = stanza
+			return
+		}
+		current = &confStanza{name: name, values: map[string]string{}}
+		byName[name] = current
+		stanzas = append(stanzas, current)
+	}
+
+	for _, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			use(strings.TrimSpace(line[1 : len(line)-1]))
+			continue
+		}
+		if current == nil {
+			use("default")
+		}
+		key, value, _ := strings.Cut(line, "=")
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		if _, ok := current.values[key]; !ok {
+			current.keys = append(current.keys, key)
+		}
+		current.values[key] = value
+	}
+	return stanzas
+}
+
+// diffConf compares two versions of a .conf file stanza by stanza. Added and removed stanzas are listed
+// with all their settings, changed stanzas only with the settings that differ.
+func diffConf(live, proposed string) []string {
+	ret := []string{}
+	liveStanzas := map[string]*confStanza{}
+	for _, stanza := range parseConf(live) {
+		liveStanzas[stanza.name] = stanza
+	}
+	seen := map[string]bool{}
+
+	for _, stanza := range parseConf(proposed) {
+		seen[stanza.name] = true
+		old, ok := liveStanzas[stanza.name]
+		if !ok {
+			ret = append(ret, "+ ["+stanza.name+"]")
+			for _, key := range stanza.keys {
+				ret = append(ret, "+   "+key+" = "+stanza.values[key])
+			}
+			continue
+		}
+		changes := []string{}
+		for _, key := range stanza.keys {
+			oldValue, ok := old.values[key]
+			if !ok {
+				changes = append(changes, "+   "+key+" = "+stanza.values[key])
+			} else if oldValue != stanza.values[key] {
+				changes = append(changes, "~   "+key+": "+oldValue+" -> "+stanza.values[key])
+			}
+		}
+		for _, key := range old.keys {
+			if _, ok := stanza.values[key]; !ok {
+				changes = append(changes, "-   "+key+" = "+old.values[key])
+			}
+		}
+		if len(changes) > 0 {
+			ret = append(ret, "~ ["+stanza.name+"]")
+			ret = append(ret, changes...)
+		}
+	}
+
+	for _, stanza := range parseConf(live) {
+		if seen[stanza.name] {
+			continue
+		}
+		ret = append(ret, "- ["+stanza.name+"]")
+		for _, key := range stanza.keys {
+			ret = append(ret, "-   "+key+" = "+stanza.values[key])
+		}
+	}
+	return ret
+}
@@ -0,0 +1,331 @@
+package render
+
+import (
+	"context"
+	"encoding/json"
+	goerr "errors"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	ctrlconfig "sigs.k8s.io/controller-runtime/pkg/client/config"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	"github.com/openshift/splunk-forwarder-operator/config"
+	"github.com/openshift/splunk-forwarder-operator/pkg/kube"
+	"github.com/openshift/splunk-forwarder-operator/pkg/lookup"
+)
+
+// authMode describes how the forwarders of a CR authenticate against Splunk
+func authMode(instance *sfv1alpha1.SplunkForwarder, useHECToken bool) string {
+	switch {
+	case useHECToken:
+		return "HEC token"
+	case instance.Spec.CertificateIssuerRef != nil:
+		return "mTLS with a cert-manager certificate"
+	}
+	return "mTLS"
+}
+
+// flattenFields flattens a JSON value into its leaf field paths. List items are named by their "name"
+// or "key" field when they have one, so that reordering containers or volumes does not show up as a change.
+func flattenFields(path string, value interface{}, fields map[string]string) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, item := range v {
+			flattenFields(strings.TrimPrefix(path+"."+key, "."), item, fields)
+		}
+	case []interface{}:
+		for i, item := range v {
+			index := fmt.Sprint(i)
+			if m, ok := item.(map[string]interface{}); ok {
+				if name, ok := m["name"].(string); ok {
+					index = name
+				} else if key, ok := m["key"].(string); ok {
+					index = key
+				}
+			}
+			flattenFields(path+"["+index+"]", item, fields)
+		}
+	default:
+		data, _ := json.Marshal(v)
+		fields[path] = string(data)
+	}
+}
+
+// templateFields returns the leaf fields of a pod template
+func templateFields(template *corev1.PodTemplateSpec) map[string]string {
+	fields := map[string]string{}
+	data, err := json.Marshal(template)
+	if err != nil {
+		// A PodTemplateSpec always marshals
+		panic(err)
+	}
+	var value interface{}
+	if err := json.Unmarshal(data, &value); err != nil {
+		panic(err)
+	}
+	flattenFields("", value, fields)
+	return fields
+}
+
+// diffTemplate compares the pod template of the live DaemonSet with the proposed one. Only the fields the
+// operator generates, for the live or the proposed CR, are compared, so the defaults the API server fills
+// in are not reported.
+func diffTemplate(live, generatedLive, proposed *corev1.PodTemplateSpec) []string {
+	liveFields := templateFields(live)
+	proposedFields := templateFields(proposed)
+	paths := map[string]bool{}
+	for path := range templateFields(generatedLive) {
+		paths[path] = true
+	}
+	for path := range proposedFields {
+		paths[path] = true
+	}
+	sorted := make([]string, 0, len(paths))
+	for path := range paths {
+		sorted = append(sorted, path)
+	}
+	sort.Strings(sorted)
+
+	ret := []string{}
+	for _, path := range sorted {
+		oldValue, inLive := liveFields[path]
+		newValue, inProposed := proposedFields[path]
+		switch {
+		case inLive && !inProposed:
+			ret = append(ret, "- "+path+": "+oldValue)
+		case !inLive && inProposed:
+			ret = append(ret, "+ "+path+": "+newValue)
+		case oldValue != newValue:
+			ret = append(ret, "~ "+path+": "+oldValue+" -> "+newValue)
+		}
+	}
+	return ret
+}
+
+// diffConfigMap compares the .conf files of a live and a proposed ConfigMap
+func diffConfigMap(live, proposed map[string]string) []string {
+	files := map[string]bool{}
+	for file := range live {
+		files[file] = true
+	}
+	for file := range proposed {
+		files[file] = true
+	}
+	sorted := make([]string, 0, len(files))
+	for file := range files {
+		sorted = append(sorted, file)
+	}
+	sort.Strings(sorted)
+
+	ret := []string{}
+	for _, file := range sorted {
+		changes := diffConf(live[file], proposed[file])
+		if len(changes) == 0 {
+			continue
+		}
+		ret = append(ret, "  "+file)
+		for _, change := range changes {
+			ret = append(ret, "    "+change)
+		}
+	}
+	return ret
+}
+
+// operatorImage returns the image the config-reload sidecar of the live forwarders runs, which is the image
+// of the operator that applied them, or the name of the operator image when there is no live forwarder
+func operatorImage(ctx context.Context, c client.Reader, live *sfv1alpha1.SplunkForwarder) (string, error) {
+	ds := &appsv1.DaemonSet{}
+	err := c.Get(ctx, types.NamespacedName{Name: live.Name + "-ds", Namespace: live.Namespace}, ds)
+	if errors.IsNotFound(err) {
+		return config.OperatorName, nil
+	} else if err != nil {
+		return "", err
+	}
+	for _, container := range ds.Spec.Template.Spec.Containers {
+		if container.Name == "config-reload" {
+			return container.Image, nil
+		}
+	}
+	return config.OperatorName, nil
+}
+
+// Diff compares the ConfigMaps and DaemonSets generated for the proposed CR with the live ones of the CR
+// it replaces. It reports the changed .conf stanzas, whether the DaemonSets roll their pods, and the
+// authentication mode. The objects are built from the cluster state the operator looks up.
+// In FIPS mode, a configuration the operator would refuse for its non-FIPS ciphers is an error, and so is
+// an invalid image. Image tags are pinned to the digest recorded in the status of the live CR.
+func Diff(ctx context.Context, c client.Reader, proposed *sfv1alpha1.SplunkForwarder) ([]string, error) {
+	live := &sfv1alpha1.SplunkForwarder{}
+	err := c.Get(ctx, types.NamespacedName{Name: proposed.Name, Namespace: proposed.Namespace}, live)
+	if err != nil {
+		return nil, fmt.Errorf("unable to get the live SplunkForwarder: %w", err)
+	}
+	if err := kube.ValidateImage(proposed); err != nil {
+		return nil, fmt.Errorf("the operator does not roll out an invalid image: %w", err)
+	}
+	// The forwarders run the digest the image tag was resolved to, until the tag changes
+	kube.PinImageDigest(live, live.Status)
+	kube.PinImageDigest(proposed, live.Status)
+
+	// Like the operator, render without the cluster version fields when there is no ClusterVersion
+	state, err := lookup.ClusterState(ctx, c, proposed)
+	if err != nil && !goerr.Is(err, lookup.ErrClusterVersionUnavailable) {
+		return nil, err
+	}
+	image, err := operatorImage(ctx, c, live)
+	if err != nil {
+		return nil, err
+	}
+	ret := []string{}
+
+	// ConfigMaps
+	generated := map[string]bool{}
+	configMaps := kube.BuildConfigMaps(proposed, state)
+	if kube.FIPSEnabled(proposed, state.FIPS) {
+		authSecret := &corev1.Secret{}
+		err := c.Get(ctx, types.NamespacedName{Name: config.SplunkAuthSecretName, Namespace: proposed.Namespace}, authSecret)
+		if err != nil {
+			return nil, err
+		}
+		if err := kube.CheckFIPSConfigs(configMaps, authSecret); err != nil {
+			return nil, fmt.Errorf("the operator does not roll out non-FIPS ciphers in FIPS mode: %w", err)
+		}
+	}
+	for _, cm := range configMaps {
+		generated[cm.Name] = true
+		cmFound := &corev1.ConfigMap{}
+		err := c.Get(ctx, types.NamespacedName{Name: cm.Name, Namespace: cm.Namespace}, cmFound)
+		if errors.IsNotFound(err) {
+			ret = append(ret, "ConfigMap "+cm.Name+": created")
+			ret = append(ret, diffConfigMap(nil, cm.Data)...)
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		if changes := diffConfigMap(cmFound.Data, cm.Data); len(changes) > 0 {
+			ret = append(ret, "ConfigMap "+cm.Name+": updated")
+			ret = append(ret, changes...)
+		}
+	}
+	cmList := &corev1.ConfigMapList{}
+	if err := c.List(ctx, cmList, client.InNamespace(proposed.Namespace), client.HasLabels{kube.NodeRoleLabel}); err != nil {
+		return nil, err
+	}
+	for _, cm := range cmList.Items {
+		if !generated[cm.Name] && cm.Labels[kube.CanaryLabel] == "" {
+			ret = append(ret, "ConfigMap "+cm.Name+": deleted")
+		}
+	}
+
+	// DaemonSets
+	liveUsesHECToken := false
+	generatedLive := map[string]*appsv1.DaemonSet{}
+	for _, ds := range kube.BuildDaemonSets(live, state, image) {
+		generatedLive[ds.Name] = ds
+	}
+	generated = map[string]bool{}
+	for _, ds := range kube.BuildDaemonSets(proposed, state, image) {
+		generated[ds.Name] = true
+		dsFound := &appsv1.DaemonSet{}
+		err := c.Get(ctx, types.NamespacedName{Name: ds.Name, Namespace: ds.Namespace}, dsFound)
+		if errors.IsNotFound(err) {
+			ret = append(ret, "DaemonSet "+ds.Name+": created")
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		for _, volume := range dsFound.Spec.Template.Spec.Volumes {
+			if volume.Name == config.SplunkHECTokenSecretName {
+				liveUsesHECToken = true
+			}
+		}
+		if dsFound.Annotations[kube.TemplateHashAnnotation] == ds.Annotations[kube.TemplateHashAnnotation] {
+			continue
+		}
+		ret = append(ret, "DaemonSet "+ds.Name+": pod template changed, the pods are rolled")
+		baseline, ok := generatedLive[ds.Name]
+		if !ok {
+			baseline = ds
+		}
+		for _, change := range diffTemplate(&dsFound.Spec.Template, &baseline.Spec.Template, &ds.Spec.Template) {
+			ret = append(ret, "  "+change)
+		}
+	}
+	dsList := &appsv1.DaemonSetList{}
+	if err := c.List(ctx, dsList, client.InNamespace(proposed.Namespace), client.HasLabels{kube.NodeRoleLabel}); err != nil {
+		return nil, err
+	}
+	for _, ds := range dsList.Items {
+		if !generated[ds.Name] && ds.Labels[kube.CanaryLabel] == "" {
+			ret = append(ret, "DaemonSet "+ds.Name+": deleted")
+		}
+	}
+
+	// The HEC token is used as soon as its Secret exists, so the live mode is taken from the DaemonSets
+	if oldMode, newMode := authMode(live, liveUsesHECToken), authMode(proposed, state.UseHECToken); oldMode != newMode {
+		ret = append(ret, "Auth mode: "+oldMode+" -> "+newMode)
+	}
+	if len(ret) > 0 && kube.CanaryEnabled(proposed) {
+		ret = append(ret, "Rollout: canary nodes first, then every node once the canary forwarders stayed ready")
+	}
+	return ret, nil
+}
+
+// RunDiff implements the diff subcommand. It returns the exit code of the command.
+func RunDiff(args []string, stdout, stderr io.Writer, newClient func() (client.Client, error)) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: %s diff -f <cr.yaml> [flags]\n\nPrints what applying a SplunkForwarder CR changes in the live objects of the cluster in $KUBECONFIG.\n\n", config.OperatorName)
+		fs.PrintDefaults()
+	}
+	var file, namespace string
+	fs.StringVar(&file, "f", "", "Proposed SplunkForwarder CR, v1alpha1 or v1beta1 (\"-\" for stdin)")
+	fs.StringVar(&namespace, "namespace", config.OperatorNamespace, "Namespace of the CR, when it does not set one")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if file == "" || fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+
+	instance, err := readCR(file, namespace)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	c, err := newClient()
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	changes, err := Diff(context.TODO(), c, instance)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if len(changes) == 0 {
+		changes = []string{"No changes"}
+	}
+	fmt.Fprintln(stdout, strings.Join(changes, "\n"))
+	return 0
+}
+
+// NewClient returns a client for the cluster in $KUBECONFIG, or the in-cluster config
+func NewClient() (client.Client, error) {
+	cfg, err := ctrlconfig.GetConfig()
+	if err != nil {
+		return nil, err
+	}
+	return client.New(cfg, client.Options{Scheme: scheme})
+}
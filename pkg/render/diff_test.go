@@ -0,0 +1,217 @@
+package render
+
+import (
+	"bytes"
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+
+	configv1 "github.com/openshift/api/config/v1"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	fakekubeclient "sigs.k8s.io/controller-runtime/pkg/client/fake"
+
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	"github.com/openshift/splunk-forwarder-operator/config"
+	"github.com/openshift/splunk-forwarder-operator/pkg/kube"
+)
+
+func TestDiffConf(t *testing.T) {
+	live := `
+[monitor:///var/log/audit.log]
+sourcetype = _json
+index = main
+disabled = false
+
+[monitor:///var/log/removed.log]
+index = main
+`
+	proposed := `
+[monitor:///var/log/audit.log]
+sourcetype = linux_audit
+index = main
+whitelist = \.log$
+
+[monitor:///var/log/added.log]
+index = main
+`
+	want := []string{
+		"~ [monitor:///var/log/audit.log]",
+		"~   sourcetype: _json -> linux_audit",
+		"+   whitelist = \\.log$",
+		"-   disabled = false",
+		"+ [monitor:///var/log/added.log]",
+		"+   index = main",
+		"- [monitor:///var/log/removed.log]",
+		"-   index = main",
+	}
+	if got := diffConf(live, proposed); !reflect.DeepEqual(got, want) {
+		t.Errorf("diffConf() = %q, want %q", got, want)
+	}
+	if got := diffConf(live, live); len(got) != 0 {
+		t.Errorf("diffConf() of the same file = %q, want no changes", got)
+	}
+}
+
+// liveCluster returns a fake client with the objects the operator generates for the CR
+func liveCluster(t *testing.T, live *sfv1alpha1.SplunkForwarder, objects ...client.Object) client.Client {
+	t.Helper()
+	objects = append(objects, live, &configv1.Infrastructure{
+		ObjectMeta: metav1.ObjectMeta{Name: "cluster"},
+		Status:     configv1.InfrastructureStatus{InfrastructureName: "test-abc12"},
+	})
+	state := kube.ClusterState{ClusterMetadata: kube.ClusterMetadata{ClusterID: "test-abc12"}}
+	for _, cm := range kube.BuildConfigMaps(live, state) {
+		objects = append(objects, cm)
+	}
+	for _, ds := range kube.BuildDaemonSets(live, state, config.OperatorName) {
+		objects = append(objects, ds)
+	}
+	return fakekubeclient.NewClientBuilder().WithScheme(scheme).WithObjects(objects...).Build()
+}
+
+func testLiveCR() *sfv1alpha1.SplunkForwarder {
+	return &sfv1alpha1.SplunkForwarder{
+		ObjectMeta: metav1.ObjectMeta{Name: "test", Namespace: "openshift-test", Generation: 3},
+		Spec: sfv1alpha1.SplunkForwarderSpec{
+			Image:       "test-image",
+			ImageDigest: "sha256:2452a3f01e840661ee1194777ed5a9185ceaaa9ec7329ed364fa2f02be22a701",
+			SplunkInputs: []sfv1alpha1.SplunkForwarderInputs{
+				{Path: "/host/var/log/openshift-apiserver/audit.log", Index: "openshift_managed_audit"},
+				{Path: "/host/var/log/containers", Index: "openshift_managed_debug_node", NodeRoles: []string{"infra"}},
+			},
+		},
+	}
+}
+
+func TestDiff(t *testing.T) {
+	tests := []struct {
+		name    string
+		objects []client.Object
+		update  func(*sfv1alpha1.SplunkForwarder)
+		want    []string
+	}{
+		{
+			name:   "No changes",
+			update: func(*sfv1alpha1.SplunkForwarder) {},
+			want:   []string{},
+		},
+		{
+			name: "Inputs change",
+			update: func(instance *sfv1alpha1.SplunkForwarder) {
+				instance.Spec.SplunkInputs[0].Index = "openshift_audit"
+			},
+			want: []string{
+				"ConfigMap osd-monitored-logs-local: updated",
+				"  inputs.conf",
+				"    ~ [monitor:///host/var/log/openshift-apiserver/audit.log]",
+				"    ~   index: openshift_managed_audit -> openshift_audit",
+				"ConfigMap osd-monitored-logs-local-infra: updated",
+				"  inputs.conf",
+				"    ~ [monitor:///host/var/log/openshift-apiserver/audit.log]",
+				"    ~   index: openshift_managed_audit -> openshift_audit",
+			},
+		},
+		{
+			name: "Image change and removed node role",
+			update: func(instance *sfv1alpha1.SplunkForwarder) {
+				instance.Spec.ImageDigest = "sha256:74b6a7e80da95b5bde5d7aade76d306b383430fc9a73f0b650ffaddf87b9a784"
+				instance.Spec.SplunkInputs = instance.Spec.SplunkInputs[:1]
+			},
+			want: []string{
+				"ConfigMap osd-monitored-logs-local-infra: deleted",
+				"DaemonSet test-ds: pod template changed, the pods are rolled",
+				"  - spec.affinity.nodeAffinity.requiredDuringSchedulingIgnoredDuringExecution.nodeSelectorTerms[0].matchExpressions[node-role.kubernetes.io/infra].key: \"node-role.kubernetes.io/infra\"",
+				"  - spec.affinity.nodeAffinity.requiredDuringSchedulingIgnoredDuringExecution.nodeSelectorTerms[0].matchExpressions[node-role.kubernetes.io/infra].operator: \"DoesNotExist\"",
+				"  ~ spec.containers[splunk-uf].image: \"test-image@sha256:2452a3f01e840661ee1194777ed5a9185ceaaa9ec7329ed364fa2f02be22a701\" -> \"test-image@sha256:74b6a7e80da95b5bde5d7aade76d306b383430fc9a73f0b650ffaddf87b9a784\"",
+				"DaemonSet test-ds-infra: deleted",
+			},
+		},
+		{
+			name: "Auth mode",
+			update: func(instance *sfv1alpha1.SplunkForwarder) {
+				instance.Spec.CertificateIssuerRef = &sfv1alpha1.CertificateIssuerReference{Name: "splunk-ca"}
+			},
+			want: []string{"Auth mode: mTLS -> mTLS with a cert-manager certificate"},
+		},
+		{
+			name: "HEC token Secret added",
+			objects: []client.Object{
+				&corev1.Secret{ObjectMeta: metav1.ObjectMeta{Name: config.SplunkHECTokenSecretName, Namespace: "openshift-test"}},
+			},
+			update: func(*sfv1alpha1.SplunkForwarder) {},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := liveCluster(t, testLiveCR(), tt.objects...)
+			proposed := testLiveCR()
+			tt.update(proposed)
+			got, err := Diff(context.TODO(), c, proposed)
+			if err != nil {
+				t.Fatalf("Diff() error = %v", err)
+			}
+			if tt.want == nil {
+				// Only check the summary of long diffs
+				if len(got) == 0 || got[len(got)-1] != "Auth mode: mTLS -> HEC token" {
+					t.Errorf("Diff() = %s, want the auth mode to change to the HEC token", strings.Join(got, "\n"))
+				}
+				return
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff() = %s, want %s", strings.Join(got, "\n"), strings.Join(tt.want, "\n"))
+			}
+		})
+	}
+}
+
+func TestRunDiff(t *testing.T) {
+	c := liveCluster(t, testLiveCR())
+	newClient := func() (client.Client, error) { return c, nil }
+	tests := []struct {
+		name     string
+		cr       string
+		wantCode int
+		want     string
+	}{
+		{
+			name: "Changes",
+			cr: `apiVersion: splunkforwarder.managed.openshift.io/v1alpha1
+kind: SplunkForwarder
+metadata:
+  name: test
+  namespace: openshift-test
+spec:
+  image: test-image
+  imageDigest: sha256:2452a3f01e840661ee1194777ed5a9185ceaaa9ec7329ed364fa2f02be22a701
+  splunkInputs:
+  - path: /host/var/log/openshift-apiserver/audit.log
+    index: openshift_managed_audit
+  - path: /host/var/log/containers
+    index: openshift_managed_debug_node
+    nodeRoles:
+    - infra
+  - path: /host/var/log/new.log
+`,
+			want: "ConfigMap osd-monitored-logs-local: updated\n  inputs.conf\n    + [monitor:///host/var/log/new.log]\n",
+		},
+		{
+			name:     "Unknown CR",
+			cr:       strings.Replace(v1alpha1CR, "name: test", "name: other", 1),
+			wantCode: 1,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			if code := RunDiff([]string{"-f", writeCR(t, tt.cr)}, stdout, stderr, newClient); code != tt.wantCode {
+				t.Fatalf("RunDiff() = %d, want %d, stderr: %s", code, tt.wantCode, stderr)
+			}
+			if !strings.HasPrefix(stdout.String(), tt.want) {
+				t.Errorf("RunDiff() output = %q, want prefix %q", stdout, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,280 @@
+// Package render runs the pkg/kube generators offline, printing the objects the operator would create
+// for a SplunkForwarder CR without a cluster.
+package render
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+
+	corev1 "k8s.io/api/core/v1"
+	apiruntime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/serializer"
+	"k8s.io/apimachinery/pkg/types"
+	utilruntime "k8s.io/apimachinery/pkg/util/runtime"
+	clientgoscheme "k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/yaml"
+
+	configv1 "github.com/openshift/api/config/v1"
+	sfv1alpha1 "github.com/openshift/splunk-forwarder-operator/api/v1alpha1"
+	sfv1beta1 "github.com/openshift/splunk-forwarder-operator/api/v1beta1"
+	"github.com/openshift/splunk-forwarder-operator/config"
+	"github.com/openshift/splunk-forwarder-operator/pkg/kube"
+	"github.com/openshift/splunk-forwarder-operator/pkg/lookup"
+)
+
+const (
+	// OutputYAML prints the generated objects as a multi-document YAML stream
+	OutputYAML = "yaml"
+	// OutputConf prints the Splunk .conf files of the generated ConfigMaps
+	OutputConf = "conf"
+)
+
+var scheme = apiruntime.NewScheme()
+
+func init() {
+	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
+	utilruntime.Must(sfv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(sfv1beta1.AddToScheme(scheme))
+	utilruntime.Must(configv1.Install(scheme))
+}
+
+// Options are the cluster facts the operator looks up at runtime, which have to be given offline
+type Options struct {
+	// Cluster metadata added to the events. The cluster ID of the CR takes precedence.
+	ClusterMetadata kube.ClusterMetadata
+	// Whether the splunk-hec-token Secret is present, selecting the HEC mode of the forwarders
+	UseHECToken bool
+	// Image of the operator, run by the config-reload sidecar, and by the events collector when the CR does not set one
+	OperatorImage string
+	// Egress proxy of the cluster
+	Proxy kube.ClusterProxy
+	// Whether the cluster is installed in FIPS mode, followed by the CRs in Auto FIPS mode
+	FIPS bool
+	// TLS security profile of the cluster
+	TLSProfile kube.TLSProfile
+}
+
+// Decode parses a v1alpha1 or v1beta1 SplunkForwarder CR, converting it to the version the generators use
+func Decode(data []byte) (*sfv1alpha1.SplunkForwarder, error) {
+	obj, _, err := serializer.NewCodecFactory(scheme).UniversalDeserializer().Decode(data, nil, nil)
+	if err != nil {
+		return nil, fmt.Errorf("unable to decode the SplunkForwarder: %w", err)
+	}
+	switch cr := obj.(type) {
+	case *sfv1alpha1.SplunkForwarder:
+		return cr, nil
+	case *sfv1beta1.SplunkForwarder:
+		instance := &sfv1alpha1.SplunkForwarder{}
+		if err := instance.ConvertFrom(cr); err != nil {
+			return nil, err
+		}
+		return instance, nil
+	}
+	return nil, fmt.Errorf("expected a SplunkForwarder, got %T", obj)
+}
+
+// Objects returns the objects the operator generates for the CR: the ConfigMaps and DaemonSets, the
+// Heavy Forwarder ConfigMaps when it is used, the cert-manager Certificate when an issuer is referenced,
+// the ConfigMap the trusted CA bundle is injected into, and the events collector Deployment when it is
+// enabled. With a canary rollout, the canary copies of the forwarder ConfigMaps and DaemonSets are added.
+// Image tags are not resolved offline, the DaemonSets reference the tag.
+func Objects(instance *sfv1alpha1.SplunkForwarder, opts Options) ([]client.Object, error) {
+	metadata := opts.ClusterMetadata
+	if instance.Spec.ClusterID != "" {
+		metadata.ClusterID = instance.Spec.ClusterID
+	}
+	if metadata.ClusterID == "" {
+		return nil, errors.New("the CR does not set clusterID, a cluster ID has to be given")
+	}
+	if err := kube.ValidateImage(instance); err != nil {
+		return nil, fmt.Errorf("the operator does not roll out an invalid image: %w", err)
+	}
+	namespacedName := types.NamespacedName{Namespace: instance.Namespace, Name: instance.Name}
+	// The pods are not known offline, so the podLogs inputs do not render any stanza, and the content the
+	// pods mount is not hashed into the pod templates
+	state := kube.ClusterState{
+		ClusterMetadata: metadata,
+		UseHECToken:     opts.UseHECToken,
+		Proxy:           opts.Proxy,
+		FIPS:            opts.FIPS,
+		TLSProfile:      opts.TLSProfile,
+	}
+
+	objects := []client.Object{}
+	configMaps := kube.BuildConfigMaps(instance, state)
+	if kube.FIPSEnabled(instance, opts.FIPS) {
+		// The splunk-auth Secret is not known offline, only the generated configuration is checked
+		if err := kube.CheckFIPSConfigs(configMaps, nil); err != nil {
+			return nil, fmt.Errorf("the operator does not roll out non-FIPS ciphers in FIPS mode: %w", err)
+		}
+	}
+	for _, cm := range configMaps {
+		objects = append(objects, cm)
+	}
+	if instance.Spec.UseHeavyForwarder {
+		objects = append(objects, kube.GenerateInternalConfigMap(instance, namespacedName))
+		objects = append(objects, kube.GenerateFilteringConfigMap(instance, namespacedName))
+	}
+	if instance.Spec.CertificateIssuerRef != nil {
+		objects = append(objects, kube.GenerateCertificate(instance))
+	}
+	if kube.TrustedCABundleEnabled(instance) {
+		objects = append(objects, kube.GenerateTrustedCABundleConfigMap(instance))
+	}
+	daemonSets := kube.BuildDaemonSets(instance, state, opts.OperatorImage)
+	for _, ds := range daemonSets {
+		objects = append(objects, ds)
+	}
+	if kube.CanaryEnabled(instance) {
+		canaryConfigMaps, canaryDaemonSets := kube.GenerateCanaryObjects(configMaps, daemonSets, instance.Spec.RolloutStrategy.Canary.NodeSelector)
+		for _, cm := range canaryConfigMaps {
+			objects = append(objects, cm)
+		}
+		for _, ds := range canaryDaemonSets {
+			objects = append(objects, ds)
+		}
+	}
+	if kube.EventsCollectorEnabled(instance) {
+		objects = append(objects, kube.BuildEventsCollectorDeployment(instance, state, opts.OperatorImage))
+	}
+
+	for _, obj := range objects {
+		if !obj.GetObjectKind().GroupVersionKind().Empty() {
+			continue
+		}
+		gvk, err := apiutil.GVKForObject(obj, scheme)
+		if err != nil {
+			return nil, err
+		}
+		obj.GetObjectKind().SetGroupVersionKind(gvk)
+	}
+	return objects, nil
+}
+
+// WriteYAML prints the objects as a multi-document YAML stream
+func WriteYAML(w io.Writer, objects []client.Object) error {
+	for _, obj := range objects {
+		data, err := yaml.Marshal(obj)
+		if err != nil {
+			return err
+		}
+		if _, err := fmt.Fprintf(w, "---\n%s", data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// WriteConf prints the files of the ConfigMaps, each preceded by a "# <ConfigMap>/<file>" header.
+// The other objects have no Splunk configuration and are left out.
+func WriteConf(w io.Writer, objects []client.Object) error {
+	for _, obj := range objects {
+		cm, ok := obj.(*corev1.ConfigMap)
+		if !ok {
+			continue
+		}
+		files := make([]string, 0, len(cm.Data))
+		for file := range cm.Data {
+			files = append(files, file)
+		}
+		sort.Strings(files)
+		for _, file := range files {
+			if _, err := fmt.Fprintf(w, "# %s/%s\n%s\n", cm.Name, file, cm.Data[file]); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// readCR reads and decodes a CR file, "-" being stdin, defaulting its namespace
+func readCR(file, namespace string) (*sfv1alpha1.SplunkForwarder, error) {
+	var data []byte
+	var err error
+	if file == "-" {
+		data, err = io.ReadAll(os.Stdin)
+	} else {
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return nil, err
+	}
+	instance, err := Decode(data)
+	if err != nil {
+		return nil, err
+	}
+	if instance.Namespace == "" {
+		instance.Namespace = namespace
+	}
+	return instance, nil
+}
+
+// Run implements the render subcommand. It returns the exit code of the command.
+func Run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("render", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "Usage: %s render -f <cr.yaml> [flags]\n\nPrints the objects the operator generates for a SplunkForwarder CR.\n\n", config.OperatorName)
+		fs.PrintDefaults()
+	}
+	var file, namespace, output, tlsProfile string
+	opts := Options{}
+	fs.StringVar(&file, "f", "", "SplunkForwarder CR to render, v1alpha1 or v1beta1 (\"-\" for stdin)")
+	fs.StringVar(&namespace, "namespace", config.OperatorNamespace, "Namespace of the CR, when it does not set one")
+	fs.StringVar(&output, "output", OutputYAML, "Output format: \"yaml\" for the objects or \"conf\" for the Splunk .conf files")
+	fs.StringVar(&opts.ClusterMetadata.ClusterID, "cluster-id", "", "Cluster ID, used when the CR does not set clusterID")
+	fs.StringVar(&opts.ClusterMetadata.ClusterVersion, "cluster-version", "", "OpenShift version for the clusterVersion metadata field")
+	fs.StringVar(&opts.ClusterMetadata.ClusterUUID, "cluster-uuid", "", "External cluster ID for the clusterUUID metadata field")
+	fs.StringVar(&opts.ClusterMetadata.Platform, "platform", "", "Infrastructure platform for the platform metadata field")
+	fs.StringVar(&opts.ClusterMetadata.Region, "region", "", "Cloud region for the region metadata field")
+	fs.BoolVar(&opts.UseHECToken, "hec", false, "Render the forwarders for the HEC token instead of mTLS")
+	fs.StringVar(&opts.OperatorImage, "operator-image", config.OperatorName, "Operator image run by the config-reload sidecar, and by the events collector when the CR does not set one")
+	fs.StringVar(&opts.Proxy.HTTPProxy, "http-proxy", "", "HTTP proxy of the cluster")
+	fs.StringVar(&opts.Proxy.HTTPSProxy, "https-proxy", "", "HTTPS proxy of the cluster")
+	fs.StringVar(&opts.Proxy.NoProxy, "no-proxy", "", "Comma separated destinations that bypass the proxy")
+	fs.BoolVar(&opts.FIPS, "fips", false, "Render for a cluster installed in FIPS mode, used when the CR sets fips to Auto")
+	fs.StringVar(&tlsProfile, "tls-profile", "", "TLS security profile of the cluster: \"Old\", \"Intermediate\" or \"Modern\"")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if file == "" || fs.NArg() > 0 || (output != OutputYAML && output != OutputConf) {
+		fs.Usage()
+		return 2
+	}
+	if tlsProfile != "" {
+		profileType := configv1.TLSProfileType(tlsProfile)
+		if _, ok := configv1.TLSProfiles[profileType]; !ok {
+			fs.Usage()
+			return 2
+		}
+		opts.TLSProfile = lookup.TLSProfileFromSecurityProfile(&configv1.TLSSecurityProfile{Type: profileType})
+	}
+
+	instance, err := readCR(file, namespace)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+
+	objects, err := Objects(instance, opts)
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	if output == OutputConf {
+		err = WriteConf(stdout, objects)
+	} else {
+		err = WriteYAML(stdout, objects)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, err)
+		return 1
+	}
+	return 0
+}
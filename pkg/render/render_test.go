@@ -0,0 +1,180 @@
+package render
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	appsv1 "k8s.io/api/apps/v1"
+	corev1 "k8s.io/api/core/v1"
+	"sigs.k8s.io/yaml"
+)
+
+const v1alpha1CR = `apiVersion: splunkforwarder.managed.openshift.io/v1alpha1
+kind: SplunkForwarder
+metadata:
+  name: test
+  namespace: openshift-test
+spec:
+  image: test-image
+  imageDigest: sha256:2452a3f01e840661ee1194777ed5a9185ceaaa9ec7329ed364fa2f02be22a701
+  splunkLicenseAccepted: true
+  splunkInputs:
+  - path: /host/var/log/openshift-apiserver/audit.log
+    index: openshift_managed_audit
+`
+
+const v1beta1CR = `apiVersion: splunkforwarder.managed.openshift.io/v1beta1
+kind: SplunkForwarder
+metadata:
+  name: test
+spec:
+  splunkLicenseAccepted: true
+  forwarder:
+    image:
+      repository: test-image
+      digest: sha256:2452a3f01e840661ee1194777ed5a9185ceaaa9ec7329ed364fa2f02be22a701
+    inputs:
+    - path: /host/var/log/openshift-apiserver/audit.log
+      index: openshift_managed_audit
+  heavyForwarder:
+    enabled: true
+    image:
+      repository: test-hf-image
+  outputs:
+    clusterID: from-cr
+`
+
+func writeCR(t *testing.T, cr string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "cr.yaml")
+	if err := os.WriteFile(path, []byte(cr), 0o600); err != nil {
+		t.Fatalf("unable to write CR: %v", err)
+	}
+	return path
+}
+
+func TestRun(t *testing.T) {
+	tests := []struct {
+		name       string
+		cr         string
+		args       []string
+		wantCode   int
+		wantOutput []string
+		wantError  string
+	}{
+		{
+			name:     "YAML",
+			cr:       v1alpha1CR,
+			args:     []string{"--cluster-id", "x"},
+			wantCode: 0,
+			wantOutput: []string{
+				"kind: ConfigMap\nmetadata:\n  annotations:\n    genVersion: \"0\"\n  labels:\n    app: test\n  name: osd-monitored-logs-local\n  namespace: openshift-test\n",
+				"_meta = clusterid::x",
+				"apiVersion: apps/v1\nkind: DaemonSet\n",
+				"name: test-ds\n",
+			},
+		},
+		{
+			name:     "Conf files of a v1beta1 CR",
+			cr:       v1beta1CR,
+			args:     []string{"--cluster-id", "x", "--output", "conf"},
+			wantCode: 0,
+			wantOutput: []string{
+				"# osd-monitored-logs-local/inputs.conf\n[monitor:///host/var/log/openshift-apiserver/audit.log]\n",
+				"_meta = clusterid::from-cr\n",
+				"# test-internalsplunk/outputs.conf\n",
+				"# test-hfconfig/inputs.conf\n",
+			},
+		},
+		{
+			name:      "No cluster ID",
+			cr:        v1alpha1CR,
+			wantCode:  1,
+			wantError: "a cluster ID has to be given",
+		},
+		{
+			name:      "Not a SplunkForwarder",
+			cr:        "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: test\n",
+			args:      []string{"--cluster-id", "x"},
+			wantCode:  1,
+			wantError: "expected a SplunkForwarder",
+		},
+		{
+			name:     "Unknown output format",
+			cr:       v1alpha1CR,
+			args:     []string{"--output", "json"},
+			wantCode: 2,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stdout, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+			args := append([]string{"-f", writeCR(t, tt.cr)}, tt.args...)
+			if code := Run(args, stdout, stderr); code != tt.wantCode {
+				t.Fatalf("Run() = %d, want %d, stderr: %s", code, tt.wantCode, stderr)
+			}
+			for _, want := range tt.wantOutput {
+				if !strings.Contains(stdout.String(), want) {
+					t.Errorf("Run() output does not contain %q:\n%s", want, stdout)
+				}
+			}
+			if !strings.Contains(stderr.String(), tt.wantError) {
+				t.Errorf("Run() error = %q, want %q", stderr, tt.wantError)
+			}
+		})
+	}
+}
+
+func TestObjects(t *testing.T) {
+	instance, err := Decode([]byte(v1beta1CR))
+	if err != nil {
+		t.Fatalf("Decode() error = %v", err)
+	}
+	instance.Namespace = "openshift-test"
+
+	objects, err := Objects(instance, Options{UseHECToken: true})
+	if err != nil {
+		t.Fatalf("Objects() error = %v", err)
+	}
+	kinds := []string{}
+	for _, obj := range objects {
+		kinds = append(kinds, obj.GetObjectKind().GroupVersionKind().Kind+"/"+obj.GetName())
+	}
+	want := []string{
+		"ConfigMap/osd-monitored-logs-metadata",
+		"ConfigMap/osd-monitored-logs-local",
+		"ConfigMap/test-internalsplunk",
+		"ConfigMap/test-hfconfig",
+		"DaemonSet/test-ds",
+	}
+	if strings.Join(kinds, ",") != strings.Join(want, ",") {
+		t.Errorf("Objects() = %v, want %v", kinds, want)
+	}
+
+	// The printed objects can be read back
+	out := &bytes.Buffer{}
+	if err := WriteYAML(out, objects); err != nil {
+		t.Fatalf("WriteYAML() error = %v", err)
+	}
+	documents := strings.Split(strings.TrimPrefix(out.String(), "---\n"), "---\n")
+	if len(documents) != len(objects) {
+		t.Fatalf("WriteYAML() printed %d documents, want %d", len(documents), len(objects))
+	}
+	cm := &corev1.ConfigMap{}
+	if err := yaml.Unmarshal([]byte(documents[1]), cm); err != nil {
+		t.Fatalf("unable to read back the ConfigMap: %v", err)
+	}
+	if cm.Data["inputs.conf"] != objects[1].(*corev1.ConfigMap).Data["inputs.conf"] {
+		t.Errorf("read back inputs.conf = %q", cm.Data["inputs.conf"])
+	}
+	ds := &appsv1.DaemonSet{}
+	if err := yaml.Unmarshal([]byte(documents[4]), ds); err != nil {
+		t.Fatalf("unable to read back the DaemonSet: %v", err)
+	}
+	if ds.Spec.Template.Spec.Containers[0].Image != "test-image@sha256:2452a3f01e840661ee1194777ed5a9185ceaaa9ec7329ed364fa2f02be22a701" {
+		t.Errorf("read back image = %q", ds.Spec.Template.Spec.Containers[0].Image)
+	}
+}
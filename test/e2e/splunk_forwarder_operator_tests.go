@@ -37,7 +37,9 @@ var (
 	dedicatedAdminSplunkForwarder = "osde2e-dedicated-admin-splunkforwarder-x"
 	operatorNamespace             = "openshift-splunk-forwarder-operator"
 	operatorLockFile              = "splunk-forwarder-operator-lock"
-	clusterID                     string
+	// forwarderImageDigest pins the forwarder image of the test CRs, which must not use the latest tag
+	forwarderImageDigest = "sha256:74b6a7e80da95b5bde5d7aade76d306b383430fc9a73f0b650ffaddf87b9a784"
+	clusterID            string
 )
 
 // Blocking SplunkForwarder Signal
@@ -411,7 +413,7 @@ var _ = ginkgo.Describe("Splunk Forwarder Operator", ginkgo.Ordered, func() {
 			Spec: sfv1alpha1.SplunkForwarderSpec{
 				SplunkLicenseAccepted: true,
 				Image:                 "quay.io/redhat-services-prod/openshift/splunk-forwarder-images",
-				ImageTag:              "latest",
+				ImageDigest:           forwarderImageDigest,
 				ClusterID:             "multi-index-cluster",
 				SplunkInputs: []sfv1alpha1.SplunkForwarderInputs{
 					{
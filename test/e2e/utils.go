@@ -54,7 +54,7 @@ func makeSplunkforwarderWithIndex(name, namespace, path, index, sourcetype strin
 		Spec: sfv1alpha1.SplunkForwarderSpec{
 			SplunkLicenseAccepted: true,
 			Image:                 "quay.io/redhat-services-prod/openshift/splunk-forwarder-images",
-			ImageTag:              "latest",
+			ImageDigest:           forwarderImageDigest,
 			SplunkInputs: []sfv1alpha1.SplunkForwarderInputs{
 				{
 					Path:       path,